@@ -8,6 +8,8 @@ import (
 	_ "github.com/muchlist/berita_acara/docs"
 	"github.com/muchlist/berita_acara/utils/logger"
 	"github.com/muchlist/berita_acara/utils/mjwt"
+	"github.com/muchlist/berita_acara/utils/mpki"
+	"github.com/muchlist/berita_acara/utils/mtotp"
 	"log"
 	"os"
 	"os/signal"
@@ -25,6 +27,9 @@ import (
 // @securityDefinitions.apikey bearerAuth
 // @in header
 // @name Authorization
+// @securityDefinitions.apikey apiKeyAuth
+// @in header
+// @name X-API-Key
 // @host localhost:3500
 // @BasePath /api/v1
 func RunApp() {
@@ -34,9 +39,13 @@ func RunApp() {
 	db.Init()
 	defer db.Close()
 	mjwt.Init()
+	mpki.Init()
+	mtotp.Init()
 
 	// membuat fiber app
-	app := fiber.New()
+	app := fiber.New(fiber.Config{
+		ProxyHeader: configs.Config.PROXYHEADER,
+	})
 
 	// gracefully shutdown
 	c := make(chan os.Signal, 1)
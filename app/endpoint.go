@@ -1,18 +1,46 @@
 package app
 
 import (
+	"context"
 	swagger "github.com/arsmn/fiber-swagger/v2"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/muchlist/berita_acara/configs/permission"
 	"github.com/muchlist/berita_acara/configs/roles"
+	"github.com/muchlist/berita_acara/dao/apikeydao"
+	"github.com/muchlist/berita_acara/dao/attemptdao"
+	"github.com/muchlist/berita_acara/dao/auditdao"
+	"github.com/muchlist/berita_acara/dao/beritaacaradao"
+	"github.com/muchlist/berita_acara/dao/denylistdao"
+	"github.com/muchlist/berita_acara/dao/keydao"
+	"github.com/muchlist/berita_acara/dao/mfadao"
+	"github.com/muchlist/berita_acara/dao/numberingdao"
+	"github.com/muchlist/berita_acara/dao/oidcdao"
+	"github.com/muchlist/berita_acara/dao/resetdao"
+	"github.com/muchlist/berita_acara/dao/roledao"
+	"github.com/muchlist/berita_acara/dao/sessiondao"
+	"github.com/muchlist/berita_acara/dao/templatedao"
+	"github.com/muchlist/berita_acara/dao/tokendao"
 	"github.com/muchlist/berita_acara/dao/userdao"
 	"github.com/muchlist/berita_acara/db"
 	"github.com/muchlist/berita_acara/handler"
 	"github.com/muchlist/berita_acara/middle"
+	"github.com/muchlist/berita_acara/services/apikeyserv"
+	"github.com/muchlist/berita_acara/services/beritaacaraserv"
+	"github.com/muchlist/berita_acara/services/numberingserv"
+	"github.com/muchlist/berita_acara/services/roleserv"
+	"github.com/muchlist/berita_acara/services/templateserv"
 	"github.com/muchlist/berita_acara/services/userserv"
+	"github.com/muchlist/berita_acara/utils/mailer"
 	"github.com/muchlist/berita_acara/utils/mcrypt"
 	"github.com/muchlist/berita_acara/utils/mjwt"
+	"github.com/muchlist/berita_acara/utils/mldap"
+	"github.com/muchlist/berita_acara/utils/moidc"
+	"github.com/muchlist/berita_acara/utils/mpdf"
+	"github.com/muchlist/berita_acara/utils/mpki"
+	"github.com/muchlist/berita_acara/utils/mtotp"
+	"log"
 )
 
 func prepareEndPoint(app *fiber.App) {
@@ -20,16 +48,64 @@ func prepareEndPoint(app *fiber.App) {
 	// Utils
 	cryptoUtils := mcrypt.NewCrypto()
 	jwt := mjwt.NewJwt()
+	pdf := mpdf.NewPdf()
+	pki := mpki.NewPki()
+	totp := mtotp.NewTotp()
+	mail := mailer.NewMailer()
+	oidcClient := moidc.NewOIDC()
+	ldapClient := mldap.NewLDAP()
+
+	// Role Domain
+	roleDao := roledao.New(db.DB)
+	roleService := roleserv.NewRoleService(roleDao)
+	roleHandler := handler.NewRoleHandler(roleService)
+	if err := roleService.LoadRoles(context.Background()); err != nil {
+		log.Fatalf("gagal memuat role: %s", err.Error())
+	}
+	middle.SetPermissionResolver(roleService)
 
 	// User Domain
 	userDao := userdao.New(db.DB)
-	userService := userserv.NewUserService(userDao, cryptoUtils, jwt)
+	tokenDao := tokendao.New(db.DB)
+	mfaDao := mfadao.New(db.DB)
+	attemptDao := attemptdao.New(db.DB)
+	auditDao := auditdao.New(db.DB)
+	resetDao := resetdao.New(db.DB)
+	sessionDao := sessiondao.New(db.DB)
+	oidcDao := oidcdao.New(db.DB)
+	denylistDao := denylistdao.NewCached(denylistdao.New(db.DB), denylistdao.DefaultCacheTTL)
+	middle.SetTokenDenylist(denylistDao)
+	userService := userserv.NewUserService(userDao, tokenDao, mfaDao, attemptDao, auditDao, resetDao, sessionDao, oidcDao, denylistDao, cryptoUtils, jwt, totp, mail, oidcClient, ldapClient)
+	middle.SetImpersonationRecorder(userService)
 	userHandler := handler.NewUserHandler(userService)
+	jwksHandler := handler.NewJwksHandler(jwt)
+
+	// API Key Domain
+	apiKeyDao := apikeydao.New(db.DB)
+	apiKeyService := apikeyserv.NewAPIKeyService(apiKeyDao, userDao, auditDao, cryptoUtils)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	middle.SetAPIKeyAuthenticator(apiKeyService)
+
+	// Document Domain
+	documentDao := beritaacaradao.New(db.DB)
+	keyDao := keydao.New(db.DB)
+	documentService := beritaacaraserv.NewDocumentService(documentDao, userDao, keyDao, pdf, pki)
+	documentHandler := handler.NewDocumentHandler(documentService)
+
+	// Template Domain
+	templateDao := templatedao.New(db.DB)
+	templateService := templateserv.NewTemplateService(templateDao)
+	templateHandler := handler.NewTemplateHandler(templateService)
+
+	// Numbering Domain
+	numberingDao := numberingdao.New(db.DB)
+	numberingService := numberingserv.NewNumberingService(numberingDao)
+	numberingHandler := handler.NewNumberingHandler(numberingService)
 
 	app.Use(logger.New())
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
-		AllowHeaders: "Content-Type, Accept, Authorization",
+		AllowHeaders: "Content-Type, Accept, Authorization, X-API-Key",
 	}))
 
 	app.Get("/swagger/*", swagger.Handler) // default
@@ -41,6 +117,12 @@ func prepareEndPoint(app *fiber.App) {
 		DocExpansion: "none",
 	}))
 
+	// halaman publik verifikasi document dari QR code
+	app.Get("/verify/:token", documentHandler.VerifyPage)
+
+	// public key untuk verifikasi token oleh service lain
+	app.Get("/.well-known/jwks.json", jwksHandler.JWKS)
+
 	// url mapping
 	api := app.Group("/api/v1")
 
@@ -48,9 +130,68 @@ func prepareEndPoint(app *fiber.App) {
 	api.Get("/users/:id", userHandler.Get)
 	api.Get("/users", middle.NormalAuth(), userHandler.Find)
 	api.Post("/login", userHandler.Login)
+	api.Post("/login/mfa", userHandler.LoginMFA)
+	api.Get("/oidc/login", userHandler.OIDCLogin)
+	api.Get("/oidc/callback", userHandler.OIDCCallback)
 	api.Post("/refresh", userHandler.RefreshToken)
+	api.Post("/logout", middle.OptionalAuth(), userHandler.Logout)
+	api.Post("/forgot-password", userHandler.ForgotPassword)
+	api.Post("/reset-password", userHandler.ResetPassword)
 	api.Get("/profile", middle.NormalAuth(), userHandler.GetProfile)
+	api.Put("/profile", middle.NormalAuth(), userHandler.EditProfile)
+	api.Get("/profile/sessions", middle.NormalAuth(), userHandler.GetProfileSessions)
+	api.Delete("/profile/sessions/:id", middle.NormalAuth(), userHandler.DeleteProfileSession)
+	api.Post("/profile/password", middle.FreshAuth(), userHandler.ChangePassword)
+	api.Post("/profile/mfa/enroll", middle.MFAAuth(), userHandler.EnrollMFA)
+	api.Post("/profile/mfa/enable", middle.MFAAuth(), userHandler.EnableMFA)
+	api.Post("/profile/mfa/disable", middle.FreshAuth(), userHandler.DisableMFA)
 	api.Post("/register", middle.NormalAuth(roles.RoleAdmin), userHandler.Register)
 	api.Put("/users/:id", middle.NormalAuth(roles.RoleAdmin), userHandler.Edit)
 	api.Delete("/users/:id", middle.NormalAuth(roles.RoleAdmin), userHandler.Delete)
+	api.Post("/users/:id/revoke-sessions", middle.NormalAuth(roles.RoleAdmin), userHandler.RevokeSessions)
+	api.Post("/users/:id/unlock", middle.NormalAuth(roles.RoleAdmin), userHandler.Unlock)
+	api.Post("/users/:id/impersonate", middle.FreshAuth(roles.RoleAdmin), userHandler.Impersonate)
+	api.Get("/users/:id/sessions", middle.NormalAuth(roles.RoleAdmin), userHandler.GetSessions)
+	api.Delete("/users/:id/sessions/:session_id", middle.NormalAuth(roles.RoleAdmin), userHandler.DeleteSession)
+
+	//API KEY
+	api.Post("/service-accounts", middle.NormalAuth(roles.RoleAdmin), apiKeyHandler.CreateServiceAccount)
+	api.Get("/users/:id/api-keys", middle.NormalAuth(roles.RoleAdmin), apiKeyHandler.Find)
+	api.Post("/users/:id/api-keys", middle.NormalAuth(roles.RoleAdmin), apiKeyHandler.Create)
+	api.Delete("/api-keys/:id", middle.NormalAuth(roles.RoleAdmin), apiKeyHandler.Revoke)
+
+	//ROLE
+	api.Get("/roles/:name", middle.RequirePermission(permission.RoleWrite), roleHandler.Get)
+	api.Get("/roles", middle.RequirePermission(permission.RoleWrite), roleHandler.Find)
+	api.Post("/roles", middle.RequirePermission(permission.RoleWrite), roleHandler.Insert)
+	api.Put("/roles/:name", middle.FreshAuth(), middle.RequirePermission(permission.RoleWrite), roleHandler.Rename)
+	api.Delete("/roles/:name", middle.FreshAuth(), middle.RequirePermission(permission.RoleWrite), roleHandler.Delete)
+	api.Post("/roles/:name/permissions", middle.RequirePermission(permission.RoleWrite), roleHandler.AddPermissions)
+	api.Delete("/roles/:name/permissions/:permission", middle.RequirePermission(permission.RoleWrite), roleHandler.RemovePermission)
+
+	//DOCUMENT
+	api.Get("/documents/:id", middle.NormalAuth(), documentHandler.Get)
+	api.Get("/documents/:id/pdf", middle.NormalAuth(), documentHandler.GetPDF)
+	api.Get("/documents/:id/verify", middle.NormalAuth(), documentHandler.Verify)
+	api.Get("/documents", middle.NormalAuth(), documentHandler.Find)
+	api.Post("/documents", middle.NormalAuth(), documentHandler.Insert)
+	api.Put("/documents/:id", middle.NormalAuth(), documentHandler.Edit)
+	api.Delete("/documents/:id", middle.NormalAuth(), documentHandler.Delete)
+	api.Post("/documents/:id/signatories", middle.NormalAuth(), documentHandler.RequestSignature)
+	api.Post("/documents/:id/sign", middle.FreshAuth(), documentHandler.Sign)
+	api.Post("/documents/:id/reject", middle.NormalAuth(), documentHandler.Reject)
+
+	//TEMPLATE
+	api.Get("/templates/:id", middle.NormalAuth(), templateHandler.Get)
+	api.Get("/templates", middle.NormalAuth(), templateHandler.Find)
+	api.Post("/templates/:id/render", middle.NormalAuth(), templateHandler.Render)
+	api.Post("/templates", middle.RequirePermission(permission.TemplateWrite), templateHandler.Insert)
+	api.Put("/templates/:id", middle.RequirePermission(permission.TemplateWrite), templateHandler.Edit)
+	api.Delete("/templates/:id", middle.RequirePermission(permission.TemplateWrite), templateHandler.Delete)
+
+	//NUMBERING
+	api.Get("/numbering-schemes/:unit", middle.NormalAuth(), numberingHandler.Get)
+	api.Get("/numbering-schemes", middle.NormalAuth(), numberingHandler.Find)
+	api.Put("/numbering-schemes/:unit", middle.RequirePermission(permission.NumberingWrite), numberingHandler.Upsert)
+	api.Delete("/numbering-schemes/:unit", middle.RequirePermission(permission.NumberingWrite), numberingHandler.Delete)
 }
@@ -2,8 +2,10 @@ package configs
 
 import (
 	"github.com/joho/godotenv"
+	"github.com/muchlist/berita_acara/utils/sfunc"
 	"log"
 	"os"
+	"strings"
 )
 
 type configuration struct {
@@ -15,6 +17,85 @@ type configuration struct {
 	LOGLEVEL  string
 	LOGOUTPUT string
 	SECRETKEY string
+
+	JWTALGORITHM      string
+	JWTPRIVATEKEYFILE string
+	JWTPUBLICKEYFILES []string
+	JWTISSUER         string
+	JWTAUDIENCE       string
+	JWTLEEWAY         int // detik
+
+	// lifetime token dalam menit
+	ACCESSTOKENMINUTE  int
+	REFRESHTOKENMINUTE int
+	FRESHTOKENMINUTE   int
+	// token impersonation tidak memiliki refresh token dan tidak pernah fresh
+	IMPERSONATETOKENMINUTE int
+
+	// hash password baru menggunakan argon2id (default) atau bcrypt, hash lama diperbarui saat login
+	PASSWORDALGORITHM string
+	BCRYPTCOST        int
+	ARGON2MEMORY      int // KiB
+	ARGON2TIME        int
+	ARGON2THREADS     int
+
+	MFAISSUER       string
+	MFAREQUIREADMIN bool
+
+	// batas login gagal berturut-turut per user dan per IP sebelum dikunci selama LOGINLOCKMINUTE,
+	// sebelum dikunci setiap kegagalan memblokir percobaan berikutnya selama LOGINBACKOFFSECOND * 2^(gagal-1)
+	LOGINMAXFAILURE    int
+	LOGINMAXFAILUREIP  int
+	LOGINBACKOFFSECOND int
+	LOGINLOCKMINUTE    int
+
+	// BA_MAIL_DRIVER smtp atau log (default), driver log menulis email ke MAILLOGFILE atau ke log aplikasi
+	MAILDRIVER   string
+	MAILFROM     string
+	MAILLOGFILE  string
+	SMTPHOST     string
+	SMTPPORT     int
+	SMTPUSERNAME string
+	SMTPPASSWORD string
+
+	// alamat halaman reset password pada frontend, token ditambahkan sebagai query ?token=
+	RESETPASSWORDURL string
+	RESETTOKENMINUTE int
+
+	// login melalui identity provider OIDC, nonaktif apabila OIDCISSUER kosong. User yang belum terdaftar
+	// dibuat otomatis dengan role BASIC apabila OIDCAUTOPROVISION aktif
+	OIDCISSUER        string
+	OIDCCLIENTID      string
+	OIDCCLIENTSECRET  string
+	OIDCREDIRECTURL   string
+	OIDCSCOPES        []string
+	OIDCAUTOPROVISION bool
+	OIDCSTATEMINUTE   int
+
+	// BA_AUTH_BACKEND local (default) atau ldap, backend ldap memeriksa password melalui bind ke directory
+	// dan menyinkronkan role user berdasarkan LDAPGROUPROLES setiap login
+	AUTHBACKEND        string
+	LDAPURL            string
+	LDAPBINDDN         string
+	LDAPBINDPASSWORD   string
+	LDAPBASEDN         string
+	LDAPUSERFILTER     string
+	LDAPGROUPATTRIBUTE string
+	LDAPGROUPROLES     string
+	LDAPSTARTTLS       bool
+	LDAPTLSSKIPVERIFY  bool
+	LDAPCACERTFILE     string
+
+	// header berisi ip client apabila aplikasi berada di belakang reverse proxy, misalnya X-Real-IP
+	PROXYHEADER string
+
+	CACERTFILE string
+	CAKEYFILE  string
+
+	PUBLICURL string
+
+	LETTERHEADTITLE   string
+	LETTERHEADADDRESS string
 }
 
 var (
@@ -36,4 +117,83 @@ func Init() {
 	Config.LOGLEVEL = os.Getenv("BA_LOG_LEVEL")
 	Config.LOGLEVEL = os.Getenv("BA_LOG_OUTPUT")
 	Config.SECRETKEY = os.Getenv("BA_SECRET_KEY")
+
+	Config.JWTALGORITHM = os.Getenv("BA_JWT_ALGORITHM")
+	Config.JWTPRIVATEKEYFILE = os.Getenv("BA_JWT_PRIVATE_KEY_FILE")
+	Config.JWTISSUER = getEnvDefault("BA_JWT_ISSUER", "berita_acara")
+	Config.JWTAUDIENCE = getEnvDefault("BA_JWT_AUDIENCE", "berita_acara")
+	Config.JWTLEEWAY = sfunc.StrToInt(os.Getenv("BA_JWT_LEEWAY"), 30)
+
+	Config.ACCESSTOKENMINUTE = sfunc.StrToInt(os.Getenv("BA_ACCESS_TOKEN_MINUTE"), 60)
+	Config.REFRESHTOKENMINUTE = sfunc.StrToInt(os.Getenv("BA_REFRESH_TOKEN_MINUTE"), 60*24*10)
+	Config.FRESHTOKENMINUTE = sfunc.StrToInt(os.Getenv("BA_FRESH_TOKEN_MINUTE"), 15)
+	Config.IMPERSONATETOKENMINUTE = sfunc.StrToInt(os.Getenv("BA_IMPERSONATE_TOKEN_MINUTE"), 15)
+
+	Config.PASSWORDALGORITHM = getEnvDefault("BA_PASSWORD_ALGORITHM", "argon2id")
+	Config.BCRYPTCOST = sfunc.StrToInt(os.Getenv("BA_BCRYPT_COST"), 12)
+	Config.ARGON2MEMORY = sfunc.StrToInt(os.Getenv("BA_ARGON2_MEMORY"), 64*1024)
+	Config.ARGON2TIME = sfunc.StrToInt(os.Getenv("BA_ARGON2_TIME"), 3)
+	Config.ARGON2THREADS = sfunc.StrToInt(os.Getenv("BA_ARGON2_THREADS"), 2)
+
+	Config.MFAISSUER = getEnvDefault("BA_MFA_ISSUER", "Berita Acara")
+	Config.MFAREQUIREADMIN = strings.EqualFold(os.Getenv("BA_MFA_REQUIRE_ADMIN"), "true")
+
+	Config.LOGINMAXFAILURE = sfunc.StrToInt(os.Getenv("BA_LOGIN_MAX_FAILURE"), 5)
+	Config.LOGINMAXFAILUREIP = sfunc.StrToInt(os.Getenv("BA_LOGIN_MAX_FAILURE_IP"), 20)
+	Config.LOGINBACKOFFSECOND = sfunc.StrToInt(os.Getenv("BA_LOGIN_BACKOFF_SECOND"), 1)
+	Config.LOGINLOCKMINUTE = sfunc.StrToInt(os.Getenv("BA_LOGIN_LOCK_MINUTE"), 15)
+	Config.PROXYHEADER = os.Getenv("BA_PROXY_HEADER")
+
+	Config.MAILDRIVER = getEnvDefault("BA_MAIL_DRIVER", "log")
+	Config.MAILFROM = getEnvDefault("BA_MAIL_FROM", "noreply@localhost")
+	Config.MAILLOGFILE = os.Getenv("BA_MAIL_LOG_FILE")
+	Config.SMTPHOST = os.Getenv("BA_SMTP_HOST")
+	Config.SMTPPORT = sfunc.StrToInt(os.Getenv("BA_SMTP_PORT"), 587)
+	Config.SMTPUSERNAME = os.Getenv("BA_SMTP_USERNAME")
+	Config.SMTPPASSWORD = os.Getenv("BA_SMTP_PASSWORD")
+
+	// daftar file dipisahkan koma, berisi public key lama yang masih diterima selama rotasi
+	for _, file := range strings.Split(os.Getenv("BA_JWT_PUBLIC_KEY_FILES"), ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			Config.JWTPUBLICKEYFILES = append(Config.JWTPUBLICKEYFILES, file)
+		}
+	}
+
+	Config.CACERTFILE = os.Getenv("BA_CA_CERT_FILE")
+	Config.CAKEYFILE = os.Getenv("BA_CA_KEY_FILE")
+
+	Config.PUBLICURL = strings.TrimSuffix(os.Getenv("BA_PUBLIC_URL"), "/")
+	Config.RESETPASSWORDURL = getEnvDefault("BA_RESET_PASSWORD_URL", Config.PUBLICURL+"/reset-password")
+	Config.RESETTOKENMINUTE = sfunc.StrToInt(os.Getenv("BA_RESET_TOKEN_MINUTE"), 30)
+
+	Config.OIDCISSUER = os.Getenv("BA_OIDC_ISSUER")
+	Config.OIDCCLIENTID = os.Getenv("BA_OIDC_CLIENT_ID")
+	Config.OIDCCLIENTSECRET = os.Getenv("BA_OIDC_CLIENT_SECRET")
+	Config.OIDCREDIRECTURL = getEnvDefault("BA_OIDC_REDIRECT_URL", Config.PUBLICURL+"/api/v1/oidc/callback")
+	Config.OIDCAUTOPROVISION = !strings.EqualFold(os.Getenv("BA_OIDC_AUTO_PROVISION"), "false")
+	Config.OIDCSTATEMINUTE = sfunc.StrToInt(os.Getenv("BA_OIDC_STATE_MINUTE"), 10)
+	// daftar scope dipisahkan spasi, default openid email profile
+	Config.OIDCSCOPES = strings.Fields(os.Getenv("BA_OIDC_SCOPES"))
+
+	Config.AUTHBACKEND = getEnvDefault("BA_AUTH_BACKEND", "local")
+	Config.LDAPURL = os.Getenv("BA_LDAP_URL")
+	Config.LDAPBINDDN = os.Getenv("BA_LDAP_BIND_DN")
+	Config.LDAPBINDPASSWORD = os.Getenv("BA_LDAP_BIND_PASSWORD")
+	Config.LDAPBASEDN = os.Getenv("BA_LDAP_BASE_DN")
+	Config.LDAPUSERFILTER = getEnvDefault("BA_LDAP_USER_FILTER", "(mail=%s)")
+	Config.LDAPGROUPATTRIBUTE = getEnvDefault("BA_LDAP_GROUP_ATTRIBUTE", "memberOf")
+	Config.LDAPGROUPROLES = os.Getenv("BA_LDAP_GROUP_ROLES")
+	Config.LDAPSTARTTLS = strings.EqualFold(os.Getenv("BA_LDAP_START_TLS"), "true")
+	Config.LDAPTLSSKIPVERIFY = strings.EqualFold(os.Getenv("BA_LDAP_TLS_SKIP_VERIFY"), "true")
+	Config.LDAPCACERTFILE = os.Getenv("BA_LDAP_CA_CERT_FILE")
+
+	Config.LETTERHEADTITLE = os.Getenv("BA_LETTERHEAD_TITLE")
+	Config.LETTERHEADADDRESS = os.Getenv("BA_LETTERHEAD_ADDRESS")
+}
+
+func getEnvDefault(key string, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
 }
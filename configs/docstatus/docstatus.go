@@ -0,0 +1,37 @@
+package docstatus
+
+// Status document
+const (
+	Draft            = "draft"
+	WaitingSignature = "waiting_signature"
+	Signed           = "signed"
+	Rejected         = "rejected"
+)
+
+// Status penandatangan pada sebuah document
+const (
+	SignerPending  = "pending"
+	SignerSigned   = "signed"
+	SignerRejected = "rejected"
+)
+
+// transitions daftar perpindahan status document yang diijinkan
+// draft -> waiting_signature -> signed / rejected
+var transitions = map[string][]string{
+	Draft:            {WaitingSignature},
+	WaitingSignature: {Signed, Rejected},
+}
+
+func GetStatusAvailable() []string {
+	return []string{Draft, WaitingSignature, Signed, Rejected}
+}
+
+// CanTransition return true jika document boleh berpindah dari status from ke status to
+func CanTransition(from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
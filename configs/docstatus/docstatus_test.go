@@ -0,0 +1,24 @@
+package docstatus
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want bool
+	}{
+		{Draft, WaitingSignature, true},
+		{WaitingSignature, Signed, true},
+		{WaitingSignature, Rejected, true},
+		{Draft, Signed, false},
+		{Signed, Draft, false},
+		{Rejected, WaitingSignature, false},
+		{WaitingSignature, Draft, false},
+	}
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package permission
+
+// permission yang digunakan pada endpoint, format resource:action
+const (
+	RoleWrite      = "role:write"
+	TemplateWrite  = "template:write"
+	NumberingWrite = "numbering:write"
+)
+
+// GetAdminDefault permission yang selalu dimiliki role ADMIN, dikembalikan setiap aplikasi start
+func GetAdminDefault() []string {
+	return []string{RoleWrite, TemplateWrite, NumberingWrite}
+}
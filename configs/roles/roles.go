@@ -1,11 +1,59 @@
 package roles
 
+import "sync"
+
+// role bawaan yang dibuat saat aplikasi start, digunakan langsung pada kode sehingga tidak dapat diubah maupun dihapus
 const (
 	RoleAdmin  = "ADMIN"
 	RoleNormal = "NORMAL"
 	RoleBasic  = "BASIC"
 )
 
-func GetRolesAvailable() []string {
+var (
+	mu        sync.RWMutex
+	available = GetRolesDefault()
+)
+
+func GetRolesDefault() []string {
 	return []string{RoleAdmin, RoleNormal, RoleBasic}
 }
+
+// IsDefault return true jika role merupakan role bawaan
+func IsDefault(role string) bool {
+	for _, r := range GetRolesDefault() {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRolesAvailable mengembalikan daftar role yang tersimpan di database,
+// sebelum SetRolesAvailable dipanggil berisi role bawaan
+func GetRolesAvailable() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	result := make([]string, len(available))
+	copy(result, available)
+	return result
+}
+
+// SetRolesAvailable mengganti daftar role, dipanggil saat start dan setiap kali role berubah
+func SetRolesAvailable(roles []string) {
+	mu.Lock()
+	defer mu.Unlock()
+	available = make([]string, len(roles))
+	copy(available, roles)
+}
+
+// IsAvailable return true jika role terdapat pada daftar role
+func IsAvailable(role string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, r := range available {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
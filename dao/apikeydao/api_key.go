@@ -0,0 +1,265 @@
+package apikeydao
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/muchlist/berita_acara/dao"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/logger"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/muchlist/berita_acara/utils/sql_err"
+)
+
+const (
+	keyAPIKeyTable = "api_keys"
+	keyID          = "id"
+	keyUsersID     = "users_id"
+	keyName        = "name"
+	keyKeyHash     = "key_hash"
+	keyExpiresAt   = "expires_at"
+	keyLastUsedAt  = "last_used_at"
+	keyRevokedAt   = "revoked_at"
+	keyCreatedBy   = "created_by"
+	keyCreatedAt   = "created_at"
+
+	keyScopeTable = "api_key_scopes"
+	keyAPIKeysID  = "api_keys_id"
+	keyRolesName  = "roles_name"
+
+	// lastUsedInterval last_used_at hanya diperbarui jika sudah lewat sekian detik
+	// agar setiap request tidak selalu menulis ke database
+	lastUsedInterval = 60
+)
+
+type apiKeyDao struct {
+	db *pgxpool.Pool
+	sb squirrel.StatementBuilderType
+}
+
+func New(db *pgxpool.Pool) APIKeyDaoAssumer {
+	return &apiKeyDao{
+		db: db,
+		sb: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+// Insert menyimpan api key beserta scope nya
+func (a *apiKeyDao) Insert(ctx context.Context, key dto.APIKey) rest_err.APIError {
+	if len(key.Scopes) == 0 {
+		return rest_err.NewBadRequestError("scope tidak boleh kosong")
+	}
+
+	// ------------------------------------------------------------------------- begin
+	trx, err := a.db.Begin(ctx)
+	if err != nil {
+		return rest_err.NewInternalServerError("gagal memulai transaksi", err)
+	}
+	defer func(trx pgx.Tx) {
+		_ = trx.Rollback(context.Background())
+	}(trx)
+
+	// ------------------------------------------------------------------------- insert key
+	sqlStatement, args, err := a.sb.Insert(keyAPIKeyTable).
+		Columns(keyID, keyUsersID, keyName, keyKeyHash, keyExpiresAt, keyCreatedBy, keyCreatedAt).
+		Values(key.ID, key.UserID, key.Name, key.KeyHash, key.ExpiresAt, key.CreatedBy, key.CreatedAt).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = trx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat trx exec api key (Insert:0)", err)
+		return sql_err.ParseError(err)
+	}
+
+	// ------------------------------------------------------------------------- insert scope
+	sqlInsert := a.sb.Insert(keyScopeTable).Columns(keyAPIKeysID, keyRolesName)
+	for _, scope := range key.Scopes {
+		sqlInsert = sqlInsert.Values(key.ID, scope)
+	}
+	sqlStatement, args, err = sqlInsert.ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = trx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat trx exec api key scope (Insert:1)", err)
+		return sql_err.ParseError(err)
+	}
+
+	// ------------------------------------------------------------------------- commit
+	if err := trx.Commit(ctx); err != nil {
+		return rest_err.NewInternalServerError(dao.ErrCommit, err)
+	}
+	return nil
+}
+
+// Revoke menonaktifkan api key, key yang sudah direvoke tidak dapat diaktifkan kembali
+func (a *apiKeyDao) Revoke(ctx context.Context, id string, revokedAt int64) rest_err.APIError {
+	sqlStatement, args, err := a.sb.Update(keyAPIKeyTable).
+		Set(keyRevokedAt, revokedAt).
+		Where(squirrel.Eq{
+			keyID:        id,
+			keyRevokedAt: 0,
+		}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	res, err := a.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec api key (Revoke:0)", err)
+		return sql_err.ParseError(err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return rest_err.NewBadRequestError(fmt.Sprintf("API key %s tidak ditemukan atau sudah direvoke", id))
+	}
+	return nil
+}
+
+// TouchLastUsed memperbarui waktu terakhir api key digunakan paling sering sekali per lastUsedInterval
+func (a *apiKeyDao) TouchLastUsed(ctx context.Context, id string, usedAt int64) rest_err.APIError {
+	sqlStatement, args, err := a.sb.Update(keyAPIKeyTable).
+		Set(keyLastUsedAt, usedAt).
+		Where(squirrel.And{
+			squirrel.Eq{keyID: id},
+			squirrel.Lt{keyLastUsedAt: usedAt - lastUsedInterval},
+		}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = a.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec api key (TouchLastUsed:0)", err)
+		return sql_err.ParseError(err)
+	}
+	return nil
+}
+
+// Get mengembalikan nil tanpa error jika api key tidak ditemukan
+func (a *apiKeyDao) Get(ctx context.Context, id string) (*dto.APIKey, rest_err.APIError) {
+	sqlStatement, args, err := a.sb.Select(keyID, keyUsersID, keyName, keyKeyHash, keyExpiresAt, keyLastUsedAt,
+		keyRevokedAt, keyCreatedBy, keyCreatedAt).
+		From(keyAPIKeyTable).
+		Where(squirrel.Eq{keyID: id}).
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var key dto.APIKey
+	err = a.db.QueryRow(ctx, sqlStatement, args...).Scan(&key.ID, &key.UserID, &key.Name, &key.KeyHash,
+		&key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt, &key.CreatedBy, &key.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		logger.Error("error saat queryrow api key (Get:0)", err)
+		return nil, sql_err.ParseError(err)
+	}
+
+	scopeMap, apiErr := a.findScopes(ctx, []string{key.ID})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	key.Scopes = scopeMap[key.ID]
+	key.Prepare()
+
+	return &key, nil
+}
+
+// FindByUser menampilkan seluruh api key milik user termasuk yang sudah direvoke, terbaru di atas
+func (a *apiKeyDao) FindByUser(ctx context.Context, userID int) ([]dto.APIKey, rest_err.APIError) {
+	sqlStatement, args, err := a.sb.Select(keyID, keyUsersID, keyName, keyExpiresAt, keyLastUsedAt,
+		keyRevokedAt, keyCreatedBy, keyCreatedAt).
+		From(keyAPIKeyTable).
+		Where(squirrel.Eq{keyUsersID: userID}).
+		OrderBy(keyCreatedAt + " DESC").
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	rows, err := a.db.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat query api key (FindByUser:0)", err)
+		return nil, sql_err.ParseError(err)
+	}
+	defer rows.Close()
+
+	keys := make([]dto.APIKey, 0)
+	keyIDs := make([]string, 0)
+	for rows.Next() {
+		var key dto.APIKey
+		err := rows.Scan(&key.ID, &key.UserID, &key.Name, &key.ExpiresAt, &key.LastUsedAt,
+			&key.RevokedAt, &key.CreatedBy, &key.CreatedAt)
+		if err != nil {
+			logger.Error("error saat scan api key (FindByUser:1)", err)
+			return nil, sql_err.ParseError(err)
+		}
+		keys = append(keys, key)
+		keyIDs = append(keyIDs, key.ID)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error("error saat query api key (FindByUser:2)", err)
+		return nil, sql_err.ParseError(err)
+	}
+
+	scopeMap, apiErr := a.findScopes(ctx, keyIDs)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	for i := range keys {
+		keys[i].Scopes = scopeMap[keys[i].ID]
+		keys[i].Prepare()
+	}
+
+	return keys, nil
+}
+
+// findScopes key map adalah id api key dan value adalah daftar role
+func (a *apiKeyDao) findScopes(ctx context.Context, keyIDs []string) (map[string][]string, rest_err.APIError) {
+	scopeMap := make(map[string][]string)
+	if len(keyIDs) == 0 {
+		return scopeMap, nil
+	}
+
+	sqlStatement, args, err := a.sb.Select(keyAPIKeysID, keyRolesName).
+		From(keyScopeTable).
+		Where(squirrel.Eq{keyAPIKeysID: keyIDs}).
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	rows, err := a.db.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat query api key scope (findScopes:0)", err)
+		return nil, sql_err.ParseError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var keyID, roleName string
+		if err := rows.Scan(&keyID, &roleName); err != nil {
+			logger.Error("error saat scan api key scope (findScopes:1)", err)
+			return nil, sql_err.ParseError(err)
+		}
+		scopeMap[keyID] = append(scopeMap[keyID], roleName)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error("error saat query api key scope (findScopes:2)", err)
+		return nil, sql_err.ParseError(err)
+	}
+
+	return scopeMap, nil
+}
@@ -0,0 +1,23 @@
+package apikeydao
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+type APIKeyDaoAssumer interface {
+	APIKeySaver
+	APIKeyReader
+}
+
+type APIKeySaver interface {
+	Insert(ctx context.Context, key dto.APIKey) rest_err.APIError
+	Revoke(ctx context.Context, id string, revokedAt int64) rest_err.APIError
+	TouchLastUsed(ctx context.Context, id string, usedAt int64) rest_err.APIError
+}
+
+type APIKeyReader interface {
+	Get(ctx context.Context, id string) (*dto.APIKey, rest_err.APIError)
+	FindByUser(ctx context.Context, userID int) ([]dto.APIKey, rest_err.APIError)
+}
@@ -0,0 +1,173 @@
+package attemptdao
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/muchlist/berita_acara/dao"
+	"github.com/muchlist/berita_acara/utils/logger"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/muchlist/berita_acara/utils/sql_err"
+)
+
+const (
+	keyAttemptTable = "login_attempts"
+	keySubject      = "subject"
+	keyFailures     = "failures"
+	keyBlockedUntil = "blocked_until"
+	keyLastFailedAt = "last_failed_at"
+	keyClaimedUntil = "claimed_until"
+)
+
+type attemptDao struct {
+	db *pgxpool.Pool
+	sb squirrel.StatementBuilderType
+}
+
+func New(db *pgxpool.Pool) AttemptDaoAssumer {
+	return &attemptDao{
+		db: db,
+		sb: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+// RecordFailure menambah jumlah kegagalan subject secara atomik dan mengembalikan jumlah terbaru.
+// Hitungan dimulai ulang apabila kegagalan terakhir terjadi sebelum resetBefore, subject lain yang
+// sudah tidak diblokir dan kegagalan terakhirnya sebelum resetBefore ikut dibersihkan.
+func (a *attemptDao) RecordFailure(ctx context.Context, subject string, now int64, resetBefore int64) (int, rest_err.APIError) {
+	sqlStatement, args, err := a.sb.Insert(keyAttemptTable).
+		Columns(keySubject, keyFailures, keyBlockedUntil, keyLastFailedAt).
+		Values(subject, 1, 0, now).
+		Suffix(`ON CONFLICT (subject) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failed_at < ? THEN 1 ELSE login_attempts.failures + 1 END,
+			last_failed_at = EXCLUDED.last_failed_at
+			RETURNING failures`, resetBefore).
+		ToSql()
+	if err != nil {
+		return 0, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var failures int
+	err = a.db.QueryRow(ctx, sqlStatement, args...).Scan(&failures)
+	if err != nil {
+		logger.Error("error saat queryrow login attempt (RecordFailure:0)", err)
+		return 0, sql_err.ParseError(err)
+	}
+
+	// ------------------------------------------------------------------------- cleanup
+	sqlStatement, args, err = a.sb.Delete(keyAttemptTable).
+		Where(squirrel.And{
+			squirrel.Lt{keyLastFailedAt: resetBefore},
+			squirrel.Lt{keyBlockedUntil: now},
+			squirrel.Lt{keyClaimedUntil: now},
+		}).
+		ToSql()
+	if err != nil {
+		return 0, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = a.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec login attempt (RecordFailure:1)", err)
+		return 0, sql_err.ParseError(err)
+	}
+	return failures, nil
+}
+
+// Claim mengklaim percobaan login subject sampai until secara atomik, return false apabila subject sedang
+// diblokir atau masih diklaim oleh percobaan lain. Klaim dilepas menggunakan Release.
+func (a *attemptDao) Claim(ctx context.Context, subject string, now int64, until int64) (bool, rest_err.APIError) {
+	sqlStatement, args, err := a.sb.Insert(keyAttemptTable).
+		Columns(keySubject, keyFailures, keyBlockedUntil, keyLastFailedAt, keyClaimedUntil).
+		Values(subject, 0, 0, 0, until).
+		Suffix(`ON CONFLICT (subject) DO UPDATE SET claimed_until = EXCLUDED.claimed_until
+			WHERE login_attempts.blocked_until <= ? AND login_attempts.claimed_until <= ?
+			RETURNING claimed_until`, now, now).
+		ToSql()
+	if err != nil {
+		return false, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var claimedUntil int64
+	err = a.db.QueryRow(ctx, sqlStatement, args...).Scan(&claimedUntil)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		logger.Error("error saat queryrow login attempt (Claim:0)", err)
+		return false, sql_err.ParseError(err)
+	}
+	return true, nil
+}
+
+// Release melepas klaim percobaan login subject
+func (a *attemptDao) Release(ctx context.Context, subject string) rest_err.APIError {
+	sqlStatement, args, err := a.sb.Update(keyAttemptTable).
+		Set(keyClaimedUntil, 0).
+		Where(squirrel.Eq{keySubject: subject}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = a.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec login attempt (Release:0)", err)
+		return sql_err.ParseError(err)
+	}
+	return nil
+}
+
+// Block memblokir subject sampai until, blokir yang lebih lama tidak diperpendek
+func (a *attemptDao) Block(ctx context.Context, subject string, until int64) rest_err.APIError {
+	sqlStatement, args, err := a.sb.Update(keyAttemptTable).
+		Set(keyBlockedUntil, squirrel.Expr("GREATEST(blocked_until, ?)", until)).
+		Where(squirrel.Eq{keySubject: subject}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = a.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec login attempt (Block:0)", err)
+		return sql_err.ParseError(err)
+	}
+	return nil
+}
+
+// Reset menghapus hitungan kegagalan dan blokir subject
+func (a *attemptDao) Reset(ctx context.Context, subject string) rest_err.APIError {
+	sqlStatement, args, err := a.sb.Delete(keyAttemptTable).
+		Where(squirrel.Eq{keySubject: subject}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = a.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec login attempt (Reset:0)", err)
+		return sql_err.ParseError(err)
+	}
+	return nil
+}
+
+// GetBlockedUntil return waktu blokir paling lama dari subjects, 0 jika tidak ada yang diblokir
+func (a *attemptDao) GetBlockedUntil(ctx context.Context, subjects ...string) (int64, rest_err.APIError) {
+	sqlStatement, args, err := a.sb.Select("COALESCE(MAX(" + keyBlockedUntil + "), 0)").
+		From(keyAttemptTable).
+		Where(squirrel.Eq{keySubject: subjects}).
+		ToSql()
+	if err != nil {
+		return 0, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var blockedUntil int64
+	err = a.db.QueryRow(ctx, sqlStatement, args...).Scan(&blockedUntil)
+	if err != nil {
+		return 0, sql_err.ParseError(err)
+	}
+	return blockedUntil, nil
+}
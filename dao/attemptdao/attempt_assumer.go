@@ -0,0 +1,23 @@
+package attemptdao
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+type AttemptDaoAssumer interface {
+	AttemptSaver
+	AttemptReader
+}
+
+type AttemptSaver interface {
+	Claim(ctx context.Context, subject string, now int64, until int64) (bool, rest_err.APIError)
+	Release(ctx context.Context, subject string) rest_err.APIError
+	RecordFailure(ctx context.Context, subject string, now int64, resetBefore int64) (int, rest_err.APIError)
+	Block(ctx context.Context, subject string, until int64) rest_err.APIError
+	Reset(ctx context.Context, subject string) rest_err.APIError
+}
+
+type AttemptReader interface {
+	GetBlockedUntil(ctx context.Context, subjects ...string) (int64, rest_err.APIError)
+}
@@ -0,0 +1,51 @@
+package auditdao
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/muchlist/berita_acara/dao"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/logger"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/muchlist/berita_acara/utils/sql_err"
+)
+
+const (
+	keyAuditTable = "audit_logs"
+	keyAction     = "action"
+	keyUsersID    = "users_id"
+	keyActorID    = "actor_id"
+	keyIP         = "ip"
+	keyDetail     = "detail"
+	keyCreatedAt  = "created_at"
+)
+
+type auditDao struct {
+	db *pgxpool.Pool
+	sb squirrel.StatementBuilderType
+}
+
+func New(db *pgxpool.Pool) AuditDaoAssumer {
+	return &auditDao{
+		db: db,
+		sb: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+func (a *auditDao) Insert(ctx context.Context, log dto.AuditLog) rest_err.APIError {
+	sqlStatement, args, err := a.sb.Insert(keyAuditTable).
+		Columns(keyAction, keyUsersID, keyActorID, keyIP, keyDetail, keyCreatedAt).
+		Values(log.Action, log.UserID, log.ActorID, log.IP, log.Detail, log.CreatedAt).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = a.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec audit log (Insert:0)", err)
+		return sql_err.ParseError(err)
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package auditdao
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+type AuditDaoAssumer interface {
+	AuditSaver
+}
+
+type AuditSaver interface {
+	Insert(ctx context.Context, log dto.AuditLog) rest_err.APIError
+}
@@ -0,0 +1,272 @@
+package beritaacaradao
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/muchlist/berita_acara/configs/docstatus"
+	"github.com/muchlist/berita_acara/dao"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/logger"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/muchlist/berita_acara/utils/sql_err"
+)
+
+const (
+	keyDocumentTable = "documents"
+	keyID            = "id"
+	keyTitle         = "title"
+	keyType          = "doc_type"
+	keyBody          = "body"
+	keyStatus        = "status"
+	keyCreator       = "creator"
+	keyUnit          = "unit"
+	keyNumber        = "doc_number"
+	keyVerifyToken   = "verify_token"
+	keyLetterhead    = "letterhead_title"
+	keyAddress       = "letterhead_address"
+	keyCreatedAt     = "created_at"
+	keyUpdatedAt     = "updated_at"
+
+	keyUserTable = "users"
+	keyName      = "name"
+)
+
+type documentDao struct {
+	db *pgxpool.Pool
+	sb squirrel.StatementBuilderType
+}
+
+func New(db *pgxpool.Pool) DocumentDaoAssumer {
+	return &documentDao{
+		db: db,
+		sb: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+func (d *documentDao) Insert(ctx context.Context, document dto.Document) (int, rest_err.APIError) {
+	sqlStatement, args, err := d.sb.Insert(keyDocumentTable).
+		Columns(keyTitle, keyType, keyUnit, keyBody, keyStatus, keyCreator, keyVerifyToken, keyCreatedAt, keyUpdatedAt).
+		Values(document.Title, document.Type, document.Unit, document.Body, document.Status, document.Creator,
+			document.VerifyToken, document.CreatedAt, document.UpdatedAt).
+		Suffix(dao.Returning(keyID)).
+		ToSql()
+	if err != nil {
+		return 0, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var documentID int
+	err = d.db.QueryRow(ctx, sqlStatement, args...).Scan(&documentID)
+	if err != nil {
+		logger.Error("error saat query document (Insert:0)", err)
+		return 0, sql_err.ParseError(err)
+	}
+
+	return documentID, nil
+}
+
+// Edit hanya dapat dilakukan oleh pembuat document dan selama document masih berstatus draft
+func (d *documentDao) Edit(ctx context.Context, input dto.Document) (*dto.Document, rest_err.APIError) {
+	sqlStatement, args, err := d.sb.Update(keyDocumentTable).
+		SetMap(squirrel.Eq{
+			keyTitle:     input.Title,
+			keyType:      input.Type,
+			keyUnit:      input.Unit,
+			keyBody:      input.Body,
+			keyUpdatedAt: input.UpdatedAt,
+		}).
+		Where(squirrel.Eq{
+			keyID:      input.ID,
+			keyCreator: input.Creator,
+			keyStatus:  docstatus.Draft,
+		}).
+		Suffix(dao.Returning(keyID, keyTitle, keyType, keyUnit, keyBody, keyStatus, keyCreator, keyVerifyToken,
+			keyCreatedAt, keyUpdatedAt)).
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var document dto.Document
+	err = d.db.QueryRow(ctx, sqlStatement, args...).Scan(
+		&document.ID, &document.Title, &document.Type, &document.Unit, &document.Body,
+		&document.Status, &document.Creator, &document.VerifyToken, &document.CreatedAt, &document.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, rest_err.NewBadRequestError(fmt.Sprintf("Document %d tidak ditemukan atau tidak dapat diubah", input.ID))
+		}
+		return nil, sql_err.ParseError(err)
+	}
+
+	return &document, nil
+}
+
+// Delete hanya dapat dilakukan oleh pembuat document dan selama document masih berstatus draft
+func (d *documentDao) Delete(ctx context.Context, id int, creator int) rest_err.APIError {
+	sqlStatement, args, err := d.sb.Delete(keyDocumentTable).
+		Where(squirrel.Eq{
+			keyID:      id,
+			keyCreator: creator,
+			keyStatus:  docstatus.Draft,
+		}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	res, err := d.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		return rest_err.NewInternalServerError("gagal saat penghapusan document", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return rest_err.NewBadRequestError(fmt.Sprintf("Document %d tidak ditemukan atau tidak dapat dihapus", id))
+	}
+
+	return nil
+}
+
+func (d *documentDao) Get(ctx context.Context, id int) (*dto.Document, rest_err.APIError) {
+	return d.getBy(ctx, squirrel.Eq{dao.A(keyID): id}, fmt.Sprintf("Document %d tidak ditemukan", id))
+}
+
+// GetByToken mendapatkan document berdasarkan verify_token yang tercantum pada QR code
+func (d *documentDao) GetByToken(ctx context.Context, token string) (*dto.Document, rest_err.APIError) {
+	return d.getBy(ctx, squirrel.Eq{dao.A(keyVerifyToken): token}, "Document tidak ditemukan")
+}
+
+func (d *documentDao) getBy(ctx context.Context, where squirrel.Eq, notFoundMessage string) (*dto.Document, rest_err.APIError) {
+	sqlStatement, args, err := d.sb.Select(
+		dao.A(keyID),
+		dao.A(keyTitle),
+		dao.A(keyType),
+		dao.A(keyUnit),
+		dao.A(keyNumber),
+		dao.A(keyBody),
+		dao.A(keyStatus),
+		dao.A(keyCreator),
+		dao.B(keyName),
+		dao.A(keyVerifyToken),
+		dao.A(keyLetterhead),
+		dao.A(keyAddress),
+		dao.A(keyCreatedAt),
+		dao.A(keyUpdatedAt),
+	).
+		From(keyDocumentTable + " A").
+		LeftJoin(keyUserTable + " B ON A.creator = B.id").
+		Where(where).
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var document dto.Document
+	var number *string
+	var creatorName *string
+	var letterheadTitle, letterheadAddress *string
+	var apiErr rest_err.APIError
+	err = d.db.QueryRow(ctx, sqlStatement, args...).Scan(
+		&document.ID, &document.Title, &document.Type, &document.Unit, &number, &document.Body, &document.Status,
+		&document.Creator, &creatorName, &document.VerifyToken, &letterheadTitle, &letterheadAddress,
+		&document.CreatedAt, &document.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, rest_err.NewNotFoundError(notFoundMessage)
+		}
+		return nil, sql_err.ParseError(err)
+	}
+	if number != nil {
+		document.Number = *number
+	}
+	if creatorName != nil {
+		document.CreatorName = dto.UppercaseString(*creatorName)
+	}
+	if letterheadTitle != nil && letterheadAddress != nil {
+		document.Letterhead = &dto.Letterhead{Title: *letterheadTitle, Address: *letterheadAddress}
+	}
+
+	document.Signatories, apiErr = d.getSignatories(ctx, d.db, document.ID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	return &document, nil
+}
+
+// FindWithCursor example : ?limit=10&cursor=last_id_from_previous_fetch
+func (d *documentDao) FindWithCursor(ctx context.Context, filter dto.DocumentFilter, limit uint64, cursor int) ([]dto.Document, rest_err.APIError) {
+	sqlfrom := d.sb.Select(
+		dao.A(keyID),
+		dao.A(keyTitle),
+		dao.A(keyType),
+		dao.A(keyUnit),
+		dao.A(keyNumber),
+		dao.A(keyStatus),
+		dao.A(keyCreator),
+		dao.B(keyName),
+		dao.A(keyCreatedAt),
+		dao.A(keyUpdatedAt),
+	).
+		From(keyDocumentTable + " A").
+		LeftJoin(keyUserTable + " B ON A.creator = B.id")
+
+	// where
+	where := squirrel.And{
+		squirrel.Gt{dao.A(keyID): cursor},
+	}
+	if len(filter.Search) > 0 {
+		where = append(where, squirrel.Or{
+			squirrel.ILike{dao.A(keyTitle): fmt.Sprint("%", filter.Search, "%")},
+			squirrel.ILike{dao.A(keyNumber): fmt.Sprint("%", filter.Search, "%")},
+		})
+	}
+	if len(filter.Type) > 0 {
+		where = append(where, squirrel.Eq{dao.A(keyType): filter.Type})
+	}
+	if len(filter.Unit) > 0 {
+		where = append(where, squirrel.Eq{dao.A(keyUnit): filter.Unit})
+	}
+	if len(filter.Status) > 0 {
+		where = append(where, squirrel.Eq{dao.A(keyStatus): filter.Status})
+	}
+	if filter.Creator != 0 {
+		where = append(where, squirrel.Eq{dao.A(keyCreator): filter.Creator})
+	}
+
+	sqlStatement, args, err := sqlfrom.Where(where).
+		OrderBy(dao.A(keyID) + " ASC").
+		Limit(limit).
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	rows, err := d.db.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		return nil, rest_err.NewInternalServerError("gagal mendapatkan daftar document", err)
+	}
+	defer rows.Close()
+
+	documents := make([]dto.Document, 0)
+	for rows.Next() {
+		document := dto.Document{}
+		var number *string
+		var creatorName *string
+		err := rows.Scan(&document.ID, &document.Title, &document.Type, &document.Unit, &number, &document.Status,
+			&document.Creator, &creatorName, &document.CreatedAt, &document.UpdatedAt)
+		if err != nil {
+			return nil, sql_err.ParseError(err)
+		}
+		if number != nil {
+			document.Number = *number
+		}
+		if creatorName != nil {
+			document.CreatorName = dto.UppercaseString(*creatorName)
+		}
+		documents = append(documents, document)
+	}
+
+	return documents, nil
+}
@@ -0,0 +1,32 @@
+package beritaacaradao
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+type DocumentDaoAssumer interface {
+	DocumentSaver
+	DocumentSigner
+	DocumentReader
+}
+
+type DocumentSaver interface {
+	Insert(ctx context.Context, document dto.Document) (int, rest_err.APIError)
+	Edit(ctx context.Context, input dto.Document) (*dto.Document, rest_err.APIError)
+	Delete(ctx context.Context, id int, creator int) rest_err.APIError
+}
+
+type DocumentSigner interface {
+	RequestSignature(ctx context.Context, documentID int, creator int, signatories []dto.Signatory, letterhead dto.Letterhead, updatedAt int64) rest_err.APIError
+	Sign(ctx context.Context, input dto.Signatory) (string, rest_err.APIError)
+	Reject(ctx context.Context, documentID int, userID int, note string, rejectedAt int64) rest_err.APIError
+}
+
+type DocumentReader interface {
+	Get(ctx context.Context, id int) (*dto.Document, rest_err.APIError)
+	GetByToken(ctx context.Context, token string) (*dto.Document, rest_err.APIError)
+	GetSignatories(ctx context.Context, documentID int) ([]dto.Signatory, rest_err.APIError)
+	FindWithCursor(ctx context.Context, filter dto.DocumentFilter, limit uint64, cursor int) ([]dto.Document, rest_err.APIError)
+}
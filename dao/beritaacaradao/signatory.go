@@ -0,0 +1,375 @@
+package beritaacaradao
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/muchlist/berita_acara/configs/docstatus"
+	"github.com/muchlist/berita_acara/dao"
+	"github.com/muchlist/berita_acara/dao/numberingdao"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/logger"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/muchlist/berita_acara/utils/sql_err"
+	"time"
+)
+
+const (
+	keySignatoryTable = "signatories"
+	keyDocumentsID    = "documents_id"
+	keyUsersID        = "users_id"
+	keyPosition       = "position"
+	keySignOrder      = "sign_order"
+	keyNote           = "note"
+	keySignedAt       = "signed_at"
+	keySignature      = "signature"
+	keyDocHash        = "doc_hash"
+)
+
+// RequestSignature merubah status document dari draft menjadi waiting_signature, menerbitkan nomor surat,
+// menyalin kop surat sekaligus menyimpan daftar penandatangan dalam satu transaksi
+func (d *documentDao) RequestSignature(ctx context.Context, documentID int, creator int, signatories []dto.Signatory, letterhead dto.Letterhead, updatedAt int64) rest_err.APIError {
+	if len(signatories) == 0 {
+		return rest_err.NewBadRequestError("penandatangan tidak boleh kosong")
+	}
+
+	// ------------------------------------------------------------------------- begin
+	trx, err := d.db.Begin(ctx)
+	if err != nil {
+		return rest_err.NewInternalServerError("gagal memulai transaksi", err)
+	}
+	defer func(trx pgx.Tx) {
+		_ = trx.Rollback(context.Background())
+	}(trx)
+
+	// ------------------------------------------------------------------------- document status
+	document, apiErr := d.lockDocument(ctx, trx, documentID)
+	if apiErr != nil {
+		return apiErr
+	}
+	if document.Creator != creator {
+		return rest_err.NewUnauthorizedError("Hanya pembuat document yang dapat meminta tanda tangan")
+	}
+	if apiErr := d.changeStatus(ctx, trx, *document, docstatus.WaitingSignature, updatedAt); apiErr != nil {
+		return apiErr
+	}
+
+	// ------------------------------------------------------------------------- document number
+	if document.Number == "" {
+		number, apiErr := numberingdao.Next(ctx, trx, document.Unit, document.Type, time.Unix(updatedAt, 0))
+		if apiErr != nil {
+			return apiErr
+		}
+		sqlStatement, args, err := d.sb.Update(keyDocumentTable).
+			Set(keyNumber, number).
+			Where(squirrel.Eq{keyID: documentID}).
+			ToSql()
+		if err != nil {
+			return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+		}
+
+		_, err = trx.Exec(ctx, sqlStatement, args...)
+		if err != nil {
+			logger.Error("error saat trx exec documents(RequestSignature:0)", err)
+			return sql_err.ParseError(err)
+		}
+	}
+
+	// ------------------------------------------------------------------------- letterhead
+	sqlStatement, args, err := d.sb.Update(keyDocumentTable).
+		Set(keyLetterhead, letterhead.Title).
+		Set(keyAddress, letterhead.Address).
+		Where(squirrel.Eq{keyID: documentID}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = trx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat trx exec documents(RequestSignature:1)", err)
+		return sql_err.ParseError(err)
+	}
+
+	// ------------------------------------------------------------------------- insert signatories
+	sqlInsert := d.sb.Insert(keySignatoryTable).
+		Columns(keyDocumentsID, keyUsersID, keyName, keyPosition, keySignOrder, keyStatus, keyCreatedAt, keyUpdatedAt)
+	for _, signatory := range signatories {
+		sqlInsert = sqlInsert.Values(documentID, signatory.UserID, signatory.Name, signatory.Position,
+			signatory.Order, docstatus.SignerPending, updatedAt, updatedAt)
+	}
+	sqlStatement, args, err = sqlInsert.ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = trx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat trx exec signatories(RequestSignature:0)", err)
+		return sql_err.ParseError(err)
+	}
+
+	// ------------------------------------------------------------------------- commit
+	if err := trx.Commit(ctx); err != nil {
+		return rest_err.NewInternalServerError(dao.ErrCommit, err)
+	}
+
+	return nil
+}
+
+// Sign menyimpan tanda tangan input.UserID pada document input.DocumentID beserta CMS signature dan hash pdf,
+// penandatangan dengan urutan lebih kecil harus sudah tanda tangan terlebih dahulu. Jika seluruh penandatangan
+// sudah tanda tangan maka status document menjadi signed. Mengembalikan status document terbaru.
+func (d *documentDao) Sign(ctx context.Context, input dto.Signatory) (string, rest_err.APIError) {
+	documentID := input.DocumentID
+	userID := input.UserID
+
+	// ------------------------------------------------------------------------- begin
+	trx, err := d.db.Begin(ctx)
+	if err != nil {
+		return "", rest_err.NewInternalServerError("gagal memulai transaksi", err)
+	}
+	defer func(trx pgx.Tx) {
+		_ = trx.Rollback(context.Background())
+	}(trx)
+
+	// ------------------------------------------------------------------------- check
+	// baris document dikunci sehingga penandatanganan bersamaan pada document yang sama diproses bergantian
+	document, apiErr := d.lockDocument(ctx, trx, documentID)
+	if apiErr != nil {
+		return "", apiErr
+	}
+	if document.Status != docstatus.WaitingSignature {
+		return "", rest_err.NewBadRequestError(fmt.Sprintf("Document berstatus %s tidak dapat ditandatangani", document.Status))
+	}
+
+	signatories, apiErr := d.getSignatories(ctx, trx, documentID)
+	if apiErr != nil {
+		return "", apiErr
+	}
+
+	var signer *dto.Signatory
+	for i := range signatories {
+		if signatories[i].UserID == userID {
+			signer = &signatories[i]
+			break
+		}
+	}
+	if signer == nil {
+		return "", rest_err.NewUnauthorizedError("Anda bukan penandatangan document ini")
+	}
+	if signer.Status != docstatus.SignerPending {
+		return "", rest_err.NewBadRequestError(fmt.Sprintf("Tanda tangan anda sudah berstatus %s", signer.Status))
+	}
+
+	remaining := 0
+	for _, signatory := range signatories {
+		if signatory.Status != docstatus.SignerPending || signatory.UserID == userID {
+			continue
+		}
+		if signatory.Order < signer.Order {
+			return "", rest_err.NewBadRequestError(fmt.Sprintf("Menunggu tanda tangan %s terlebih dahulu", signatory.Name))
+		}
+		remaining++
+	}
+
+	// ------------------------------------------------------------------------- update signatory
+	sqlStatement, args, err := d.sb.Update(keySignatoryTable).
+		SetMap(squirrel.Eq{
+			keyStatus:    docstatus.SignerSigned,
+			keySignedAt:  input.SignedAt,
+			keySignature: input.Signature,
+			keyDocHash:   input.DocHash,
+			keyUpdatedAt: input.SignedAt,
+		}).
+		Where(squirrel.Eq{keyID: signer.ID}).
+		ToSql()
+	if err != nil {
+		return "", rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = trx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat trx exec signatories(Sign:0)", err)
+		return "", sql_err.ParseError(err)
+	}
+
+	// ------------------------------------------------------------------------- update document
+	status := document.Status
+	if remaining == 0 {
+		if apiErr := d.changeStatus(ctx, trx, *document, docstatus.Signed, input.SignedAt); apiErr != nil {
+			return "", apiErr
+		}
+		status = docstatus.Signed
+	}
+
+	// ------------------------------------------------------------------------- commit
+	if err := trx.Commit(ctx); err != nil {
+		return "", rest_err.NewInternalServerError(dao.ErrCommit, err)
+	}
+
+	return status, nil
+}
+
+// Reject menolak document, penolakan oleh salah satu penandatangan membuat status document menjadi rejected
+func (d *documentDao) Reject(ctx context.Context, documentID int, userID int, note string, rejectedAt int64) rest_err.APIError {
+	// ------------------------------------------------------------------------- begin
+	trx, err := d.db.Begin(ctx)
+	if err != nil {
+		return rest_err.NewInternalServerError("gagal memulai transaksi", err)
+	}
+	defer func(trx pgx.Tx) {
+		_ = trx.Rollback(context.Background())
+	}(trx)
+
+	// ------------------------------------------------------------------------- check
+	document, apiErr := d.lockDocument(ctx, trx, documentID)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	signatories, apiErr := d.getSignatories(ctx, trx, documentID)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	var signer *dto.Signatory
+	for i := range signatories {
+		if signatories[i].UserID == userID {
+			signer = &signatories[i]
+			break
+		}
+	}
+	if signer == nil {
+		return rest_err.NewUnauthorizedError("Anda bukan penandatangan document ini")
+	}
+	if signer.Status != docstatus.SignerPending {
+		return rest_err.NewBadRequestError(fmt.Sprintf("Tanda tangan anda sudah berstatus %s", signer.Status))
+	}
+
+	// ------------------------------------------------------------------------- update
+	if apiErr := d.changeStatus(ctx, trx, *document, docstatus.Rejected, rejectedAt); apiErr != nil {
+		return apiErr
+	}
+	sqlStatement, args, err := d.sb.Update(keySignatoryTable).
+		SetMap(squirrel.Eq{
+			keyStatus:    docstatus.SignerRejected,
+			keyNote:      note,
+			keySignedAt:  rejectedAt,
+			keyUpdatedAt: rejectedAt,
+		}).
+		Where(squirrel.Eq{keyID: signer.ID}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = trx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat trx exec signatories(Reject:0)", err)
+		return sql_err.ParseError(err)
+	}
+
+	// ------------------------------------------------------------------------- commit
+	if err := trx.Commit(ctx); err != nil {
+		return rest_err.NewInternalServerError(dao.ErrCommit, err)
+	}
+
+	return nil
+}
+
+// GetSignatories mendapatkan daftar penandatangan document sesuai urutan tanda tangan
+func (d *documentDao) GetSignatories(ctx context.Context, documentID int) ([]dto.Signatory, rest_err.APIError) {
+	return d.getSignatories(ctx, d.db, documentID)
+}
+
+// querier dipenuhi oleh *pgxpool.Pool maupun pgx.Tx
+type querier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+func (d *documentDao) getSignatories(ctx context.Context, q querier, documentID int) ([]dto.Signatory, rest_err.APIError) {
+	sqlStatement, args, err := d.sb.Select(keyID, keyDocumentsID, keyUsersID, keyName, keyPosition, keySignOrder,
+		keyStatus, keyNote, keySignedAt, keySignature, keyDocHash, keyCreatedAt, keyUpdatedAt).
+		From(keySignatoryTable).
+		Where(squirrel.Eq{keyDocumentsID: documentID}).
+		OrderBy(keySignOrder+" ASC", keyID+" ASC").
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	rows, err := q.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		return nil, rest_err.NewInternalServerError("gagal mendapatkan daftar penandatangan", err)
+	}
+	defer rows.Close()
+
+	signatories := make([]dto.Signatory, 0)
+	for rows.Next() {
+		s := dto.Signatory{}
+		err := rows.Scan(&s.ID, &s.DocumentID, &s.UserID, &s.Name, &s.Position, &s.Order,
+			&s.Status, &s.Note, &s.SignedAt, &s.Signature, &s.DocHash, &s.CreatedAt, &s.UpdatedAt)
+		if err != nil {
+			return nil, sql_err.ParseError(err)
+		}
+		signatories = append(signatories, s)
+	}
+
+	return signatories, nil
+}
+
+// lockDocument membaca document dengan SELECT FOR UPDATE di dalam transaksi
+func (d *documentDao) lockDocument(ctx context.Context, trx pgx.Tx, documentID int) (*dto.Document, rest_err.APIError) {
+	sqlStatement, args, err := d.sb.Select(keyID, keyType, keyUnit, keyNumber, keyStatus, keyCreator).
+		From(keyDocumentTable).
+		Where(squirrel.Eq{keyID: documentID}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var document dto.Document
+	var number *string
+	err = trx.QueryRow(ctx, sqlStatement, args...).Scan(&document.ID, &document.Type, &document.Unit, &number,
+		&document.Status, &document.Creator)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, rest_err.NewNotFoundError(fmt.Sprintf("Document %d tidak ditemukan", documentID))
+		}
+		return nil, sql_err.ParseError(err)
+	}
+	if number != nil {
+		document.Number = *number
+	}
+
+	return &document, nil
+}
+
+// changeStatus merubah status document sesuai state machine docstatus
+func (d *documentDao) changeStatus(ctx context.Context, trx pgx.Tx, document dto.Document, status string, updatedAt int64) rest_err.APIError {
+	if !docstatus.CanTransition(document.Status, status) {
+		return rest_err.NewBadRequestError(fmt.Sprintf("Status document tidak dapat berubah dari %s menjadi %s", document.Status, status))
+	}
+
+	sqlStatement, args, err := d.sb.Update(keyDocumentTable).
+		SetMap(squirrel.Eq{
+			keyStatus:    status,
+			keyUpdatedAt: updatedAt,
+		}).
+		Where(squirrel.Eq{keyID: document.ID}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = trx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat trx exec documents(changeStatus:0)", err)
+		return sql_err.ParseError(err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package denylistdao
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/muchlist/berita_acara/dao"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/logger"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/muchlist/berita_acara/utils/sql_err"
+)
+
+const (
+	keyDeniedTokenTable = "denied_tokens"
+	keyTokenID          = "token_id"
+	keyUsersID          = "users_id"
+	keyExpiresAt        = "expires_at"
+	keyCreatedAt        = "created_at"
+
+	keyCutoffTable  = "token_cutoffs"
+	keyIssuedBefore = "issued_before"
+)
+
+type denylistDao struct {
+	db *pgxpool.Pool
+	sb squirrel.StatementBuilderType
+}
+
+func New(db *pgxpool.Pool) DenylistDaoAssumer {
+	return &denylistDao{
+		db: db,
+		sb: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+// DenyToken memasukkan access token (jti) ke denylist sampai token tersebut kadaluarsa.
+// Token pada denylist yang sudah kadaluarsa ikut dibersihkan karena sudah ditolak oleh validasi exp.
+func (d *denylistDao) DenyToken(ctx context.Context, token dto.DeniedToken) rest_err.APIError {
+	sqlStatement, args, err := d.sb.Insert(keyDeniedTokenTable).
+		Columns(keyTokenID, keyUsersID, keyExpiresAt, keyCreatedAt).
+		Values(token.TokenID, token.UserID, token.ExpiresAt, token.CreatedAt).
+		Suffix("ON CONFLICT (token_id) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = d.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec denied token (DenyToken:0)", err)
+		return sql_err.ParseError(err)
+	}
+
+	// ------------------------------------------------------------------------- cleanup
+	sqlStatement, args, err = d.sb.Delete(keyDeniedTokenTable).
+		Where(squirrel.Lt{keyExpiresAt: token.CreatedAt}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = d.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec denied token (DenyToken:1)", err)
+		return sql_err.ParseError(err)
+	}
+	return nil
+}
+
+// DenyUser menolak seluruh access token user yang diterbitkan pada atau sebelum issuedBefore.
+// Nilai yang tersimpan tidak pernah mundur.
+func (d *denylistDao) DenyUser(ctx context.Context, userID int, issuedBefore int64) rest_err.APIError {
+	sqlStatement, args, err := d.sb.Insert(keyCutoffTable).
+		Columns(keyUsersID, keyIssuedBefore).
+		Values(userID, issuedBefore).
+		Suffix("ON CONFLICT (users_id) DO UPDATE SET issued_before = GREATEST(token_cutoffs.issued_before, EXCLUDED.issued_before)").
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = d.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec token cutoff (DenyUser:0)", err)
+		return sql_err.ParseError(err)
+	}
+	return nil
+}
+
+func (d *denylistDao) IsTokenDenied(ctx context.Context, tokenID string) (bool, rest_err.APIError) {
+	sqlStatement, args, err := d.sb.Select(keyTokenID).
+		From(keyDeniedTokenTable).
+		Where(squirrel.Eq{keyTokenID: tokenID}).
+		ToSql()
+	if err != nil {
+		return false, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var deniedID string
+	err = d.db.QueryRow(ctx, sqlStatement, args...).Scan(&deniedID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, sql_err.ParseError(err)
+	}
+	return true, nil
+}
+
+// GetUserCutoff return 0 jika user tidak memiliki batas waktu penerbitan token
+func (d *denylistDao) GetUserCutoff(ctx context.Context, userID int) (int64, rest_err.APIError) {
+	sqlStatement, args, err := d.sb.Select(keyIssuedBefore).
+		From(keyCutoffTable).
+		Where(squirrel.Eq{keyUsersID: userID}).
+		ToSql()
+	if err != nil {
+		return 0, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var issuedBefore int64
+	err = d.db.QueryRow(ctx, sqlStatement, args...).Scan(&issuedBefore)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, nil
+		}
+		return 0, sql_err.ParseError(err)
+	}
+	return issuedBefore, nil
+}
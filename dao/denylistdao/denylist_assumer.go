@@ -0,0 +1,24 @@
+package denylistdao
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+// DenylistDaoAssumer penyimpanan denylist access token, implementasi lain (misalnya redis)
+// cukup memenuhi interface ini untuk menggantikan penyimpanan postgres
+type DenylistDaoAssumer interface {
+	DenylistSaver
+	DenylistReader
+}
+
+type DenylistSaver interface {
+	DenyToken(ctx context.Context, token dto.DeniedToken) rest_err.APIError
+	DenyUser(ctx context.Context, userID int, issuedBefore int64) rest_err.APIError
+}
+
+type DenylistReader interface {
+	IsTokenDenied(ctx context.Context, tokenID string) (bool, rest_err.APIError)
+	GetUserCutoff(ctx context.Context, userID int) (int64, rest_err.APIError)
+}
@@ -0,0 +1,126 @@
+package denylistdao
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL lama hasil pembacaan denylist disimpan di memory. Penolakan yang dibuat oleh
+// instance lain baru terlihat setelah cache kadaluarsa, penolakan pada instance sendiri langsung berlaku.
+const DefaultCacheTTL = 30 * time.Second
+
+type tokenEntry struct {
+	denied    bool
+	checkedAt time.Time
+}
+
+type cutoffEntry struct {
+	issuedBefore int64
+	checkedAt    time.Time
+}
+
+// NewCached membungkus store dengan cache in-memory agar middleware tidak selalu membaca store
+func NewCached(store DenylistDaoAssumer, ttl time.Duration) DenylistDaoAssumer {
+	return &cachedDenylist{
+		store:   store,
+		ttl:     ttl,
+		tokens:  make(map[string]tokenEntry),
+		cutoffs: make(map[int]cutoffEntry),
+	}
+}
+
+type cachedDenylist struct {
+	store DenylistDaoAssumer
+	ttl   time.Duration
+
+	mu        sync.Mutex
+	tokens    map[string]tokenEntry
+	cutoffs   map[int]cutoffEntry
+	lastSweep time.Time
+}
+
+func (c *cachedDenylist) DenyToken(ctx context.Context, token dto.DeniedToken) rest_err.APIError {
+	if err := c.store.DenyToken(ctx, token); err != nil {
+		return err
+	}
+	c.setToken(token.TokenID, true)
+	return nil
+}
+
+func (c *cachedDenylist) DenyUser(ctx context.Context, userID int, issuedBefore int64) rest_err.APIError {
+	if err := c.store.DenyUser(ctx, userID, issuedBefore); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	if entry := c.cutoffs[userID]; entry.issuedBefore > issuedBefore {
+		issuedBefore = entry.issuedBefore
+	}
+	c.cutoffs[userID] = cutoffEntry{issuedBefore: issuedBefore, checkedAt: time.Now()}
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *cachedDenylist) IsTokenDenied(ctx context.Context, tokenID string) (bool, rest_err.APIError) {
+	c.mu.Lock()
+	entry, ok := c.tokens[tokenID]
+	c.mu.Unlock()
+	if ok && time.Since(entry.checkedAt) < c.ttl {
+		return entry.denied, nil
+	}
+
+	denied, err := c.store.IsTokenDenied(ctx, tokenID)
+	if err != nil {
+		return false, err
+	}
+	c.setToken(tokenID, denied)
+	return denied, nil
+}
+
+func (c *cachedDenylist) GetUserCutoff(ctx context.Context, userID int) (int64, rest_err.APIError) {
+	c.mu.Lock()
+	entry, ok := c.cutoffs[userID]
+	c.mu.Unlock()
+	if ok && time.Since(entry.checkedAt) < c.ttl {
+		return entry.issuedBefore, nil
+	}
+
+	issuedBefore, err := c.store.GetUserCutoff(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	c.cutoffs[userID] = cutoffEntry{issuedBefore: issuedBefore, checkedAt: time.Now()}
+	c.sweep()
+	c.mu.Unlock()
+	return issuedBefore, nil
+}
+
+func (c *cachedDenylist) setToken(tokenID string, denied bool) {
+	c.mu.Lock()
+	c.tokens[tokenID] = tokenEntry{denied: denied, checkedAt: time.Now()}
+	c.sweep()
+	c.mu.Unlock()
+}
+
+// sweep membuang entry yang sudah kadaluarsa agar ukuran cache tidak terus bertambah,
+// dijalankan paling sering sekali per ttl. Harus dipanggil saat mu terkunci.
+func (c *cachedDenylist) sweep() {
+	now := time.Now()
+	if now.Sub(c.lastSweep) < c.ttl {
+		return
+	}
+	c.lastSweep = now
+	for tokenID, entry := range c.tokens {
+		if now.Sub(entry.checkedAt) >= c.ttl {
+			delete(c.tokens, tokenID)
+		}
+	}
+	for userID, entry := range c.cutoffs {
+		if now.Sub(entry.checkedAt) >= c.ttl {
+			delete(c.cutoffs, userID)
+		}
+	}
+}
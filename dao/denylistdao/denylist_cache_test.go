@@ -0,0 +1,95 @@
+package denylistdao
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"testing"
+	"time"
+)
+
+type fakeStore struct {
+	tokens  map[string]bool
+	cutoffs map[int]int64
+	reads   int
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{tokens: map[string]bool{}, cutoffs: map[int]int64{}}
+}
+
+func (f *fakeStore) DenyToken(_ context.Context, token dto.DeniedToken) rest_err.APIError {
+	f.tokens[token.TokenID] = true
+	return nil
+}
+
+func (f *fakeStore) DenyUser(_ context.Context, userID int, issuedBefore int64) rest_err.APIError {
+	if issuedBefore > f.cutoffs[userID] {
+		f.cutoffs[userID] = issuedBefore
+	}
+	return nil
+}
+
+func (f *fakeStore) IsTokenDenied(_ context.Context, tokenID string) (bool, rest_err.APIError) {
+	f.reads++
+	return f.tokens[tokenID], nil
+}
+
+func (f *fakeStore) GetUserCutoff(_ context.Context, userID int) (int64, rest_err.APIError) {
+	f.reads++
+	return f.cutoffs[userID], nil
+}
+
+func TestCachedDenylist_LocalDenyVisibleImmediately(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	cache := NewCached(store, time.Hour)
+
+	// hasil negatif ikut disimpan di cache
+	if denied, _ := cache.IsTokenDenied(ctx, "abc"); denied {
+		t.Fatal("token belum seharusnya ditolak")
+	}
+	if err := cache.DenyToken(ctx, dto.DeniedToken{TokenID: "abc", UserID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if denied, _ := cache.IsTokenDenied(ctx, "abc"); !denied {
+		t.Error("penolakan pada instance sendiri harus langsung berlaku tanpa menunggu ttl")
+	}
+
+	if cutoff, _ := cache.GetUserCutoff(ctx, 1); cutoff != 0 {
+		t.Errorf("cutoff = %d, want 0", cutoff)
+	}
+	_ = cache.DenyUser(ctx, 1, 200)
+	_ = cache.DenyUser(ctx, 1, 100)
+	if cutoff, _ := cache.GetUserCutoff(ctx, 1); cutoff != 200 {
+		t.Errorf("cutoff = %d, want 200 (cutoff tidak boleh mundur)", cutoff)
+	}
+	if store.reads != 2 {
+		t.Errorf("store reads = %d, want 2", store.reads)
+	}
+}
+
+func TestCachedDenylist_RemoteDenyVisibleAfterTTL(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	cache := NewCached(store, 10*time.Millisecond)
+
+	_, _ = cache.IsTokenDenied(ctx, "abc")
+	_, _ = cache.GetUserCutoff(ctx, 1)
+
+	// ditolak oleh instance lain langsung ke store
+	store.tokens["abc"] = true
+	store.cutoffs[1] = 300
+
+	if denied, _ := cache.IsTokenDenied(ctx, "abc"); denied {
+		t.Error("hasil cache harusnya digunakan sebelum ttl")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if denied, _ := cache.IsTokenDenied(ctx, "abc"); !denied {
+		t.Error("store harusnya dibaca ulang setelah ttl")
+	}
+	if cutoff, _ := cache.GetUserCutoff(ctx, 1); cutoff != 300 {
+		t.Errorf("cutoff = %d, want 300", cutoff)
+	}
+}
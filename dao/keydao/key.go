@@ -0,0 +1,73 @@
+package keydao
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/muchlist/berita_acara/dao"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/muchlist/berita_acara/utils/sql_err"
+)
+
+const (
+	keyUserKeyTable = "user_keys"
+	keyUsersID      = "users_id"
+	keyCertificate  = "certificate"
+	keyPrivateKey   = "private_key"
+	keyNotAfter     = "not_after"
+	keyCreatedAt    = "created_at"
+)
+
+type keyDao struct {
+	db *pgxpool.Pool
+	sb squirrel.StatementBuilderType
+}
+
+func New(db *pgxpool.Pool) KeyDaoAssumer {
+	return &keyDao{
+		db: db,
+		sb: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+// Upsert menyimpan key user, key lama (misalnya kadaluarsa) akan diganti
+func (k *keyDao) Upsert(ctx context.Context, key dto.UserKey) rest_err.APIError {
+	sqlStatement, args, err := k.sb.Insert(keyUserKeyTable).
+		Columns(keyUsersID, keyCertificate, keyPrivateKey, keyNotAfter, keyCreatedAt).
+		Values(key.UserID, key.Certificate, key.PrivateKey, key.NotAfter, key.CreatedAt).
+		Suffix("ON CONFLICT (users_id) DO UPDATE SET certificate = EXCLUDED.certificate, " +
+			"private_key = EXCLUDED.private_key, not_after = EXCLUDED.not_after, created_at = EXCLUDED.created_at").
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = k.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		return sql_err.ParseError(err)
+	}
+	return nil
+}
+
+// Get mengembalikan nil tanpa error jika user belum memiliki key
+func (k *keyDao) Get(ctx context.Context, userID int) (*dto.UserKey, rest_err.APIError) {
+	sqlStatement, args, err := k.sb.Select(keyUsersID, keyCertificate, keyPrivateKey, keyNotAfter, keyCreatedAt).
+		From(keyUserKeyTable).
+		Where(squirrel.Eq{keyUsersID: userID}).
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var key dto.UserKey
+	err = k.db.QueryRow(ctx, sqlStatement, args...).Scan(&key.UserID, &key.Certificate, &key.PrivateKey, &key.NotAfter, &key.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, sql_err.ParseError(err)
+	}
+	return &key, nil
+}
@@ -0,0 +1,20 @@
+package keydao
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+type KeyDaoAssumer interface {
+	KeySaver
+	KeyReader
+}
+
+type KeySaver interface {
+	Upsert(ctx context.Context, key dto.UserKey) rest_err.APIError
+}
+
+type KeyReader interface {
+	Get(ctx context.Context, userID int) (*dto.UserKey, rest_err.APIError)
+}
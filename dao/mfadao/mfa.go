@@ -0,0 +1,226 @@
+package mfadao
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/muchlist/berita_acara/dao"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/logger"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/muchlist/berita_acara/utils/sql_err"
+)
+
+const (
+	keyMFATable     = "user_mfa"
+	keyUsersID      = "users_id"
+	keySecret       = "secret"
+	keyEnabled      = "enabled"
+	keyLastUsedStep = "last_used_step"
+	keyCreatedAt    = "created_at"
+	keyUpdatedAt    = "updated_at"
+
+	keyRecoveryCodeTable = "user_recovery_codes"
+	keyCodeHash          = "code_hash"
+	keyUsedAt            = "used_at"
+)
+
+type mfaDao struct {
+	db *pgxpool.Pool
+	sb squirrel.StatementBuilderType
+}
+
+func New(db *pgxpool.Pool) MFADaoAssumer {
+	return &mfaDao{
+		db: db,
+		sb: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+// Enroll menyimpan secret baru yang belum aktif, secret yang belum dikonfirmasi akan diganti.
+// Ditolak apabila MFA user sudah aktif.
+func (m *mfaDao) Enroll(ctx context.Context, mfa dto.UserMFA) rest_err.APIError {
+	sqlStatement, args, err := m.sb.Insert(keyMFATable).
+		Columns(keyUsersID, keySecret, keyEnabled, keyCreatedAt, keyUpdatedAt).
+		Values(mfa.UserID, mfa.Secret, false, mfa.CreatedAt, mfa.UpdatedAt).
+		Suffix("ON CONFLICT (users_id) DO UPDATE SET secret = EXCLUDED.secret, updated_at = EXCLUDED.updated_at " +
+			"WHERE user_mfa.enabled = FALSE").
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	res, err := m.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec mfa (Enroll:0)", err)
+		return sql_err.ParseError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return rest_err.NewBadRequestError("MFA sudah aktif, nonaktifkan terlebih dahulu untuk mendaftar ulang")
+	}
+	return nil
+}
+
+// Enable mengaktifkan MFA yang sudah dienroll dan mengganti seluruh recovery code
+func (m *mfaDao) Enable(ctx context.Context, userID int, step int64, recoveryCodeHashes []string, updatedAt int64) rest_err.APIError {
+	// ------------------------------------------------------------------------- begin
+	trx, err := m.db.Begin(ctx)
+	if err != nil {
+		return rest_err.NewInternalServerError("gagal memulai transaksi", err)
+	}
+	defer func(trx pgx.Tx) {
+		_ = trx.Rollback(context.Background())
+	}(trx)
+
+	// ------------------------------------------------------------------------- enable
+	sqlStatement, args, err := m.sb.Update(keyMFATable).
+		SetMap(squirrel.Eq{
+			keyEnabled:      true,
+			keyLastUsedStep: step,
+			keyUpdatedAt:    updatedAt,
+		}).
+		Where(squirrel.Eq{
+			keyUsersID: userID,
+			keyEnabled: false,
+		}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	res, err := trx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat trx exec mfa (Enable:0)", err)
+		return sql_err.ParseError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return rest_err.NewBadRequestError("MFA belum dienroll atau sudah aktif")
+	}
+
+	// ------------------------------------------------------------------------- recovery codes
+	sqlStatement, args, err = m.sb.Delete(keyRecoveryCodeTable).
+		Where(squirrel.Eq{keyUsersID: userID}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = trx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat trx exec recovery code (Enable:1)", err)
+		return sql_err.ParseError(err)
+	}
+
+	if len(recoveryCodeHashes) != 0 {
+		sqlInsert := m.sb.Insert(keyRecoveryCodeTable).Columns(keyUsersID, keyCodeHash)
+		for _, codeHash := range recoveryCodeHashes {
+			sqlInsert = sqlInsert.Values(userID, codeHash)
+		}
+		sqlStatement, args, err = sqlInsert.ToSql()
+		if err != nil {
+			return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+		}
+
+		_, err = trx.Exec(ctx, sqlStatement, args...)
+		if err != nil {
+			logger.Error("error saat trx exec recovery code (Enable:2)", err)
+			return sql_err.ParseError(err)
+		}
+	}
+
+	// ------------------------------------------------------------------------- commit
+	if err := trx.Commit(ctx); err != nil {
+		return rest_err.NewInternalServerError(dao.ErrCommit, err)
+	}
+	return nil
+}
+
+// UseStep mencatat step TOTP yang dipakai, code dengan step yang sama atau lebih lama
+// dari step terakhir ditolak sehingga satu code tidak dapat dipakai dua kali
+func (m *mfaDao) UseStep(ctx context.Context, userID int, step int64) rest_err.APIError {
+	sqlStatement, args, err := m.sb.Update(keyMFATable).
+		Set(keyLastUsedStep, step).
+		Where(squirrel.And{
+			squirrel.Eq{keyUsersID: userID, keyEnabled: true},
+			squirrel.Lt{keyLastUsedStep: step},
+		}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	res, err := m.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec mfa (UseStep:0)", err)
+		return sql_err.ParseError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return rest_err.NewUnauthorizedError("Code MFA sudah pernah digunakan")
+	}
+	return nil
+}
+
+// UseRecoveryCode menandai recovery code terpakai, setiap code hanya dapat digunakan sekali
+func (m *mfaDao) UseRecoveryCode(ctx context.Context, userID int, codeHash string, usedAt int64) rest_err.APIError {
+	sqlStatement, args, err := m.sb.Update(keyRecoveryCodeTable).
+		Set(keyUsedAt, usedAt).
+		Where(squirrel.Eq{
+			keyUsersID:  userID,
+			keyCodeHash: codeHash,
+			keyUsedAt:   0,
+		}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	res, err := m.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec recovery code (UseRecoveryCode:0)", err)
+		return sql_err.ParseError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return rest_err.NewUnauthorizedError("Code MFA tidak valid")
+	}
+	return nil
+}
+
+// Delete menonaktifkan MFA, recovery code ikut terhapus melalui ON DELETE CASCADE
+func (m *mfaDao) Delete(ctx context.Context, userID int) rest_err.APIError {
+	sqlStatement, args, err := m.sb.Delete(keyMFATable).
+		Where(squirrel.Eq{keyUsersID: userID}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = m.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec mfa (Delete:0)", err)
+		return sql_err.ParseError(err)
+	}
+	return nil
+}
+
+// Get mengembalikan nil tanpa error jika user belum pernah enroll
+func (m *mfaDao) Get(ctx context.Context, userID int) (*dto.UserMFA, rest_err.APIError) {
+	sqlStatement, args, err := m.sb.Select(keyUsersID, keySecret, keyEnabled, keyLastUsedStep, keyCreatedAt, keyUpdatedAt).
+		From(keyMFATable).
+		Where(squirrel.Eq{keyUsersID: userID}).
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var mfa dto.UserMFA
+	err = m.db.QueryRow(ctx, sqlStatement, args...).Scan(&mfa.UserID, &mfa.Secret, &mfa.Enabled, &mfa.LastUsedStep,
+		&mfa.CreatedAt, &mfa.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, sql_err.ParseError(err)
+	}
+	return &mfa, nil
+}
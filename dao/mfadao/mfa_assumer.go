@@ -0,0 +1,24 @@
+package mfadao
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+type MFADaoAssumer interface {
+	MFASaver
+	MFAReader
+}
+
+type MFASaver interface {
+	Enroll(ctx context.Context, mfa dto.UserMFA) rest_err.APIError
+	Enable(ctx context.Context, userID int, step int64, recoveryCodeHashes []string, updatedAt int64) rest_err.APIError
+	UseStep(ctx context.Context, userID int, step int64) rest_err.APIError
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string, usedAt int64) rest_err.APIError
+	Delete(ctx context.Context, userID int) rest_err.APIError
+}
+
+type MFAReader interface {
+	Get(ctx context.Context, userID int) (*dto.UserMFA, rest_err.APIError)
+}
@@ -0,0 +1,184 @@
+package numberingdao
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/muchlist/berita_acara/dao"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/logger"
+	"github.com/muchlist/berita_acara/utils/mnumber"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/muchlist/berita_acara/utils/sql_err"
+	"time"
+)
+
+const (
+	keySchemeTable = "numbering_schemes"
+	keyUnit        = "unit"
+	keyPattern     = "pattern"
+	keyCreatedAt   = "created_at"
+	keyUpdatedAt   = "updated_at"
+
+	keyCounterTable = "numbering_counters"
+	keyYear         = "year"
+	keyLastValue    = "last_value"
+)
+
+var sb = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+type numberingDao struct {
+	db *pgxpool.Pool
+	sb squirrel.StatementBuilderType
+}
+
+func New(db *pgxpool.Pool) NumberingDaoAssumer {
+	return &numberingDao{
+		db: db,
+		sb: sb,
+	}
+}
+
+// Upsert menyimpan skema penomoran unit, skema yang sudah ada akan diganti.
+// Perubahan pattern tidak merubah nomor yang sudah terbit dan tidak mereset counter.
+func (n *numberingDao) Upsert(ctx context.Context, scheme dto.NumberingScheme) (*dto.NumberingScheme, rest_err.APIError) {
+	sqlStatement, args, err := n.sb.Insert(keySchemeTable).
+		Columns(keyUnit, keyPattern, keyCreatedAt, keyUpdatedAt).
+		Values(scheme.Unit, scheme.Pattern, scheme.CreatedAt, scheme.UpdatedAt).
+		Suffix("ON CONFLICT (unit) DO UPDATE SET pattern = EXCLUDED.pattern, updated_at = EXCLUDED.updated_at " +
+			dao.Returning(keyUnit, keyPattern, keyCreatedAt, keyUpdatedAt)).
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var result dto.NumberingScheme
+	err = n.db.QueryRow(ctx, sqlStatement, args...).Scan(&result.Unit, &result.Pattern, &result.CreatedAt, &result.UpdatedAt)
+	if err != nil {
+		logger.Error("error saat query numbering scheme (Upsert:0)", err)
+		return nil, sql_err.ParseError(err)
+	}
+
+	return &result, nil
+}
+
+// Delete menghapus skema penomoran unit, unit akan kembali menggunakan mnumber.DefaultPattern
+func (n *numberingDao) Delete(ctx context.Context, unit string) rest_err.APIError {
+	sqlStatement, args, err := n.sb.Delete(keySchemeTable).
+		Where(squirrel.Eq{keyUnit: unit}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	res, err := n.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		return rest_err.NewInternalServerError("gagal saat penghapusan skema penomoran", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return rest_err.NewBadRequestError(fmt.Sprintf("Skema penomoran unit %s tidak ditemukan", unit))
+	}
+
+	return nil
+}
+
+func (n *numberingDao) Get(ctx context.Context, unit string) (*dto.NumberingScheme, rest_err.APIError) {
+	sqlStatement, args, err := n.sb.Select(keyUnit, keyPattern, keyCreatedAt, keyUpdatedAt).
+		From(keySchemeTable).
+		Where(squirrel.Eq{keyUnit: unit}).
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var scheme dto.NumberingScheme
+	err = n.db.QueryRow(ctx, sqlStatement, args...).Scan(&scheme.Unit, &scheme.Pattern, &scheme.CreatedAt, &scheme.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, rest_err.NewNotFoundError(fmt.Sprintf("Skema penomoran unit %s tidak ditemukan", unit))
+		}
+		return nil, sql_err.ParseError(err)
+	}
+
+	return &scheme, nil
+}
+
+func (n *numberingDao) Find(ctx context.Context) ([]dto.NumberingScheme, rest_err.APIError) {
+	sqlStatement, args, err := n.sb.Select(keyUnit, keyPattern, keyCreatedAt, keyUpdatedAt).
+		From(keySchemeTable).
+		OrderBy(keyUnit + " ASC").
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	rows, err := n.db.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		return nil, rest_err.NewInternalServerError("gagal mendapatkan daftar skema penomoran", err)
+	}
+	defer rows.Close()
+
+	schemes := make([]dto.NumberingScheme, 0)
+	for rows.Next() {
+		scheme := dto.NumberingScheme{}
+		if err := rows.Scan(&scheme.Unit, &scheme.Pattern, &scheme.CreatedAt, &scheme.UpdatedAt); err != nil {
+			return nil, sql_err.ParseError(err)
+		}
+		schemes = append(schemes, scheme)
+	}
+
+	return schemes, nil
+}
+
+// Next menerbitkan nomor surat berikutnya untuk unit pada tahun dari at, harus dipanggil di dalam transaksi
+// yang sama dengan perubahan status document. Counter dinaikkan dengan upsert sehingga baris counter terkunci
+// sampai transaksi selesai, permintaan bersamaan pada unit dan tahun yang sama akan menunggu dan tidak
+// mendapatkan nomor yang sama. Counter tidak pernah diturunkan sehingga nomor tidak dipakai ulang.
+func Next(ctx context.Context, trx pgx.Tx, unit string, docType string, at time.Time) (string, rest_err.APIError) {
+	// ------------------------------------------------------------------------- pattern
+	sqlStatement, args, err := sb.Select(keyPattern).
+		From(keySchemeTable).
+		Where(squirrel.Eq{keyUnit: unit}).
+		ToSql()
+	if err != nil {
+		return "", rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	pattern := mnumber.DefaultPattern
+	err = trx.QueryRow(ctx, sqlStatement, args...).Scan(&pattern)
+	if err != nil && err != pgx.ErrNoRows {
+		return "", sql_err.ParseError(err)
+	}
+	// skema yang disimpan sebelum {unit} dan {year} diwajibkan dapat menghasilkan nomor ganda
+	if err := mnumber.ValidatePattern(pattern); err != nil {
+		return "", rest_err.NewBadRequestError(fmt.Sprintf("Skema penomoran unit %s harus diperbarui : %s", unit, err.Error()))
+	}
+
+	// ------------------------------------------------------------------------- counter
+	sqlStatement, args, err = sb.Insert(keyCounterTable).
+		Columns(keyUnit, keyYear, keyLastValue).
+		Values(unit, at.Year(), 1).
+		Suffix("ON CONFLICT (unit, year) DO UPDATE SET last_value = numbering_counters.last_value + 1 " +
+			dao.Returning(keyLastValue)).
+		ToSql()
+	if err != nil {
+		return "", rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var seq int
+	err = trx.QueryRow(ctx, sqlStatement, args...).Scan(&seq)
+	if err != nil {
+		logger.Error("error saat trx query numbering counter (Next:0)", err)
+		return "", sql_err.ParseError(err)
+	}
+
+	return mnumber.Format(pattern, mnumber.Values{
+		Seq:  seq,
+		Unit: unit,
+		Type: docType,
+		Time: at,
+	}), nil
+}
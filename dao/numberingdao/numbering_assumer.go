@@ -0,0 +1,22 @@
+package numberingdao
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+type NumberingDaoAssumer interface {
+	NumberingSaver
+	NumberingReader
+}
+
+type NumberingSaver interface {
+	Upsert(ctx context.Context, scheme dto.NumberingScheme) (*dto.NumberingScheme, rest_err.APIError)
+	Delete(ctx context.Context, unit string) rest_err.APIError
+}
+
+type NumberingReader interface {
+	Get(ctx context.Context, unit string) (*dto.NumberingScheme, rest_err.APIError)
+	Find(ctx context.Context) ([]dto.NumberingScheme, rest_err.APIError)
+}
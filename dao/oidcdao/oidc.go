@@ -0,0 +1,242 @@
+package oidcdao
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/muchlist/berita_acara/dao"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/logger"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/muchlist/berita_acara/utils/sql_err"
+)
+
+const (
+	keyStateTable   = "oidc_states"
+	keyStateHash    = "state_hash"
+	keyNonce        = "nonce"
+	keyCodeVerifier = "code_verifier"
+	keyExpiresAt    = "expires_at"
+	keyCreatedAt    = "created_at"
+
+	keyIdentityTable = "user_identities"
+	keyIssuer        = "issuer"
+	keySubject       = "subject"
+	keyUsersID       = "users_id"
+
+	keyUserTable = "users"
+	keyID        = "id"
+	keyEmail     = "email"
+	keyName      = "name"
+	keyPosition  = "position"
+	keyPassword  = "password"
+	keyUpdatedAt = "updated_at"
+
+	keyUsersRolesTable = "users_roles"
+	keyRolesName       = "roles_name"
+
+	provisionedIDSequence = "users_provisioned_id_seq"
+)
+
+type oidcDao struct {
+	db *pgxpool.Pool
+	sb squirrel.StatementBuilderType
+}
+
+func New(db *pgxpool.Pool) OIDCDaoAssumer {
+	return &oidcDao{
+		db: db,
+		sb: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+// InsertState menyimpan state login baru, state yang sudah kadaluarsa ikut dihapus
+func (o *oidcDao) InsertState(ctx context.Context, state dto.OIDCState) rest_err.APIError {
+	// ------------------------------------------------------------------------- begin
+	trx, err := o.db.Begin(ctx)
+	if err != nil {
+		return rest_err.NewInternalServerError("gagal memulai transaksi", err)
+	}
+	defer func(trx pgx.Tx) {
+		_ = trx.Rollback(context.Background())
+	}(trx)
+
+	// ------------------------------------------------------------------------- cleanup
+	sqlStatement, args, err := o.sb.Delete(keyStateTable).
+		Where(squirrel.Lt{keyExpiresAt: state.CreatedAt}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = trx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat trx exec oidc state (InsertState:0)", err)
+		return sql_err.ParseError(err)
+	}
+
+	// ------------------------------------------------------------------------- insert
+	sqlStatement, args, err = o.sb.Insert(keyStateTable).
+		Columns(keyStateHash, keyNonce, keyCodeVerifier, keyExpiresAt, keyCreatedAt).
+		Values(state.StateHash, state.Nonce, state.CodeVerifier, state.ExpiresAt, state.CreatedAt).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = trx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat trx exec oidc state (InsertState:1)", err)
+		return sql_err.ParseError(err)
+	}
+
+	// ------------------------------------------------------------------------- commit
+	if err := trx.Commit(ctx); err != nil {
+		return rest_err.NewInternalServerError(dao.ErrCommit, err)
+	}
+	return nil
+}
+
+// UseState menghapus dan mengembalikan state, state yang tidak ada atau kadaluarsa ditolak dengan pesan yang sama
+func (o *oidcDao) UseState(ctx context.Context, stateHash string, now int64) (*dto.OIDCState, rest_err.APIError) {
+	sqlStatement, args, err := o.sb.Delete(keyStateTable).
+		Where(squirrel.And{
+			squirrel.Eq{keyStateHash: stateHash},
+			squirrel.GtOrEq{keyExpiresAt: now},
+		}).
+		Suffix(dao.Returning(keyStateHash, keyNonce, keyCodeVerifier, keyExpiresAt, keyCreatedAt)).
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var state dto.OIDCState
+	err = o.db.QueryRow(ctx, sqlStatement, args...).Scan(&state.StateHash, &state.Nonce, &state.CodeVerifier,
+		&state.ExpiresAt, &state.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, rest_err.NewBadRequestError("State login tidak valid atau sudah kadaluarsa, silakan login kembali")
+		}
+		logger.Error("error saat queryrow oidc state (UseState:0)", err)
+		return nil, sql_err.ParseError(err)
+	}
+	return &state, nil
+}
+
+// GetUserID mengembalikan 0 tanpa error jika identitas belum terhubung dengan user
+func (o *oidcDao) GetUserID(ctx context.Context, issuer string, subject string) (int, rest_err.APIError) {
+	sqlStatement, args, err := o.sb.Select(keyUsersID).
+		From(keyIdentityTable).
+		Where(squirrel.Eq{
+			keyIssuer:  issuer,
+			keySubject: subject,
+		}).
+		ToSql()
+	if err != nil {
+		return 0, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var userID int
+	err = o.db.QueryRow(ctx, sqlStatement, args...).Scan(&userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, nil
+		}
+		logger.Error("error saat queryrow user identity (GetUserID:0)", err)
+		return 0, sql_err.ParseError(err)
+	}
+	return userID, nil
+}
+
+// LinkIdentity menghubungkan identitas dengan user yang sudah ada
+func (o *oidcDao) LinkIdentity(ctx context.Context, identity dto.UserIdentity) rest_err.APIError {
+	return o.insertIdentity(ctx, o.db, identity)
+}
+
+// Provision membuat user baru beserta role dan identitasnya dalam satu transaksi,
+// id user diambil dari sequence users_provisioned_id_seq
+func (o *oidcDao) Provision(ctx context.Context, user dto.User, identity dto.UserIdentity) (int, rest_err.APIError) {
+	user.Prepare()
+	if len(user.Roles) == 0 {
+		return 0, rest_err.NewBadRequestError("role tidak boleh kosong")
+	}
+
+	// ------------------------------------------------------------------------- begin
+	trx, err := o.db.Begin(ctx)
+	if err != nil {
+		return 0, rest_err.NewInternalServerError("gagal memulai transaksi", err)
+	}
+	defer func(trx pgx.Tx) {
+		_ = trx.Rollback(context.Background())
+	}(trx)
+
+	// ------------------------------------------------------------------------- insert user
+	sqlStatement, args, err := o.sb.Insert(keyUserTable).
+		Columns(keyID, keyEmail, keyName, keyPosition, keyPassword, keyCreatedAt, keyUpdatedAt).
+		Values(squirrel.Expr("nextval('"+provisionedIDSequence+"')"), user.Email, user.Name, user.Position,
+			user.Password, user.CreatedAt, user.UpdatedAt).
+		Suffix(dao.Returning(keyID)).
+		ToSql()
+	if err != nil {
+		return 0, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var userID int
+	err = trx.QueryRow(ctx, sqlStatement, args...).Scan(&userID)
+	if err != nil {
+		logger.Error("error saat trx queryrow user (Provision:0)", err)
+		return 0, sql_err.ParseError(err)
+	}
+
+	// ------------------------------------------------------------------------- insert role
+	sqlInsert := o.sb.Insert(keyUsersRolesTable).Columns(keyRolesName, keyUsersID)
+	for _, roleName := range user.Roles {
+		sqlInsert = sqlInsert.Values(roleName, userID)
+	}
+	sqlStatement, args, err = sqlInsert.ToSql()
+	if err != nil {
+		return 0, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = trx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat trx exec usersRoles (Provision:1)", err)
+		return 0, sql_err.ParseError(err)
+	}
+
+	// ------------------------------------------------------------------------- insert identity
+	identity.UserID = userID
+	if apiErr := o.insertIdentity(ctx, trx, identity); apiErr != nil {
+		return 0, apiErr
+	}
+
+	// ------------------------------------------------------------------------- commit
+	if err := trx.Commit(ctx); err != nil {
+		return 0, rest_err.NewInternalServerError(dao.ErrCommit, err)
+	}
+	return userID, nil
+}
+
+// execer dipenuhi oleh *pgxpool.Pool maupun pgx.Tx
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+}
+
+func (o *oidcDao) insertIdentity(ctx context.Context, e execer, identity dto.UserIdentity) rest_err.APIError {
+	sqlStatement, args, err := o.sb.Insert(keyIdentityTable).
+		Columns(keyIssuer, keySubject, keyUsersID, keyCreatedAt).
+		Values(identity.Issuer, identity.Subject, identity.UserID, identity.CreatedAt).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = e.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec user identity (insertIdentity:0)", err)
+		return sql_err.ParseError(err)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package oidcdao
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+type OIDCDaoAssumer interface {
+	OIDCSaver
+	OIDCReader
+}
+
+type OIDCSaver interface {
+	InsertState(ctx context.Context, state dto.OIDCState) rest_err.APIError
+	UseState(ctx context.Context, stateHash string, now int64) (*dto.OIDCState, rest_err.APIError)
+	LinkIdentity(ctx context.Context, identity dto.UserIdentity) rest_err.APIError
+	Provision(ctx context.Context, user dto.User, identity dto.UserIdentity) (int, rest_err.APIError)
+}
+
+type OIDCReader interface {
+	GetUserID(ctx context.Context, issuer string, subject string) (int, rest_err.APIError)
+}
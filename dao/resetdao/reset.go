@@ -0,0 +1,113 @@
+package resetdao
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/muchlist/berita_acara/dao"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/logger"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/muchlist/berita_acara/utils/sql_err"
+)
+
+const (
+	keyResetTable = "password_resets"
+	keyTokenHash  = "token_hash"
+	keyUsersID    = "users_id"
+	keyExpiresAt  = "expires_at"
+	keyUsedAt     = "used_at"
+	keyCreatedAt  = "created_at"
+)
+
+type resetDao struct {
+	db *pgxpool.Pool
+	sb squirrel.StatementBuilderType
+}
+
+func New(db *pgxpool.Pool) ResetDaoAssumer {
+	return &resetDao{
+		db: db,
+		sb: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+// Insert menyimpan token reset baru, token milik user yang sama yang belum digunakan
+// dan token yang sudah kadaluarsa dihapus sehingga hanya token terakhir yang berlaku
+func (r *resetDao) Insert(ctx context.Context, reset dto.PasswordReset) rest_err.APIError {
+	// ------------------------------------------------------------------------- begin
+	trx, err := r.db.Begin(ctx)
+	if err != nil {
+		return rest_err.NewInternalServerError("gagal memulai transaksi", err)
+	}
+	defer func(trx pgx.Tx) {
+		_ = trx.Rollback(context.Background())
+	}(trx)
+
+	// ------------------------------------------------------------------------- cleanup
+	sqlStatement, args, err := r.sb.Delete(keyResetTable).
+		Where(squirrel.Or{
+			squirrel.Eq{keyUsersID: reset.UserID},
+			squirrel.Lt{keyExpiresAt: reset.CreatedAt},
+		}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = trx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat trx exec password reset (Insert:0)", err)
+		return sql_err.ParseError(err)
+	}
+
+	// ------------------------------------------------------------------------- insert
+	sqlStatement, args, err = r.sb.Insert(keyResetTable).
+		Columns(keyTokenHash, keyUsersID, keyExpiresAt, keyCreatedAt).
+		Values(reset.TokenHash, reset.UserID, reset.ExpiresAt, reset.CreatedAt).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = trx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat trx exec password reset (Insert:1)", err)
+		return sql_err.ParseError(err)
+	}
+
+	// ------------------------------------------------------------------------- commit
+	if err := trx.Commit(ctx); err != nil {
+		return rest_err.NewInternalServerError(dao.ErrCommit, err)
+	}
+	return nil
+}
+
+// Use menandai token sudah digunakan dan mengembalikan pemilik token. Token yang tidak ada,
+// sudah digunakan atau kadaluarsa ditolak dengan pesan yang sama.
+func (r *resetDao) Use(ctx context.Context, tokenHash string, now int64) (int, rest_err.APIError) {
+	sqlStatement, args, err := r.sb.Update(keyResetTable).
+		Set(keyUsedAt, now).
+		Where(squirrel.And{
+			squirrel.Eq{keyTokenHash: tokenHash},
+			squirrel.Eq{keyUsedAt: 0},
+			squirrel.GtOrEq{keyExpiresAt: now},
+		}).
+		Suffix(dao.Returning(keyUsersID)).
+		ToSql()
+	if err != nil {
+		return 0, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var userID int
+	err = r.db.QueryRow(ctx, sqlStatement, args...).Scan(&userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, rest_err.NewBadRequestError("Token reset password tidak valid atau sudah kadaluarsa")
+		}
+		logger.Error("error saat queryrow password reset (Use:0)", err)
+		return 0, sql_err.ParseError(err)
+	}
+	return userID, nil
+}
@@ -0,0 +1,16 @@
+package resetdao
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+type ResetDaoAssumer interface {
+	ResetSaver
+}
+
+type ResetSaver interface {
+	Insert(ctx context.Context, reset dto.PasswordReset) rest_err.APIError
+	Use(ctx context.Context, tokenHash string, now int64) (int, rest_err.APIError)
+}
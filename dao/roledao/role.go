@@ -0,0 +1,271 @@
+package roledao
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/muchlist/berita_acara/dao"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/logger"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/muchlist/berita_acara/utils/sql_err"
+)
+
+const (
+	keyRoleTable = "roles"
+	keyRoleName  = "role_name"
+	keyCreatedAt = "created_at"
+	keyUpdatedAt = "updated_at"
+
+	keyPermissionTable = "permissions"
+	keyRolesName       = "roles_name"
+	keyPermissionName  = "permission_name"
+
+	keyUsersRolesTable = "users_roles"
+)
+
+type roleDao struct {
+	db *pgxpool.Pool
+	sb squirrel.StatementBuilderType
+}
+
+func New(db *pgxpool.Pool) RoleDaoAssumer {
+	return &roleDao{
+		db: db,
+		sb: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+func (r *roleDao) Insert(ctx context.Context, role dto.Role) rest_err.APIError {
+	sqlStatement, args, err := r.sb.Insert(keyRoleTable).
+		Columns(keyRoleName, keyCreatedAt, keyUpdatedAt).
+		Values(role.Name, role.CreatedAt, role.UpdatedAt).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = r.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec role (Insert:0)", err)
+		return sql_err.ParseError(err)
+	}
+
+	return nil
+}
+
+// Rename merubah nama role, users_roles dan permissions ikut berubah melalui ON UPDATE CASCADE
+func (r *roleDao) Rename(ctx context.Context, oldName string, newName string, updatedAt int64) rest_err.APIError {
+	sqlStatement, args, err := r.sb.Update(keyRoleTable).
+		SetMap(squirrel.Eq{
+			keyRoleName:  newName,
+			keyUpdatedAt: updatedAt,
+		}).
+		Where(squirrel.Eq{keyRoleName: oldName}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	res, err := r.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec role (Rename:0)", err)
+		return sql_err.ParseError(err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return rest_err.NewNotFoundError(fmt.Sprintf("Role %s tidak ditemukan", oldName))
+	}
+
+	return nil
+}
+
+// Delete menghapus role beserta permissionnya, role yang masih dimiliki user tidak dapat dihapus
+func (r *roleDao) Delete(ctx context.Context, name string) rest_err.APIError {
+	// ------------------------------------------------------------------------- begin
+	trx, err := r.db.Begin(ctx)
+	if err != nil {
+		return rest_err.NewInternalServerError("gagal memulai transaksi", err)
+	}
+	defer func(trx pgx.Tx) {
+		_ = trx.Rollback(context.Background())
+	}(trx)
+
+	// ------------------------------------------------------------------------- check
+	sqlStatement, args, err := r.sb.Select("COUNT(*)").
+		From(keyUsersRolesTable).
+		Where(squirrel.Eq{keyRolesName: name}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var userCount int
+	if err := trx.QueryRow(ctx, sqlStatement, args...).Scan(&userCount); err != nil {
+		return sql_err.ParseError(err)
+	}
+	if userCount > 0 {
+		return rest_err.NewBadRequestError(fmt.Sprintf("Role %s masih digunakan oleh %d user", name, userCount))
+	}
+
+	// ------------------------------------------------------------------------- delete
+	sqlStatement, args, err = r.sb.Delete(keyRoleTable).
+		Where(squirrel.Eq{keyRoleName: name}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	res, err := trx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		return rest_err.NewInternalServerError("gagal saat penghapusan role", err)
+	}
+	if res.RowsAffected() == 0 {
+		return rest_err.NewNotFoundError(fmt.Sprintf("Role %s tidak ditemukan", name))
+	}
+
+	// ------------------------------------------------------------------------- commit
+	if err := trx.Commit(ctx); err != nil {
+		return rest_err.NewInternalServerError(dao.ErrCommit, err)
+	}
+
+	return nil
+}
+
+// AddPermissions menambahkan permission pada role, permission yang sudah ada diabaikan
+func (r *roleDao) AddPermissions(ctx context.Context, name string, permissions []string, createdAt int64) rest_err.APIError {
+	if len(permissions) == 0 {
+		return rest_err.NewBadRequestError("permission tidak boleh kosong")
+	}
+
+	sqlInsert := r.sb.Insert(keyPermissionTable).
+		Columns(keyRolesName, keyPermissionName, keyCreatedAt, keyUpdatedAt)
+	for _, permission := range permissions {
+		sqlInsert = sqlInsert.Values(name, permission, createdAt, createdAt)
+	}
+	sqlStatement, args, err := sqlInsert.
+		Suffix("ON CONFLICT (roles_name, permission_name) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = r.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec permissions (AddPermissions:0)", err)
+		return sql_err.ParseError(err)
+	}
+
+	return nil
+}
+
+func (r *roleDao) RemovePermission(ctx context.Context, name string, permission string) rest_err.APIError {
+	sqlStatement, args, err := r.sb.Delete(keyPermissionTable).
+		Where(squirrel.Eq{
+			keyRolesName:      name,
+			keyPermissionName: permission,
+		}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	res, err := r.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		return rest_err.NewInternalServerError("gagal saat penghapusan permission", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return rest_err.NewBadRequestError(fmt.Sprintf("Permission %s tidak ditemukan pada role %s", permission, name))
+	}
+
+	return nil
+}
+
+// Seed membuat role apabila belum ada, role yang sudah ada tidak diubah
+func (r *roleDao) Seed(ctx context.Context, names []string, createdAt int64) rest_err.APIError {
+	sqlInsert := r.sb.Insert(keyRoleTable).
+		Columns(keyRoleName, keyCreatedAt, keyUpdatedAt)
+	for _, name := range names {
+		sqlInsert = sqlInsert.Values(name, createdAt, createdAt)
+	}
+	sqlStatement, args, err := sqlInsert.
+		Suffix("ON CONFLICT (role_name) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = r.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec role (Seed:0)", err)
+		return sql_err.ParseError(err)
+	}
+
+	return nil
+}
+
+func (r *roleDao) Get(ctx context.Context, name string) (*dto.Role, rest_err.APIError) {
+	roles, apiErr := r.find(ctx, squirrel.Eq{dao.A(keyRoleName): name})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if len(roles) == 0 {
+		return nil, rest_err.NewNotFoundError(fmt.Sprintf("Role %s tidak ditemukan", name))
+	}
+	return &roles[0], nil
+}
+
+func (r *roleDao) Find(ctx context.Context) ([]dto.Role, rest_err.APIError) {
+	return r.find(ctx, nil)
+}
+
+// find mendapatkan role beserta permissionnya, urut berdasarkan nama role
+func (r *roleDao) find(ctx context.Context, where squirrel.Sqlizer) ([]dto.Role, rest_err.APIError) {
+	sqlfrom := r.sb.Select(
+		dao.A(keyRoleName),
+		dao.A(keyCreatedAt),
+		dao.A(keyUpdatedAt),
+		dao.B(keyPermissionName),
+	).
+		From(keyRoleTable + " A").
+		LeftJoin(keyPermissionTable + " B ON A.role_name = B.roles_name")
+	if where != nil {
+		sqlfrom = sqlfrom.Where(where)
+	}
+
+	sqlStatement, args, err := sqlfrom.
+		OrderBy(dao.A(keyRoleName)+" ASC", dao.B(keyPermissionName)+" ASC").
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	rows, err := r.db.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		return nil, rest_err.NewInternalServerError("gagal mendapatkan daftar role", err)
+	}
+	defer rows.Close()
+
+	roles := make([]dto.Role, 0)
+	for rows.Next() {
+		var role dto.Role
+		var permission *string
+		if err := rows.Scan(&role.Name, &role.CreatedAt, &role.UpdatedAt, &permission); err != nil {
+			return nil, sql_err.ParseError(err)
+		}
+		// baris dengan role yang sama berurutan karena diurutkan berdasarkan nama role
+		if len(roles) == 0 || roles[len(roles)-1].Name != role.Name {
+			role.Prepare()
+			roles = append(roles, role)
+		}
+		if permission != nil {
+			last := &roles[len(roles)-1]
+			last.Permissions = append(last.Permissions, *permission)
+		}
+	}
+
+	return roles, nil
+}
@@ -0,0 +1,26 @@
+package roledao
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+type RoleDaoAssumer interface {
+	RoleSaver
+	RoleReader
+}
+
+type RoleSaver interface {
+	Insert(ctx context.Context, role dto.Role) rest_err.APIError
+	Rename(ctx context.Context, oldName string, newName string, updatedAt int64) rest_err.APIError
+	Delete(ctx context.Context, name string) rest_err.APIError
+	AddPermissions(ctx context.Context, name string, permissions []string, createdAt int64) rest_err.APIError
+	RemovePermission(ctx context.Context, name string, permission string) rest_err.APIError
+	Seed(ctx context.Context, names []string, createdAt int64) rest_err.APIError
+}
+
+type RoleReader interface {
+	Get(ctx context.Context, name string) (*dto.Role, rest_err.APIError)
+	Find(ctx context.Context) ([]dto.Role, rest_err.APIError)
+}
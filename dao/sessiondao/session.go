@@ -0,0 +1,217 @@
+package sessiondao
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/muchlist/berita_acara/dao"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/logger"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/muchlist/berita_acara/utils/sql_err"
+)
+
+const (
+	keySessionTable  = "sessions"
+	keyID            = "id"
+	keyUsersID       = "users_id"
+	keyUserAgent     = "user_agent"
+	keyIP            = "ip"
+	keyLastRefreshAt = "last_refresh_at"
+	keyCreatedAt     = "created_at"
+
+	keyRefreshTokenTable = "refresh_tokens"
+	keyFamilyID          = "family_id"
+	keyExpiresAt         = "expires_at"
+	keyRevokedAt         = "revoked_at"
+)
+
+type sessionDao struct {
+	db *pgxpool.Pool
+	sb squirrel.StatementBuilderType
+}
+
+func New(db *pgxpool.Pool) SessionDaoAssumer {
+	return &sessionDao{
+		db: db,
+		sb: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+// activeFamily kondisi sesi masih memiliki refresh token yang belum direvoke dan belum kadaluarsa
+func activeFamily(now int64) squirrel.Sqlizer {
+	return squirrel.Expr(fmt.Sprintf("EXISTS (SELECT 1 FROM %s R WHERE R.%s = A.%s AND R.%s = 0 AND R.%s >= ?)",
+		keyRefreshTokenTable, keyFamilyID, keyID, keyRevokedAt, keyExpiresAt), now)
+}
+
+// Insert menyimpan sesi baru saat login, sesi milik user yang sudah tidak aktif ikut dihapus
+func (s *sessionDao) Insert(ctx context.Context, session dto.Session) rest_err.APIError {
+	// ------------------------------------------------------------------------- begin
+	trx, err := s.db.Begin(ctx)
+	if err != nil {
+		return rest_err.NewInternalServerError("gagal memulai transaksi", err)
+	}
+	defer func(trx pgx.Tx) {
+		_ = trx.Rollback(context.Background())
+	}(trx)
+
+	// ------------------------------------------------------------------------- cleanup
+	sqlStatement, args, err := s.sb.Delete(keySessionTable + " A").
+		Where(squirrel.Eq{dao.A(keyUsersID): session.UserID}).
+		Where(squirrel.Expr("NOT ?", activeFamily(session.CreatedAt))).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = trx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat trx exec session (Insert:0)", err)
+		return sql_err.ParseError(err)
+	}
+
+	// ------------------------------------------------------------------------- insert
+	sqlStatement, args, err = s.sb.Insert(keySessionTable).
+		Columns(keyID, keyUsersID, keyUserAgent, keyIP, keyCreatedAt).
+		Values(session.ID, session.UserID, session.UserAgent, session.IP, session.CreatedAt).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = trx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat trx exec session (Insert:1)", err)
+		return sql_err.ParseError(err)
+	}
+
+	// ------------------------------------------------------------------------- commit
+	if err := trx.Commit(ctx); err != nil {
+		return rest_err.NewInternalServerError(dao.ErrCommit, err)
+	}
+	return nil
+}
+
+// Touch mencatat waktu refresh terakhir, sesi yang dibuat sebelum tabel sessions ada diabaikan
+func (s *sessionDao) Touch(ctx context.Context, id string, refreshedAt int64) rest_err.APIError {
+	sqlStatement, args, err := s.sb.Update(keySessionTable).
+		Set(keyLastRefreshAt, refreshedAt).
+		Where(squirrel.Eq{keyID: id}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = s.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec session (Touch:0)", err)
+		return sql_err.ParseError(err)
+	}
+	return nil
+}
+
+// Terminate merevoke seluruh refresh token pada family sesi, sesi harus milik userID
+func (s *sessionDao) Terminate(ctx context.Context, userID int, id string, revokedAt int64) rest_err.APIError {
+	// ------------------------------------------------------------------------- begin
+	trx, err := s.db.Begin(ctx)
+	if err != nil {
+		return rest_err.NewInternalServerError("gagal memulai transaksi", err)
+	}
+	defer func(trx pgx.Tx) {
+		_ = trx.Rollback(context.Background())
+	}(trx)
+
+	// ------------------------------------------------------------------------- check
+	sqlStatement, args, err := s.sb.Select(keyID).
+		From(keySessionTable).
+		Where(squirrel.Eq{
+			keyID:      id,
+			keyUsersID: userID,
+		}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var sessionID string
+	err = trx.QueryRow(ctx, sqlStatement, args...).Scan(&sessionID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return rest_err.NewBadRequestError(fmt.Sprintf("Sesi %s tidak ditemukan", id))
+		}
+		logger.Error("error saat trx queryrow session (Terminate:0)", err)
+		return sql_err.ParseError(err)
+	}
+
+	// ------------------------------------------------------------------------- revoke
+	sqlStatement, args, err = s.sb.Update(keyRefreshTokenTable).
+		Set(keyRevokedAt, revokedAt).
+		Where(squirrel.Eq{
+			keyFamilyID:  sessionID,
+			keyRevokedAt: 0,
+		}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = trx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat trx exec refresh token (Terminate:1)", err)
+		return sql_err.ParseError(err)
+	}
+
+	// ------------------------------------------------------------------------- commit
+	if err := trx.Commit(ctx); err != nil {
+		return rest_err.NewInternalServerError(dao.ErrCommit, err)
+	}
+	return nil
+}
+
+// FindActive menampilkan sesi user yang masih dapat di-refresh, terbaru di atas
+func (s *sessionDao) FindActive(ctx context.Context, userID int, now int64) ([]dto.Session, rest_err.APIError) {
+	sqlStatement, args, err := s.sb.Select(
+		dao.A(keyID),
+		dao.A(keyUsersID),
+		dao.A(keyUserAgent),
+		dao.A(keyIP),
+		dao.A(keyLastRefreshAt),
+		dao.A(keyCreatedAt),
+	).
+		From(keySessionTable + " A").
+		Where(squirrel.Eq{dao.A(keyUsersID): userID}).
+		Where(activeFamily(now)).
+		OrderBy(dao.A(keyCreatedAt) + " DESC").
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	rows, err := s.db.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat query session (FindActive:0)", err)
+		return nil, sql_err.ParseError(err)
+	}
+	defer rows.Close()
+
+	sessions := make([]dto.Session, 0)
+	for rows.Next() {
+		var session dto.Session
+		err := rows.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IP,
+			&session.LastRefreshAt, &session.CreatedAt)
+		if err != nil {
+			logger.Error("error saat scan session (FindActive:1)", err)
+			return nil, sql_err.ParseError(err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error("error saat query session (FindActive:2)", err)
+		return nil, sql_err.ParseError(err)
+	}
+
+	return sessions, nil
+}
@@ -0,0 +1,22 @@
+package sessiondao
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+type SessionDaoAssumer interface {
+	SessionSaver
+	SessionReader
+}
+
+type SessionSaver interface {
+	Insert(ctx context.Context, session dto.Session) rest_err.APIError
+	Touch(ctx context.Context, id string, refreshedAt int64) rest_err.APIError
+	Terminate(ctx context.Context, userID int, id string, revokedAt int64) rest_err.APIError
+}
+
+type SessionReader interface {
+	FindActive(ctx context.Context, userID int, now int64) ([]dto.Session, rest_err.APIError)
+}
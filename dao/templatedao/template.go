@@ -0,0 +1,204 @@
+package templatedao
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/muchlist/berita_acara/dao"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/logger"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/muchlist/berita_acara/utils/sql_err"
+)
+
+const (
+	keyTemplateTable = "templates"
+	keyID            = "id"
+	keyName          = "name"
+	keyType          = "doc_type"
+	keyTitle         = "title"
+	keyBody          = "body"
+	keyFields        = "fields"
+	keyCreator       = "creator"
+	keyCreatedAt     = "created_at"
+	keyUpdatedAt     = "updated_at"
+)
+
+type templateDao struct {
+	db *pgxpool.Pool
+	sb squirrel.StatementBuilderType
+}
+
+func New(db *pgxpool.Pool) TemplateDaoAssumer {
+	return &templateDao{
+		db: db,
+		sb: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+func (t *templateDao) Insert(ctx context.Context, template dto.Template) (int, rest_err.APIError) {
+	fields, err := json.Marshal(template.Fields)
+	if err != nil {
+		return 0, rest_err.NewInternalServerError("gagal encode fields template", err)
+	}
+
+	sqlStatement, args, err := t.sb.Insert(keyTemplateTable).
+		Columns(keyName, keyType, keyTitle, keyBody, keyFields, keyCreator, keyCreatedAt, keyUpdatedAt).
+		Values(template.Name, template.Type, template.Title, template.Body, fields, template.Creator, template.CreatedAt, template.UpdatedAt).
+		Suffix(dao.Returning(keyID)).
+		ToSql()
+	if err != nil {
+		return 0, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var templateID int
+	err = t.db.QueryRow(ctx, sqlStatement, args...).Scan(&templateID)
+	if err != nil {
+		logger.Error("error saat query template (Insert:0)", err)
+		return 0, sql_err.ParseError(err)
+	}
+
+	return templateID, nil
+}
+
+func (t *templateDao) Edit(ctx context.Context, input dto.Template) (*dto.Template, rest_err.APIError) {
+	fields, err := json.Marshal(input.Fields)
+	if err != nil {
+		return nil, rest_err.NewInternalServerError("gagal encode fields template", err)
+	}
+
+	sqlStatement, args, err := t.sb.Update(keyTemplateTable).
+		SetMap(squirrel.Eq{
+			keyName:      input.Name,
+			keyType:      input.Type,
+			keyTitle:     input.Title,
+			keyBody:      input.Body,
+			keyFields:    fields,
+			keyUpdatedAt: input.UpdatedAt,
+		}).
+		Where(squirrel.Eq{
+			keyID: input.ID,
+		}).
+		Suffix(dao.Returning(keyID, keyName, keyType, keyTitle, keyBody, keyFields, keyCreator, keyCreatedAt, keyUpdatedAt)).
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	template, err := scanTemplate(t.db.QueryRow(ctx, sqlStatement, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, rest_err.NewNotFoundError(fmt.Sprintf("Template %d tidak ditemukan", input.ID))
+		}
+		return nil, sql_err.ParseError(err)
+	}
+
+	return template, nil
+}
+
+func (t *templateDao) Delete(ctx context.Context, id int) rest_err.APIError {
+	sqlStatement, args, err := t.sb.Delete(keyTemplateTable).
+		Where(squirrel.Eq{keyID: id}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	res, err := t.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		return rest_err.NewInternalServerError("gagal saat penghapusan template", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return rest_err.NewBadRequestError(fmt.Sprintf("Template %d tidak ditemukan", id))
+	}
+
+	return nil
+}
+
+func (t *templateDao) Get(ctx context.Context, id int) (*dto.Template, rest_err.APIError) {
+	sqlStatement, args, err := t.sb.Select(keyID, keyName, keyType, keyTitle, keyBody, keyFields, keyCreator, keyCreatedAt, keyUpdatedAt).
+		From(keyTemplateTable).
+		Where(squirrel.Eq{keyID: id}).
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	template, err := scanTemplate(t.db.QueryRow(ctx, sqlStatement, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, rest_err.NewNotFoundError(fmt.Sprintf("Template %d tidak ditemukan", id))
+		}
+		return nil, sql_err.ParseError(err)
+	}
+
+	return template, nil
+}
+
+// FindWithCursor example : ?limit=10&cursor=last_id_from_previous_fetch
+func (t *templateDao) FindWithCursor(ctx context.Context, search string, limit uint64, cursor int) ([]dto.Template, rest_err.APIError) {
+	sqlfrom := t.sb.Select(keyID, keyName, keyType, keyTitle, keyBody, keyFields, keyCreator, keyCreatedAt, keyUpdatedAt).
+		From(keyTemplateTable)
+
+	// where
+	if len(search) > 0 {
+		// search
+		sqlfrom = sqlfrom.Where(squirrel.And{
+			squirrel.Gt{keyID: cursor},
+			squirrel.ILike{keyName: fmt.Sprint("%", search, "%")},
+		})
+	} else {
+		// find
+		sqlfrom = sqlfrom.Where(squirrel.Gt{keyID: cursor})
+	}
+
+	sqlStatement, args, err := sqlfrom.OrderBy(keyID + " ASC").
+		Limit(limit).
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	rows, err := t.db.Query(ctx, sqlStatement, args...)
+	if err != nil {
+		return nil, rest_err.NewInternalServerError("gagal mendapatkan daftar template", err)
+	}
+	defer rows.Close()
+
+	templates := make([]dto.Template, 0)
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, sql_err.ParseError(err)
+		}
+		templates = append(templates, *template)
+	}
+
+	return templates, nil
+}
+
+// scanTemplate membaca satu baris template lengkap dan men-decode kolom fields (jsonb)
+func scanTemplate(row pgx.Row) (*dto.Template, error) {
+	var template dto.Template
+	var fields []byte
+	var creator *int
+	err := row.Scan(&template.ID, &template.Name, &template.Type, &template.Title, &template.Body,
+		&fields, &creator, &template.CreatedAt, &template.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if creator != nil {
+		template.Creator = *creator
+	}
+	if err := json.Unmarshal(fields, &template.Fields); err != nil {
+		return nil, err
+	}
+	if template.Fields == nil {
+		template.Fields = []dto.TemplateField{}
+	}
+	return &template, nil
+}
@@ -0,0 +1,23 @@
+package templatedao
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+type TemplateDaoAssumer interface {
+	TemplateSaver
+	TemplateReader
+}
+
+type TemplateSaver interface {
+	Insert(ctx context.Context, template dto.Template) (int, rest_err.APIError)
+	Edit(ctx context.Context, input dto.Template) (*dto.Template, rest_err.APIError)
+	Delete(ctx context.Context, id int) rest_err.APIError
+}
+
+type TemplateReader interface {
+	Get(ctx context.Context, id int) (*dto.Template, rest_err.APIError)
+	FindWithCursor(ctx context.Context, search string, limit uint64, cursor int) ([]dto.Template, rest_err.APIError)
+}
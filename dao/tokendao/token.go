@@ -0,0 +1,210 @@
+package tokendao
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/muchlist/berita_acara/dao"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/logger"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/muchlist/berita_acara/utils/sql_err"
+)
+
+const (
+	keyRefreshTokenTable = "refresh_tokens"
+	keyTokenID           = "token_id"
+	keyFamilyID          = "family_id"
+	keyUsersID           = "users_id"
+	keyExpiresAt         = "expires_at"
+	keyRevokedAt         = "revoked_at"
+	keyReplacedBy        = "replaced_by"
+	keyCreatedAt         = "created_at"
+)
+
+type tokenDao struct {
+	db *pgxpool.Pool
+	sb squirrel.StatementBuilderType
+}
+
+func New(db *pgxpool.Pool) TokenDaoAssumer {
+	return &tokenDao{
+		db: db,
+		sb: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+// Insert menyimpan refresh token baru, digunakan saat login untuk membuat family baru
+func (t *tokenDao) Insert(ctx context.Context, token dto.RefreshToken) rest_err.APIError {
+	return t.insert(ctx, t.db, token)
+}
+
+// Rotate menukar refresh token oldTokenID dengan newToken pada family yang sama.
+// Apabila oldTokenID sudah pernah dirotasi atau direvoke (token dipakai ulang, kemungkinan dicuri)
+// maka seluruh token pada family tersebut direvoke sehingga pemilik sah maupun pencuri harus login ulang.
+func (t *tokenDao) Rotate(ctx context.Context, oldTokenID string, newToken dto.RefreshToken) (*dto.RefreshToken, rest_err.APIError) {
+	// ------------------------------------------------------------------------- begin
+	trx, err := t.db.Begin(ctx)
+	if err != nil {
+		return nil, rest_err.NewInternalServerError("gagal memulai transaksi", err)
+	}
+	defer func(trx pgx.Tx) {
+		_ = trx.Rollback(context.Background())
+	}(trx)
+
+	// ------------------------------------------------------------------------- check
+	sqlStatement, args, err := t.sb.Select(keyTokenID, keyFamilyID, keyUsersID, keyExpiresAt, keyRevokedAt, keyReplacedBy, keyCreatedAt).
+		From(keyRefreshTokenTable).
+		Where(squirrel.Eq{keyTokenID: oldTokenID}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var old dto.RefreshToken
+	err = trx.QueryRow(ctx, sqlStatement, args...).Scan(&old.TokenID, &old.FamilyID, &old.UserID, &old.ExpiresAt,
+		&old.RevokedAt, &old.ReplacedBy, &old.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, rest_err.NewUnauthorizedError("Refresh token tidak dikenali, silakan login kembali")
+		}
+		return nil, sql_err.ParseError(err)
+	}
+
+	if old.RevokedAt != 0 {
+		if old.ReplacedBy != "" {
+			// ------------------------------------------------------------------------- reuse detected
+			if apiErr := t.revokeFamily(ctx, trx, old.FamilyID, newToken.CreatedAt); apiErr != nil {
+				return nil, apiErr
+			}
+			if err := trx.Commit(ctx); err != nil {
+				return nil, rest_err.NewInternalServerError(dao.ErrCommit, err)
+			}
+			logger.Info(fmt.Sprintf("refresh token user %d dipakai ulang, seluruh sesi pada family direvoke", old.UserID))
+		}
+		return nil, rest_err.NewUnauthorizedError("Refresh token sudah tidak berlaku, silakan login kembali")
+	}
+	if old.ExpiresAt < newToken.CreatedAt {
+		return nil, rest_err.NewUnauthorizedError("Refresh token kadaluarsa, silakan login kembali")
+	}
+
+	// ------------------------------------------------------------------------- rotate
+	sqlStatement, args, err = t.sb.Update(keyRefreshTokenTable).
+		SetMap(squirrel.Eq{
+			keyRevokedAt:  newToken.CreatedAt,
+			keyReplacedBy: newToken.TokenID,
+		}).
+		Where(squirrel.Eq{keyTokenID: old.TokenID}).
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = trx.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat trx exec refresh token (Rotate:0)", err)
+		return nil, sql_err.ParseError(err)
+	}
+
+	newToken.FamilyID = old.FamilyID
+	newToken.UserID = old.UserID
+	if apiErr := t.insert(ctx, trx, newToken); apiErr != nil {
+		return nil, apiErr
+	}
+
+	// ------------------------------------------------------------------------- commit
+	if err := trx.Commit(ctx); err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrCommit, err)
+	}
+
+	return &newToken, nil
+}
+
+// RevokeFamily merevoke seluruh token yang satu family dengan tokenID, digunakan untuk logout
+func (t *tokenDao) RevokeFamily(ctx context.Context, tokenID string, revokedAt int64) rest_err.APIError {
+	sqlStatement, args, err := t.sb.Select(keyFamilyID).
+		From(keyRefreshTokenTable).
+		Where(squirrel.Eq{keyTokenID: tokenID}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var familyID string
+	err = t.db.QueryRow(ctx, sqlStatement, args...).Scan(&familyID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return rest_err.NewBadRequestError("Refresh token tidak dikenali")
+		}
+		return sql_err.ParseError(err)
+	}
+
+	return t.revokeFamily(ctx, t.db, familyID, revokedAt)
+}
+
+// RevokeUser merevoke seluruh sesi milik user
+func (t *tokenDao) RevokeUser(ctx context.Context, userID int, revokedAt int64) rest_err.APIError {
+	sqlStatement, args, err := t.sb.Update(keyRefreshTokenTable).
+		Set(keyRevokedAt, revokedAt).
+		Where(squirrel.Eq{
+			keyUsersID:   userID,
+			keyRevokedAt: 0,
+		}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = t.db.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec refresh token (RevokeUser:0)", err)
+		return sql_err.ParseError(err)
+	}
+	return nil
+}
+
+// execer dipenuhi oleh *pgxpool.Pool maupun pgx.Tx
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+}
+
+func (t *tokenDao) insert(ctx context.Context, e execer, token dto.RefreshToken) rest_err.APIError {
+	sqlStatement, args, err := t.sb.Insert(keyRefreshTokenTable).
+		Columns(keyTokenID, keyFamilyID, keyUsersID, keyExpiresAt, keyCreatedAt).
+		Values(token.TokenID, token.FamilyID, token.UserID, token.ExpiresAt, token.CreatedAt).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = e.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec refresh token (insert:0)", err)
+		return sql_err.ParseError(err)
+	}
+	return nil
+}
+
+func (t *tokenDao) revokeFamily(ctx context.Context, e execer, familyID string, revokedAt int64) rest_err.APIError {
+	sqlStatement, args, err := t.sb.Update(keyRefreshTokenTable).
+		Set(keyRevokedAt, revokedAt).
+		Where(squirrel.Eq{
+			keyFamilyID:  familyID,
+			keyRevokedAt: 0,
+		}).
+		ToSql()
+	if err != nil {
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	_, err = e.Exec(ctx, sqlStatement, args...)
+	if err != nil {
+		logger.Error("error saat exec refresh token (revokeFamily:0)", err)
+		return sql_err.ParseError(err)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package tokendao
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+type TokenDaoAssumer interface {
+	TokenSaver
+}
+
+type TokenSaver interface {
+	Insert(ctx context.Context, token dto.RefreshToken) rest_err.APIError
+	Rotate(ctx context.Context, oldTokenID string, newToken dto.RefreshToken) (*dto.RefreshToken, rest_err.APIError)
+	RevokeFamily(ctx context.Context, tokenID string, revokedAt int64) rest_err.APIError
+	RevokeUser(ctx context.Context, userID int, revokedAt int64) rest_err.APIError
+}
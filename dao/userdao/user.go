@@ -2,8 +2,11 @@ package userdao
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/muchlist/berita_acara/dao"
@@ -12,6 +15,7 @@ import (
 	"github.com/muchlist/berita_acara/utils/logger"
 	"github.com/muchlist/berita_acara/utils/rest_err"
 	"github.com/muchlist/berita_acara/utils/sql_err"
+	"strings"
 )
 
 const (
@@ -19,7 +23,9 @@ const (
 	keyID        = "id"
 	keyEmail     = "email"
 	keyName      = "name"
+	keyPosition  = "position"
 	keyPassword  = "password"
+	keyService   = "service_account"
 	keyCreatedAt = "created_at"
 	keyUpdatedAt = "updated_at"
 
@@ -27,6 +33,9 @@ const (
 	keyUsersID         = "users_id"
 	keyRolesName       = "roles_name"
 
+	keyRefreshTokenTable = "refresh_tokens"
+	keyRevokedAt         = "revoked_at"
+
 	//keyRolesTable = "roles"
 	//keyRoleName   = "role_name"
 )
@@ -56,8 +65,8 @@ func (u *userDao) Insert(ctx context.Context, user dto.User) (int, rest_err.APIE
 	}(trx)
 
 	// -------------------------------------------------------------- insert user data
-	sqlStatement, args, err := u.sb.Insert(keyUserTable).Columns(keyID, keyEmail, keyName, keyPassword, keyCreatedAt, keyUpdatedAt).
-		Values(user.ID, user.Email, user.Name, user.Password, user.CreatedAt, user.UpdatedAt).
+	sqlStatement, args, err := u.sb.Insert(keyUserTable).Columns(keyID, keyEmail, keyName, keyPosition, keyPassword, keyService, keyCreatedAt, keyUpdatedAt).
+		Values(user.ID, user.Email, user.Name, user.Position, user.Password, user.ServiceAccount, user.CreatedAt, user.UpdatedAt).
 		Suffix(dao.Returning(keyID)).
 		ToSql()
 	if err != nil {
@@ -98,6 +107,8 @@ func (u *userDao) Insert(ctx context.Context, user dto.User) (int, rest_err.APIE
 	return userID, nil
 }
 
+// Edit merubah data user beserta rolenya, apabila role berubah maka seluruh refresh token user
+// direvoke agar token dengan role lama tidak dapat diperpanjang
 func (u *userDao) Edit(ctx context.Context, input dto.User) (*dto.User, rest_err.APIError) {
 
 	if len(input.Roles) == 0 {
@@ -115,12 +126,13 @@ func (u *userDao) Edit(ctx context.Context, input dto.User) (*dto.User, rest_err
 		SetMap(squirrel.Eq{
 			keyEmail:     input.Email,
 			keyName:      input.Name,
+			keyPosition:  input.Position,
 			keyUpdatedAt: input.UpdatedAt,
 		}).
 		Where(squirrel.Eq{
 			keyID: input.ID,
 		}).
-		Suffix(dao.Returning(keyID, keyEmail, keyName, keyCreatedAt, keyUpdatedAt)).
+		Suffix(dao.Returning(keyID, keyEmail, keyName, keyPosition, keyCreatedAt, keyUpdatedAt)).
 		ToSql()
 
 	if err != nil {
@@ -130,48 +142,162 @@ func (u *userDao) Edit(ctx context.Context, input dto.User) (*dto.User, rest_err
 	var user dto.User
 	err = trx.QueryRow(
 		ctx,
-		sqlStatement, args...).Scan(&user.ID, &user.Email, &user.Name, &user.CreatedAt, &user.UpdatedAt)
+		sqlStatement, args...).Scan(&user.ID, &user.Email, &user.Name, &user.Position, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		return nil, sql_err.ParseError(err)
 	}
 
+	// ------------------------------------------------------------------------- role replace
+	if apiErr := u.replaceRoles(ctx, trx, user.ID, input.Roles, input.UpdatedAt); apiErr != nil {
+		return nil, apiErr
+	}
+
+	// ------------------------------------------------------------------------- commit
+	if err := trx.Commit(ctx); err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrCommit, err)
+	}
+
+	user.Roles = input.Roles
+
+	return &user, nil
+}
+
+// ChangeRoles mengganti role user, digunakan untuk sinkronisasi role dari LDAP. Seperti Edit, seluruh refresh token
+// user direvoke apabila role berubah
+func (u *userDao) ChangeRoles(ctx context.Context, userID int, roles []string, updatedAt int64) rest_err.APIError {
+	if len(roles) == 0 {
+		return rest_err.NewBadRequestError("role tidak boleh kosong")
+	}
+
+	// ------------------------------------------------------------------------- begin
+	trx, err := u.db.Begin(ctx)
+	if err != nil {
+		return rest_err.NewInternalServerError("gagal memulai transaksi", err)
+	}
+	defer func(trx pgx.Tx) {
+		_ = trx.Rollback(context.Background())
+	}(trx)
+
+	// ------------------------------------------------------------------------- role replace
+	if apiErr := u.replaceRoles(ctx, trx, userID, roles, updatedAt); apiErr != nil {
+		return apiErr
+	}
+
+	// ------------------------------------------------------------------------- commit
+	if err := trx.Commit(ctx); err != nil {
+		return rest_err.NewInternalServerError(dao.ErrCommit, err)
+	}
+
+	return nil
+}
+
+// replaceRoles menghapus role lama lalu menyimpan role baru di dalam transaksi, refresh token user direvoke
+// apabila himpunan role berubah
+func (u *userDao) replaceRoles(ctx context.Context, trx pgx.Tx, userID int, roles []string, updatedAt int64) rest_err.APIError {
 	// ------------------------------------------------------------------------- role delete
-	sqlStatement, args, err = u.sb.Delete(keyUsersRolesTable).
+	sqlStatement, args, err := u.sb.Delete(keyUsersRolesTable).
 		Where(squirrel.Eq{
-			keyUsersID: input.ID,
-		}).ToSql()
+			keyUsersID: userID,
+		}).
+		Suffix(dao.Returning(keyRolesName)).
+		ToSql()
 	if err != nil {
-		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
 	}
 
-	_, err = db.DB.Exec(ctx, sqlStatement, args...)
+	rows, err := trx.Query(ctx, sqlStatement, args...)
 	if err != nil {
-		logger.Error("error saat trx exec usersRoles(ChangeRole:0)", err)
-		return nil, sql_err.ParseError(err)
+		logger.Error("error saat trx query usersRoles(ChangeRole:0)", err)
+		return sql_err.ParseError(err)
+	}
+	oldRoles := make(map[string]bool)
+	for rows.Next() {
+		var roleName string
+		if err := rows.Scan(&roleName); err != nil {
+			rows.Close()
+			return sql_err.ParseError(err)
+		}
+		oldRoles[roleName] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		logger.Error("error saat trx query usersRoles(ChangeRole:1)", err)
+		return sql_err.ParseError(err)
 	}
 
 	// ------------------------------------------------------------------------- role insert
 	sqlInsert := u.sb.Insert(keyUsersRolesTable).Columns(keyRolesName, keyUsersID)
-	for _, roleName := range input.Roles {
-		sqlInsert = sqlInsert.Values(roleName, user.ID)
+	for _, roleName := range roles {
+		sqlInsert = sqlInsert.Values(roleName, userID)
 	}
 	sqlStatement, args, err = sqlInsert.ToSql()
 	if err != nil {
-		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+		return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
 	}
 
 	_, err = trx.Exec(ctx, sqlStatement, args...)
 	if err != nil {
 		logger.Error("error saat trx query usersRoles(Insert:1)", err)
-		return nil, sql_err.ParseError(err)
+		return sql_err.ParseError(err)
 	}
 
-	// ------------------------------------------------------------------------- commit
-	if err := trx.Commit(ctx); err != nil {
-		return nil, rest_err.NewInternalServerError(dao.ErrCommit, err)
+	// ------------------------------------------------------------------------- revoke session
+	if isRolesChanged(oldRoles, roles) {
+		sqlStatement, args, err = u.sb.Update(keyRefreshTokenTable).
+			Set(keyRevokedAt, updatedAt).
+			Where(squirrel.Eq{
+				keyUsersID:   userID,
+				keyRevokedAt: 0,
+			}).
+			ToSql()
+		if err != nil {
+			return rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+		}
+
+		_, err = trx.Exec(ctx, sqlStatement, args...)
+		if err != nil {
+			logger.Error("error saat trx exec refreshTokens(Revoke:0)", err)
+			return sql_err.ParseError(err)
+		}
 	}
 
-	user.Roles = input.Roles
+	return nil
+}
+
+// EditProfile merubah data profile user tanpa merubah role
+func (u *userDao) EditProfile(ctx context.Context, input dto.User) (*dto.User, rest_err.APIError) {
+	sqlStatement, args, err := u.sb.Update(keyUserTable).
+		SetMap(squirrel.Eq{
+			keyEmail:     input.Email,
+			keyName:      input.Name,
+			keyPosition:  input.Position,
+			keyUpdatedAt: input.UpdatedAt,
+		}).
+		Where(squirrel.Eq{
+			keyID: input.ID,
+		}).
+		Suffix(dao.Returning(keyID, keyEmail, keyName, keyPosition, keyCreatedAt, keyUpdatedAt)).
+		ToSql()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError(dao.ErrSqlBuilder, err)
+	}
+
+	var user dto.User
+	err = u.db.QueryRow(ctx, sqlStatement, args...).
+		Scan(&user.ID, &user.Email, &user.Name, &user.Position, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, rest_err.NewBadRequestError(fmt.Sprintf("User dengan username %d tidak ditemukan", input.ID))
+		}
+		return nil, sql_err.ParseError(err)
+	}
+
+	roleUserMap, apiErr := u.findRoleForUsers(ctx, []int{user.ID})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	user.Roles = roleUserMap[user.ID]
+	user.Prepare()
 
 	return &user, nil
 }
@@ -182,7 +308,7 @@ func (u *userDao) ChangePassword(ctx context.Context, input dto.User) rest_err.A
 			keyPassword:  input.Password,
 			keyUpdatedAt: input.UpdatedAt,
 		}).
-		Where(keyID, input.ID).
+		Where(squirrel.Eq{keyID: input.ID}).
 		ToSql()
 
 	if err != nil {
@@ -211,6 +337,11 @@ func (u *userDao) Delete(ctx context.Context, id int) rest_err.APIError {
 
 	res, err := db.DB.Exec(ctx, sqlStatement, args...)
 	if err != nil {
+		// document dan penandatangan tetap menyimpan user sebagai riwayat sehingga penghapusan ditolak
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return rest_err.NewBadRequestError(fmt.Sprintf("User %d masih menjadi pembuat atau penandatangan document sehingga tidak dapat dihapus", id))
+		}
 		return rest_err.NewInternalServerError("gagal saat penghapusan user", err)
 	}
 
@@ -222,21 +353,33 @@ func (u *userDao) Delete(ctx context.Context, id int) rest_err.APIError {
 }
 
 func (u *userDao) Get(ctx context.Context, id int) (*dto.User, rest_err.APIError) {
+	return u.getWhere(ctx, squirrel.Eq{
+		dao.A(keyID): id,
+	})
+}
+
+// GetByEmail mencari user berdasarkan email tanpa membedakan huruf besar dan kecil,
+// sama seperti Get user kosong dikembalikan apabila tidak ditemukan
+func (u *userDao) GetByEmail(ctx context.Context, email string) (*dto.User, rest_err.APIError) {
+	return u.getWhere(ctx, squirrel.Expr("LOWER("+dao.A(keyEmail)+") = LOWER(?)", strings.TrimSpace(email)))
+}
+
+func (u *userDao) getWhere(ctx context.Context, where squirrel.Sqlizer) (*dto.User, rest_err.APIError) {
 	sqlStatement, args, err := u.sb.Select(
 		dao.B(keyRolesName),
 		dao.A(keyID),
 		dao.A(keyEmail),
 		dao.A(keyName),
+		dao.A(keyPosition),
 		dao.A(keyPassword),
+		dao.A(keyService),
 		dao.A(keyCreatedAt),
 		dao.A(keyUpdatedAt),
 	).
 		Distinct().
 		From(keyUserTable + " A").
 		Join(keyUsersRolesTable + " B ON A.id = B.users_id").
-		Where(squirrel.Eq{
-			dao.A(keyID): id,
-		}).
+		Where(where).
 		ToSql()
 
 	if err != nil {
@@ -253,7 +396,7 @@ func (u *userDao) Get(ctx context.Context, id int) (*dto.User, rest_err.APIError
 	for rows.Next() {
 		user := dto.User{}
 		var roleName string
-		err := rows.Scan(&roleName, &user.ID, &user.Email, &user.Name, &user.Password, &user.CreatedAt, &user.UpdatedAt)
+		err := rows.Scan(&roleName, &user.ID, &user.Email, &user.Name, &user.Position, &user.Password, &user.ServiceAccount, &user.CreatedAt, &user.UpdatedAt)
 		if err != nil {
 			return nil, sql_err.ParseError(err)
 		}
@@ -261,7 +404,9 @@ func (u *userDao) Get(ctx context.Context, id int) (*dto.User, rest_err.APIError
 			userRes.ID = user.ID
 			userRes.Email = user.Email
 			userRes.Name = user.Name
+			userRes.Position = user.Position
 			userRes.Password = user.Password
+			userRes.ServiceAccount = user.ServiceAccount
 			userRes.UpdatedAt = user.UpdatedAt
 			userRes.CreatedAt = user.CreatedAt
 		}
@@ -279,7 +424,7 @@ func (u *userDao) Get(ctx context.Context, id int) (*dto.User, rest_err.APIError
 func (u *userDao) FindWithCursor(ctx context.Context, search string, limit uint64, cursor int) ([]dto.User, rest_err.APIError) {
 
 	// ------------------------------------------------------------------------- find user
-	sqlfrom := u.sb.Select(keyID, keyEmail, keyName, keyCreatedAt, keyUpdatedAt).
+	sqlfrom := u.sb.Select(keyID, keyEmail, keyName, keyPosition, keyService, keyCreatedAt, keyUpdatedAt).
 		From(keyUserTable)
 
 	// where
@@ -310,7 +455,7 @@ func (u *userDao) FindWithCursor(ctx context.Context, search string, limit uint6
 	users := make([]dto.User, 0)
 	for rows.Next() {
 		user := dto.User{}
-		err := rows.Scan(&user.ID, &user.Email, &user.Name, &user.CreatedAt, &user.UpdatedAt)
+		err := rows.Scan(&user.ID, &user.Email, &user.Name, &user.Position, &user.ServiceAccount, &user.CreatedAt, &user.UpdatedAt)
 		if err != nil {
 			return nil, sql_err.ParseError(err)
 		}
@@ -376,3 +521,20 @@ func (u *userDao) findRoleForUsers(ctx context.Context, idUsers []int) (map[int]
 
 	return roleNameMap, nil
 }
+
+// isRolesChanged return true jika himpunan role baru berbeda dengan role lama
+func isRolesChanged(oldRoles map[string]bool, newRoles []string) bool {
+	newSet := make(map[string]bool, len(newRoles))
+	for _, role := range newRoles {
+		newSet[role] = true
+	}
+	if len(newSet) != len(oldRoles) {
+		return true
+	}
+	for role := range newSet {
+		if !oldRoles[role] {
+			return true
+		}
+	}
+	return false
+}
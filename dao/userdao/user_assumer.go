@@ -15,10 +15,13 @@ type UserSaver interface {
 	Insert(ctx context.Context, user dto.User) (int, rest_err.APIError)
 	Edit(ctx context.Context, userInput dto.User) (*dto.User, rest_err.APIError)
 	Delete(ctx context.Context, id int) rest_err.APIError
+	EditProfile(ctx context.Context, input dto.User) (*dto.User, rest_err.APIError)
 	ChangePassword(ctx context.Context, input dto.User) rest_err.APIError
+	ChangeRoles(ctx context.Context, userID int, roles []string, updatedAt int64) rest_err.APIError
 }
 
 type UserReader interface {
 	Get(ctx context.Context, id int) (*dto.User, rest_err.APIError)
+	GetByEmail(ctx context.Context, email string) (*dto.User, rest_err.APIError)
 	FindWithCursor(ctx context.Context, search string, limit uint64, cursor int) ([]dto.User, rest_err.APIError)
 }
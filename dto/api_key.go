@@ -0,0 +1,73 @@
+package dto
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+// APIKey key milik service account, hanya hash sha256 dari key yang disimpan.
+// Scopes adalah role yang boleh digunakan key dan harus dimiliki service account.
+type APIKey struct {
+	ID         string   `json:"id" example:"Q2b0JqI1tqXG"`
+	UserID     int      `json:"user_id" example:"9001"`
+	Name       string   `json:"name" example:"integrasi arsip"`
+	KeyHash    string   `json:"-"`
+	Scopes     []string `json:"scopes" example:"NORMAL"`
+	ExpiresAt  int64    `json:"expires_at" example:"0"` // 0 berarti tidak pernah kadaluarsa
+	LastUsedAt int64    `json:"last_used_at" example:"1631341964"`
+	RevokedAt  int64    `json:"revoked_at" example:"0"`
+	CreatedBy  int      `json:"created_by" example:"1"`
+	CreatedAt  int64    `json:"created_at" example:"1631341964"`
+}
+
+func (a *APIKey) Prepare() {
+	if a.Scopes == nil {
+		a.Scopes = make([]string, 0)
+	}
+}
+
+// ServiceAccountRequest service account tidak memiliki password dan hanya dapat mengakses API menggunakan api key
+type ServiceAccountRequest struct {
+	ID       int      `json:"id" example:"9001"`
+	Email    string   `json:"email" example:"arsip@example.com"`
+	Name     string   `json:"name" example:"integrasi arsip"`
+	Position string   `json:"position" example:"Service Account"`
+	Roles    []string `json:"roles" example:"NORMAL"`
+}
+
+func (s ServiceAccountRequest) Validate() error {
+	if err := validation.ValidateStruct(&s,
+		validation.Field(&s.ID, validation.Required),
+		validation.Field(&s.Email, validation.Required, is.Email),
+		validation.Field(&s.Name, validation.Required),
+		validation.Field(&s.Position, validation.Length(0, 100)),
+		validation.Field(&s.Roles, validation.Required, validation.By(availableRoles)),
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+// APIKeyRequest apabila ExpiresInDays bernilai 0 maka key tidak pernah kadaluarsa
+type APIKeyRequest struct {
+	Name          string   `json:"name" example:"integrasi arsip"`
+	Scopes        []string `json:"scopes" example:"NORMAL"`
+	ExpiresInDays int      `json:"expires_in_days" example:"365"`
+}
+
+func (a APIKeyRequest) Validate() error {
+	if err := validation.ValidateStruct(&a,
+		validation.Field(&a.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&a.Scopes, validation.Required, validation.By(availableRoles)),
+		validation.Field(&a.ExpiresInDays, validation.Min(0), validation.Max(3650)),
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+// APIKeyResponse Key hanya ditampilkan sekali saat dibuat dan tidak dapat ditampilkan kembali
+type APIKeyResponse struct {
+	APIKey
+	Key string `json:"key" example:"ba_Q2b0JqI1tqXG.Vh3F0YxbW7Q2b0JqI1tqXG5l0x8kz9w6S3mF2a1b4cE"`
+}
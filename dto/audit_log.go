@@ -0,0 +1,29 @@
+package dto
+
+// Aksi yang dicatat pada audit log
+const (
+	AuditLoginFailed          = "LOGIN_FAILED"
+	AuditLoginLocked          = "LOGIN_LOCKED"
+	AuditAccountUnlocked      = "ACCOUNT_UNLOCKED"
+	AuditPasswordReset        = "PASSWORD_RESET"
+	AuditAPIKeyCreated        = "API_KEY_CREATED"
+	AuditAPIKeyRevoked        = "API_KEY_REVOKED"
+	AuditSessionTerminated    = "SESSION_TERMINATED"
+	AuditOIDCLinked           = "OIDC_LINKED"
+	AuditOIDCProvisioned      = "OIDC_PROVISIONED"
+	AuditRolesSynced          = "ROLES_SYNCED"
+	AuditImpersonationStarted = "IMPERSONATION_STARTED"
+	AuditImpersonatedRequest  = "IMPERSONATED_REQUEST"
+)
+
+// AuditLog catatan kejadian keamanan. UserID adalah user yang terdampak (bisa berupa user yang tidak
+// terdaftar saat login gagal), ActorID adalah user yang melakukan aksi, 0 jika dilakukan oleh sistem.
+type AuditLog struct {
+	ID        int
+	Action    string
+	UserID    int
+	ActorID   int
+	IP        string
+	Detail    string
+	CreatedAt int64
+}
@@ -0,0 +1,9 @@
+package dto
+
+// DeniedToken access token (jti) yang ditolak sebelum waktu kadaluarsanya, misalnya karena logout
+type DeniedToken struct {
+	TokenID   string
+	UserID    int
+	ExpiresAt int64
+	CreatedAt int64
+}
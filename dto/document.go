@@ -0,0 +1,139 @@
+package dto
+
+import (
+	"errors"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type Document struct {
+	ID          int             `json:"id" example:"1"`
+	Title       string          `json:"title" example:"Serah terima laptop"`
+	Type        string          `json:"type" example:"SERAH_TERIMA"`
+	Unit        string          `json:"unit" example:"IT"`
+	Number      string          `json:"number" example:"001/BA/IT/X/2026"` // kosong selama draft
+	Body        string          `json:"body" example:"Pada hari ini telah dilakukan serah terima ..."`
+	Status      string          `json:"status" example:"draft"`
+	Creator     int             `json:"creator" example:"1"`
+	CreatorName UppercaseString `json:"creator_name" example:"MUCHLIS"`
+	VerifyToken string          `json:"verify_token" example:"bq2p0sM4b2kKk8Zr3dRj9nHkO4YwB7xv1Q2nq5mVwzE"`
+	Signatories []Signatory     `json:"signatories"`
+	Letterhead  *Letterhead     `json:"-"` // nil sebelum permintaan tanda tangan pertama
+	CreatedAt   int64           `json:"created_at" example:"1631341964"`
+	UpdatedAt   int64           `json:"updated_at" example:"1631341964"`
+}
+
+// Letterhead kop surat yang disalin dari BA_LETTERHEAD_* saat permintaan tanda tangan dibuat
+// agar pdf yang disusun ulang saat verifikasi tidak berubah meskipun konfigurasi berubah
+type Letterhead struct {
+	Title   string
+	Address string
+}
+
+type DocumentRequest struct {
+	Title string `json:"title" example:"Serah terima laptop"`
+	Type  string `json:"type" example:"SERAH_TERIMA"`
+	Unit  string `json:"unit" example:"IT"`
+	Body  string `json:"body" example:"Pada hari ini telah dilakukan serah terima ..."`
+}
+
+func (d DocumentRequest) Validate() error {
+	if err := validation.ValidateStruct(&d,
+		validation.Field(&d.Title, validation.Required, validation.Length(1, 255)),
+		validation.Field(&d.Type, validation.Required, validation.Length(1, 50)),
+		validation.Field(&d.Unit, validation.Required, validation.Length(1, 20), validation.By(validUnit)),
+		validation.Field(&d.Body, validation.Required),
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DocumentFilter digunakan untuk pencarian document, field yang kosong diabaikan
+type DocumentFilter struct {
+	Search  string
+	Type    string
+	Unit    string
+	Status  string
+	Creator int
+}
+
+// Signatory penandatangan document, nama dan jabatan disalin dari user saat permintaan
+// tanda tangan dibuat agar tampilan document tidak berubah meskipun profile user berubah
+type Signatory struct {
+	ID         int             `json:"id" example:"1"`
+	DocumentID int             `json:"document_id" example:"1"`
+	UserID     int             `json:"user_id" example:"2"`
+	Name       UppercaseString `json:"name" example:"MUCHLIS"`
+	Position   string          `json:"position" example:"Kepala Unit IT"`
+	Order      int             `json:"order" example:"1"`
+	Status     string          `json:"status" example:"pending"`
+	Note       string          `json:"note" example:""`
+	SignedAt   int64           `json:"signed_at" example:"0"` // waktu tanda tangan atau penolakan
+	Signature  []byte          `json:"-"`
+	DocHash    string          `json:"doc_hash" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	CreatedAt  int64           `json:"created_at" example:"1631341964"`
+	UpdatedAt  int64           `json:"updated_at" example:"1631341964"`
+}
+
+// SignatureRequest meminta tanda tangan kepada user_ids, jika sequential maka
+// penandatanganan harus berurutan sesuai urutan user_ids, selain itu bebas urutan
+type SignatureRequest struct {
+	Sequential bool  `json:"sequential" example:"true"`
+	UserIDs    []int `json:"user_ids" example:"2,3,4"`
+}
+
+func (s SignatureRequest) Validate() error {
+	if err := validation.ValidateStruct(&s,
+		validation.Field(&s.UserIDs, validation.Required, validation.By(uniqueInts)),
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+type SignatureRejectRequest struct {
+	Note string `json:"note" example:"jumlah barang tidak sesuai"`
+}
+
+func (s SignatureRejectRequest) Validate() error {
+	if err := validation.ValidateStruct(&s,
+		validation.Field(&s.Note, validation.Required),
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+func uniqueInts(value interface{}) error {
+	numbers, _ := value.([]int)
+	seen := make(map[int]bool, len(numbers))
+	for _, number := range numbers {
+		if seen[number] {
+			return errors.New("tidak boleh berisi nilai yang sama")
+		}
+		seen[number] = true
+	}
+	return nil
+}
+
+// DocumentVerification hasil pemeriksaan seluruh tanda tangan terhadap pdf document saat ini
+type DocumentVerification struct {
+	DocumentID int                     `json:"document_id" example:"1"`
+	Number     string                  `json:"number" example:"001/BA/IT/X/2026"`
+	Title      string                  `json:"title" example:"Serah terima laptop"`
+	Type       string                  `json:"type" example:"SERAH_TERIMA"`
+	Status     string                  `json:"status" example:"signed"`
+	Hash       string                  `json:"hash" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	Valid      bool                    `json:"valid" example:"true"`
+	Signatures []SignatureVerification `json:"signatures"`
+}
+
+type SignatureVerification struct {
+	SignatoryID int             `json:"signatory_id" example:"1"`
+	Name        UppercaseString `json:"name" example:"MUCHLIS"`
+	Position    string          `json:"position" example:"Kepala Unit IT"`
+	SignedAt    int64           `json:"signed_at" example:"1631341964"`
+	HashMatch   bool            `json:"hash_match" example:"true"`
+	Valid       bool            `json:"valid" example:"true"`
+	Message     string          `json:"message" example:""`
+}
@@ -0,0 +1,59 @@
+package dto
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// UserMFA secret TOTP user, Secret dalam bentuk terenkripsi
+type UserMFA struct {
+	UserID       int
+	Secret       []byte
+	Enabled      bool
+	LastUsedStep int64 // step TOTP terakhir yang diterima, code dengan step yang sama atau lebih lama ditolak
+	CreatedAt    int64
+	UpdatedAt    int64
+}
+
+// MFAEnrollResponse ditampilkan sekali saat enroll untuk didaftarkan ke aplikasi authenticator
+type MFAEnrollResponse struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	URI    string `json:"uri" example:"otpauth://totp/Berita%20Acara:example@example.com?algorithm=SHA1&digits=6&issuer=Berita%20Acara&period=30&secret=JBSWY3DPEHPK3PXP"`
+	QRCode string `json:"qr_code" example:"data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAA..."`
+}
+
+// MFAEnableResponse recovery code hanya ditampilkan sekali. Login berisi token apabila
+// MFA diaktifkan menggunakan mfa_token hasil login (pendaftaran wajib).
+type MFAEnableResponse struct {
+	RecoveryCodes []string           `json:"recovery_codes" example:"abcd-efgh-ijkl-mnop,qrst-uvwx-yz23-4567"`
+	Login         *UserLoginResponse `json:"login,omitempty"`
+}
+
+// MFACodeRequest code berisi 6 digit code TOTP atau recovery code
+type MFACodeRequest struct {
+	Code string `json:"code" example:"123456"`
+}
+
+func (m MFACodeRequest) Validate() error {
+	if err := validation.ValidateStruct(&m,
+		validation.Field(&m.Code, validation.Required, validation.Length(6, 30)),
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+// MFALoginRequest langkah kedua login, mfa_token didapat dari response login
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" example:"eyJhbGciOiJIUzI1N.ywibmFtZSI6IkR5cGUiOjB9.aFjz4esDQ4-_K3dMUmo"`
+	Code     string `json:"code" example:"123456"`
+}
+
+func (m MFALoginRequest) Validate() error {
+	if err := validation.ValidateStruct(&m,
+		validation.Field(&m.MFAToken, validation.Required),
+		validation.Field(&m.Code, validation.Required, validation.Length(6, 30)),
+	); err != nil {
+		return err
+	}
+	return nil
+}
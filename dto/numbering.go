@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"errors"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/muchlist/berita_acara/utils/mnumber"
+	"strings"
+)
+
+// NumberingScheme pola nomor surat sebuah unit, contoh pattern : {seq:3}/BA/{unit}/{month_roman}/{year}
+type NumberingScheme struct {
+	Unit      string `json:"unit" example:"IT"`
+	Pattern   string `json:"pattern" example:"{seq:3}/BA/{unit}/{month_roman}/{year}"`
+	CreatedAt int64  `json:"created_at" example:"1631341964"`
+	UpdatedAt int64  `json:"updated_at" example:"1631341964"`
+}
+
+type NumberingSchemeRequest struct {
+	Pattern string `json:"pattern" example:"{seq:3}/BA/{unit}/{month_roman}/{year}"`
+}
+
+func (n NumberingSchemeRequest) Validate() error {
+	if err := validation.ValidateStruct(&n,
+		validation.Field(&n.Pattern, validation.Required, validation.Length(1, 100), validation.By(validPattern)),
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+func validPattern(value interface{}) error {
+	pattern, _ := value.(string)
+	return mnumber.ValidatePattern(pattern)
+}
+
+func validUnit(value interface{}) error {
+	unit, _ := value.(string)
+	if unit != "" && !mnumber.IsValidUnit(strings.ToUpper(unit)) {
+		return errors.New("hanya boleh berisi huruf besar, angka, - dan _")
+	}
+	return nil
+}
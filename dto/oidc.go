@@ -0,0 +1,18 @@
+package dto
+
+// OIDCState data login OIDC yang disimpan sampai callback, hanya hash sha256 dari state yang disimpan
+type OIDCState struct {
+	StateHash    string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    int64
+	CreatedAt    int64
+}
+
+// UserIdentity menghubungkan subject pada identity provider dengan user
+type UserIdentity struct {
+	Issuer    string
+	Subject   string
+	UserID    int
+	CreatedAt int64
+}
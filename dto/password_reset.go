@@ -0,0 +1,44 @@
+package dto
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+// PasswordReset token reset password, hanya hash sha256 dari token yang disimpan
+type PasswordReset struct {
+	TokenHash string
+	UserID    int
+	ExpiresAt int64
+	UsedAt    int64
+	CreatedAt int64
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" example:"example@example.com"`
+}
+
+func (f ForgotPasswordRequest) Validate() error {
+	if err := validation.ValidateStruct(&f,
+		validation.Field(&f.Email, validation.Required, is.Email),
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ResetPasswordRequest token didapat dari email reset password
+type ResetPasswordRequest struct {
+	Token       string `json:"token" example:"Vh3F0YxbW7Q2b0JqI1tqXG5l0x8kz9w6S3mF2a1b4cE"`
+	NewPassword string `json:"new_password" example:"password456"`
+}
+
+func (r ResetPasswordRequest) Validate() error {
+	if err := validation.ValidateStruct(&r,
+		validation.Field(&r.Token, validation.Required),
+		validation.Field(&r.NewPassword, validation.Required, validation.Length(3, 20)),
+	); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package dto
+
+// RefreshToken refresh token yang tersimpan di server. Setiap rotasi menghasilkan token baru dengan
+// family yang sama, token lama ditandai revoked dan replaced_by berisi token penggantinya.
+type RefreshToken struct {
+	TokenID    string
+	FamilyID   string
+	UserID     int
+	ExpiresAt  int64
+	RevokedAt  int64
+	ReplacedBy string
+	CreatedAt  int64
+}
@@ -0,0 +1,69 @@
+package dto
+
+import (
+	"fmt"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/muchlist/berita_acara/configs/roles"
+	"regexp"
+)
+
+// roleNamePattern nama role huruf besar, contoh : ADMIN, KEPALA_UNIT
+var roleNamePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// permissionPattern nama permission huruf kecil dengan format resource:action, contoh : document:sign
+var permissionPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*:[a-z][a-z0-9_]*$`)
+
+type Role struct {
+	Name        string   `json:"name" example:"KEPALA_UNIT"`
+	Permissions []string `json:"permissions" example:"document:sign,template:write"`
+	CreatedAt   int64    `json:"created_at" example:"1631341964"`
+	UpdatedAt   int64    `json:"updated_at" example:"1631341964"`
+}
+
+func (r *Role) Prepare() {
+	if r.Permissions == nil {
+		r.Permissions = make([]string, 0)
+	}
+}
+
+// RoleRequest digunakan untuk membuat maupun merubah nama role
+type RoleRequest struct {
+	Name string `json:"name" example:"KEPALA_UNIT"`
+}
+
+func (r RoleRequest) Validate() error {
+	if err := validation.ValidateStruct(&r,
+		validation.Field(&r.Name, validation.Required, validation.Length(1, 20),
+			validation.Match(roleNamePattern).Error("hanya boleh berisi huruf besar, angka dan _")),
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+type RolePermissionRequest struct {
+	Permissions []string `json:"permissions" example:"document:sign,template:write"`
+}
+
+func (r RolePermissionRequest) Validate() error {
+	if err := validation.ValidateStruct(&r,
+		validation.Field(&r.Permissions, validation.Required, validation.Each(
+			validation.Required, validation.Length(1, 50),
+			validation.Match(permissionPattern).Error("harus berformat resource:action berisi huruf kecil, angka dan _"),
+		)),
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+// availableRoles memastikan setiap role terdapat pada daftar role di database
+func availableRoles(value interface{}) error {
+	names, _ := value.([]string)
+	for _, name := range names {
+		if !roles.IsAvailable(name) {
+			return fmt.Errorf("role %s tidak tersedia", name)
+		}
+	}
+	return nil
+}
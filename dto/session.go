@@ -0,0 +1,12 @@
+package dto
+
+// Session sesi login, ID sama dengan family refresh token dan claim sid pada access token
+type Session struct {
+	ID            string `json:"id" example:"Vh3F0YxbW7Q2b0JqI1tqXG"`
+	UserID        int    `json:"user_id" example:"1"`
+	UserAgent     string `json:"user_agent" example:"Mozilla/5.0 (X11; Linux x86_64)"`
+	IP            string `json:"ip" example:"10.0.0.12"`
+	LastRefreshAt int64  `json:"last_refresh_at" example:"1631345564"` // 0 jika belum pernah refresh
+	CreatedAt     int64  `json:"created_at" example:"1631341964"`
+	Current       bool   `json:"current" example:"true"` // sesi yang sedang digunakan untuk request ini
+}
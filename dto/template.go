@@ -0,0 +1,114 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/muchlist/berita_acara/utils/mtemplate"
+)
+
+type Template struct {
+	ID        int             `json:"id" example:"1"`
+	Name      string          `json:"name" example:"Serah terima barang"`
+	Type      string          `json:"type" example:"SERAH_TERIMA"`
+	Title     string          `json:"title" example:"Serah terima {{nama_barang}}"`
+	Body      string          `json:"body" example:"Pada hari ini {{nama_pihak_pertama}} menyerahkan {{nama_barang}} ..."`
+	Fields    []TemplateField `json:"fields"`
+	Creator   int             `json:"creator" example:"1"`
+	CreatedAt int64           `json:"created_at" example:"1631341964"`
+	UpdatedAt int64           `json:"updated_at" example:"1631341964"`
+}
+
+// TemplateField field yang dideklarasikan pada template, nama field digunakan sebagai placeholder {{name}}
+type TemplateField struct {
+	Name     string `json:"name" example:"nama_pihak_pertama"`
+	Label    string `json:"label" example:"Nama Pihak Pertama"`
+	Required bool   `json:"required" example:"true"`
+}
+
+func (f TemplateField) Validate() error {
+	if err := validation.ValidateStruct(&f,
+		validation.Field(&f.Name, validation.Required, validation.By(validFieldName)),
+		validation.Field(&f.Label, validation.Required),
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+type TemplateRequest struct {
+	Name   string          `json:"name" example:"Serah terima barang"`
+	Type   string          `json:"type" example:"SERAH_TERIMA"`
+	Title  string          `json:"title" example:"Serah terima {{nama_barang}}"`
+	Body   string          `json:"body" example:"Pada hari ini {{nama_pihak_pertama}} menyerahkan {{nama_barang}} ..."`
+	Fields []TemplateField `json:"fields"`
+}
+
+func (t TemplateRequest) Validate() error {
+	if err := validation.ValidateStruct(&t,
+		validation.Field(&t.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&t.Type, validation.Required, validation.Length(1, 50)),
+		validation.Field(&t.Title, validation.Required, validation.Length(1, 255)),
+		validation.Field(&t.Body, validation.Required),
+		validation.Field(&t.Fields, validation.NotNil),
+	); err != nil {
+		return err
+	}
+
+	// seluruh placeholder yang dipakai wajib dideklarasikan pada fields
+	declared := make(map[string]bool, len(t.Fields))
+	for _, field := range t.Fields {
+		if declared[field.Name] {
+			return fmt.Errorf("fields: field %s dideklarasikan lebih dari sekali", field.Name)
+		}
+		declared[field.Name] = true
+	}
+	for _, name := range mtemplate.Placeholders(t.Title + "\n" + t.Body) {
+		if !declared[name] {
+			return fmt.Errorf("fields: placeholder {{%s}} belum dideklarasikan", name)
+		}
+	}
+	return nil
+}
+
+// TemplateRenderRequest berisi nilai untuk setiap placeholder, key adalah nama field
+type TemplateRenderRequest struct {
+	Values map[string]string `json:"values"`
+}
+
+// Validate memvalidasi values terhadap field yang dideklarasikan template
+func (r TemplateRenderRequest) Validate(fields []TemplateField) error {
+	keys := make([]*validation.KeyRules, len(fields))
+	for i, field := range fields {
+		if field.Required {
+			keys[i] = validation.Key(field.Name, validation.Required)
+		} else {
+			keys[i] = validation.Key(field.Name).Optional()
+		}
+	}
+	// map nil tidak akan diperiksa oleh validation.Map sehingga field required dapat terlewat
+	values := r.Values
+	if values == nil {
+		values = map[string]string{}
+	}
+	if err := validation.Validate(values, validation.Map(keys...).AllowExtraKeys()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// TemplateRenderResponse hasil pengisian template, dapat langsung dikirim sebagai dto.DocumentRequest
+type TemplateRenderResponse struct {
+	TemplateID int    `json:"template_id" example:"1"`
+	Title      string `json:"title" example:"Serah terima laptop"`
+	Type       string `json:"type" example:"SERAH_TERIMA"`
+	Body       string `json:"body" example:"Pada hari ini MUCHLIS menyerahkan laptop ..."`
+}
+
+func validFieldName(value interface{}) error {
+	name, _ := value.(string)
+	if !mtemplate.IsValidFieldName(name) {
+		return errors.New("hanya boleh berisi huruf, angka dan underscore")
+	}
+	return nil
+}
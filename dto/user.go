@@ -6,13 +6,15 @@ import (
 )
 
 type User struct {
-	ID        int             `json:"id" example:"1"`
-	Email     string          `json:"email" example:"example@example.com"`
-	Name      UppercaseString `json:"name" example:"muchlis"`
-	Password  string          `json:"-"`
-	Roles     []string        `json:"roles" example:"ADMIN,NORMAL"`
-	CreatedAt int64           `json:"created_at" example:"1631341964"`
-	UpdatedAt int64           `json:"updated_at" example:"1631341964"`
+	ID             int             `json:"id" example:"1"`
+	Email          string          `json:"email" example:"example@example.com"`
+	Name           UppercaseString `json:"name" example:"muchlis"`
+	Position       string          `json:"position" example:"Kepala Unit IT"`
+	Password       string          `json:"-"`
+	Roles          []string        `json:"roles" example:"ADMIN,NORMAL"`
+	ServiceAccount bool            `json:"service_account" example:"false"` // hanya dapat mengakses API menggunakan api key
+	CreatedAt      int64           `json:"created_at" example:"1631341964"`
+	UpdatedAt      int64           `json:"updated_at" example:"1631341964"`
 }
 
 func (u *User) Prepare() {
@@ -25,6 +27,7 @@ type UserRegisterReq struct {
 	ID       int      `json:"id" example:"1"`
 	Email    string   `json:"email" example:"example@example.com"`
 	Name     string   `json:"name" example:"muchlis"`
+	Position string   `json:"position" example:"Kepala Unit IT"`
 	Password string   `json:"password" example:"password123"`
 	Roles    []string `json:"roles" example:"ADMIN,NORMAL,BASIC"`
 }
@@ -34,7 +37,8 @@ func (u UserRegisterReq) Validate() error {
 		validation.Field(&u.ID, validation.Required),
 		validation.Field(&u.Email, validation.Required, is.Email),
 		validation.Field(&u.Name, validation.Required),
-		validation.Field(&u.Roles, validation.NotNil),
+		validation.Field(&u.Position, validation.Length(0, 100)),
+		validation.Field(&u.Roles, validation.NotNil, validation.By(availableRoles)),
 		validation.Field(&u.Password, validation.Required, validation.Length(3, 20)),
 	); err != nil {
 		return err
@@ -43,37 +47,92 @@ func (u UserRegisterReq) Validate() error {
 }
 
 type UserEditRequest struct {
-	Email string   `json:"email" example:"example@example.com"`
-	Name  string   `json:"name" example:"muchlis"`
-	Roles []string `json:"roles" example:"ADMIN,NORMAL"`
+	Email    string   `json:"email" example:"example@example.com"`
+	Name     string   `json:"name" example:"muchlis"`
+	Position string   `json:"position" example:"Kepala Unit IT"`
+	Roles    []string `json:"roles" example:"ADMIN,NORMAL"`
 }
 
 func (u UserEditRequest) Validate() error {
 	if err := validation.ValidateStruct(&u,
 		validation.Field(&u.Email, validation.Required, is.Email),
 		validation.Field(&u.Name, validation.Required),
-		validation.Field(&u.Roles, validation.NotNil),
+		validation.Field(&u.Position, validation.Length(0, 100)),
+		validation.Field(&u.Roles, validation.NotNil, validation.By(availableRoles)),
 	); err != nil {
 		return err
 	}
 	return nil
 }
 
-// UserLoginResponse balikan user ketika sukses login dengan tambahan AccessToken
+// UserProfileEditRequest perubahan profile oleh user itu sendiri, role tidak dapat diubah
+type UserProfileEditRequest struct {
+	Email    string `json:"email" example:"example@example.com"`
+	Name     string `json:"name" example:"muchlis"`
+	Position string `json:"position" example:"Kepala Unit IT"`
+}
+
+func (u UserProfileEditRequest) Validate() error {
+	if err := validation.ValidateStruct(&u,
+		validation.Field(&u.Email, validation.Required, is.Email),
+		validation.Field(&u.Name, validation.Required),
+		validation.Field(&u.Position, validation.Length(0, 100)),
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+type UserChangePasswordRequest struct {
+	OldPassword string `json:"old_password" example:"password123"`
+	NewPassword string `json:"new_password" example:"password456"`
+}
+
+func (u UserChangePasswordRequest) Validate() error {
+	if err := validation.ValidateStruct(&u,
+		validation.Field(&u.OldPassword, validation.Required),
+		validation.Field(&u.NewPassword, validation.Required, validation.Length(3, 20),
+			validation.NotIn(u.OldPassword).Error("tidak boleh sama dengan password lama")),
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UserLoginRequest login menggunakan user_id atau email (tidak membedakan huruf besar dan kecil),
+// apabila keduanya diisi maka email yang digunakan
 type UserLoginRequest struct {
-	UserID   int    `json:"user_id"`
-	Password string `json:"password"`
+	UserID   int    `json:"user_id" example:"1"`
+	Email    string `json:"email" example:"example@example.com"`
+	Password string `json:"password" example:"password123"`
 }
 
 // UserLoginResponse balikan user ketika sukses login dengan tambahan AccessToken
 type UserLoginResponse struct {
-	ID           int      `json:"id" example:"1"`
-	Email        string   `json:"email" example:"example@example.com"`
-	Name         string   `json:"name" example:"muchlis"`
-	Roles        []string `json:"roles" example:"ADMIN,NORMAL,BASIC"`
-	AccessToken  string   `json:"access_token" example:"eyJhbGciOiJIUzI1N.ywibmFtZSI6IkR5cGUiOjB9.aFjz4esDQ4-_K3dMUmo"`
-	RefreshToken string   `json:"refresh_token" example:"eyJhbGciOiJIUzI1N.ywibmFtZSI6IkR5cGUiOjB9.aFjz4esDQ4-_K3dMUmo"`
-	Expired      int64    `json:"expired" example:"1631341964"`
+	ID             int      `json:"id" example:"1"`
+	Email          string   `json:"email" example:"example@example.com"`
+	Name           string   `json:"name" example:"muchlis"`
+	Roles          []string `json:"roles" example:"ADMIN,NORMAL,BASIC"`
+	AccessToken    string   `json:"access_token" example:"eyJhbGciOiJIUzI1N.ywibmFtZSI6IkR5cGUiOjB9.aFjz4esDQ4-_K3dMUmo"`
+	RefreshToken   string   `json:"refresh_token" example:"eyJhbGciOiJIUzI1N.ywibmFtZSI6IkR5cGUiOjB9.aFjz4esDQ4-_K3dMUmo"`
+	Expired        int64    `json:"expired" example:"1631341964"`         // expired access token
+	RefreshExpired int64    `json:"refresh_expired" example:"1632205964"` // expired refresh token
+
+	// diisi apabila login memerlukan langkah kedua, access token dan refresh token kosong
+	MFARequired           bool   `json:"mfa_required" example:"false"`
+	MFAEnrollmentRequired bool   `json:"mfa_enrollment_required" example:"false"`
+	MFAToken              string `json:"mfa_token,omitempty" example:""`
+}
+
+// ImpersonateResponse access token atas nama user tanpa refresh token, ActorID adalah admin yang melakukan impersonation
+type ImpersonateResponse struct {
+	ID          int      `json:"id" example:"7"`
+	Email       string   `json:"email" example:"example@example.com"`
+	Name        string   `json:"name" example:"muchlis"`
+	Roles       []string `json:"roles" example:"NORMAL"`
+	ActorID     int      `json:"actor_id" example:"1"`
+	AccessToken string   `json:"access_token" example:"eyJhbGciOiJIUzI1N.ywibmFtZSI6IkR5cGUiOjB9.aFjz4esDQ4-_K3dMUmo"`
+	Expired     int64    `json:"expired" example:"1631341964"`
 }
 
 type UserRefreshTokenRequest struct {
@@ -81,8 +140,10 @@ type UserRefreshTokenRequest struct {
 }
 
 // UserRefreshTokenResponse mengembalikan token dengan claims yang
-// sama dengan token sebelumnya dengan expired yang baru
+// sama dengan token sebelumnya dengan expired yang baru, refresh token lama tidak berlaku lagi
 type UserRefreshTokenResponse struct {
-	AccessToken string `json:"access_token" example:"eyJhbGciOiJIUzI1N.ywibmFtZSI6IkR5cGUiOjB9.aFjz4esDQ4-_K3dMUmo"`
-	Expired     int64  `json:"expired" example:"1631341964"`
+	AccessToken    string `json:"access_token" example:"eyJhbGciOiJIUzI1N.ywibmFtZSI6IkR5cGUiOjB9.aFjz4esDQ4-_K3dMUmo"`
+	RefreshToken   string `json:"refresh_token" example:"eyJhbGciOiJIUzI1N.ywibmFtZSI6IkR5cGUiOjB9.aFjz4esDQ4-_K3dMUmo"`
+	Expired        int64  `json:"expired" example:"1631341964"`
+	RefreshExpired int64  `json:"refresh_expired" example:"1632205964"`
 }
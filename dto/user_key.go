@@ -0,0 +1,10 @@
+package dto
+
+// UserKey sertifikat X.509 user yang diterbitkan CA internal beserta private key terenkripsi
+type UserKey struct {
+	UserID      int
+	Certificate string
+	PrivateKey  []byte
+	NotAfter    int64
+	CreatedAt   int64
+}
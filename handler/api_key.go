@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/services/apikeyserv"
+	"github.com/muchlist/berita_acara/utils/mjwt"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"strconv"
+)
+
+func NewAPIKeyHandler(apiKeyService apikeyserv.APIKeyServiceAssumer) *APIKeyHandler {
+	return &APIKeyHandler{
+		service: apiKeyService,
+	}
+}
+
+type APIKeyHandler struct {
+	service apikeyserv.APIKeyServiceAssumer
+}
+
+// CreateServiceAccount menambahkan service account
+// @Summary create service account
+// @Description membuat user tanpa password yang hanya dapat mengakses API menggunakan api key
+// @ID service-account-create
+// @Accept json
+// @Produce json
+// @Tags API Key
+// @Security bearerAuth
+// @Param ReqBody body dto.ServiceAccountRequest true "Body raw JSON"
+// @Success 200 {object} payload.RespMsgExample
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /service-accounts [post]
+func (a *APIKeyHandler) CreateServiceAccount(c *fiber.Ctx) error {
+	var req dto.ServiceAccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	insertUserID, apiErr := a.service.CreateServiceAccount(c.Context(), req)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": fmt.Sprintf("Service account berhasil dibuat, ID: %d", insertUserID)})
+}
+
+// Create membuat api key untuk service account
+// @Summary create api key
+// @Description membuat api key untuk service account, key hanya ditampilkan sekali pada response ini
+// @ID api-key-create
+// @Accept json
+// @Produce json
+// @Tags API Key
+// @Security bearerAuth
+// @Param id path int true "User ID service account"
+// @Param ReqBody body dto.APIKeyRequest true "Body raw JSON"
+// @Success 200 {object} payload.RespWrap{data=dto.APIKeyResponse}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /users/{id}/api-keys [post]
+func (a *APIKeyHandler) Create(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	userID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		apiErr := rest_err.NewBadRequestError("kesalahan input, id harus berupa angka")
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	var req dto.APIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	response, apiErr := a.service.CreateAPIKey(c.Context(), userID, req, claims.Identity, c.IP())
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": response})
+}
+
+// Find menampilkan api key milik user
+// @Summary find api key
+// @Description menampilkan seluruh api key milik user termasuk yang sudah direvoke, key tidak ditampilkan
+// @ID api-key-find
+// @Accept json
+// @Produce json
+// @Tags API Key
+// @Security bearerAuth
+// @Param id path int true "User ID service account"
+// @Success 200 {object} payload.RespWrap{data=[]dto.APIKey}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /users/{id}/api-keys [get]
+func (a *APIKeyHandler) Find(c *fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		apiErr := rest_err.NewBadRequestError("kesalahan input, id harus berupa angka")
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	keys, apiErr := a.service.FindAPIKeys(c.Context(), userID)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": keys})
+}
+
+// Revoke menonaktifkan api key
+// @Summary revoke api key
+// @Description menonaktifkan api key, request berikutnya yang menggunakan key tersebut ditolak
+// @ID api-key-revoke
+// @Accept json
+// @Produce json
+// @Tags API Key
+// @Security bearerAuth
+// @Param id path string true "API Key ID"
+// @Success 200 {object} payload.RespMsgExample
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /api-keys/{id} [delete]
+func (a *APIKeyHandler) Revoke(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	keyID := c.Params("id")
+	apiErr := a.service.RevokeAPIKey(c.Context(), keyID, claims.Identity, c.IP())
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": fmt.Sprintf("API key %s berhasil direvoke", keyID)})
+}
@@ -0,0 +1,417 @@
+package handler
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/services/beritaacaraserv"
+	"github.com/muchlist/berita_acara/utils/mjwt"
+	"github.com/muchlist/berita_acara/utils/mpdf"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/muchlist/berita_acara/utils/sfunc"
+	"html/template"
+	"strconv"
+	"time"
+)
+
+//go:embed view/verify.html
+var verifyPageHTML string
+
+var verifyPage = template.Must(template.New("verify").Funcs(template.FuncMap{
+	"formatTime": func(unix int64) string {
+		return time.Unix(unix, 0).Format("02-01-2006 15:04:05 MST")
+	},
+}).Parse(verifyPageHTML))
+
+func NewDocumentHandler(documentService beritaacaraserv.DocumentServiceAssumer) *DocumentHandler {
+	return &DocumentHandler{
+		service: documentService,
+	}
+}
+
+type DocumentHandler struct {
+	service beritaacaraserv.DocumentServiceAssumer
+}
+
+// Insert menambahkan document
+// @Summary insert document
+// @Description membuat draft berita acara baru, pembuat diambil dari token
+// @ID document-insert
+// @Accept json
+// @Produce json
+// @Tags Document
+// @Security bearerAuth
+// @Param ReqBody body dto.DocumentRequest true "Body raw JSON"
+// @Success 200 {object} payload.RespMsgExample
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /documents [post]
+func (d *DocumentHandler) Insert(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	var req dto.DocumentRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	insertID, apiErr := d.service.InsertDocument(c.Context(), dto.Document{
+		Title:   req.Title,
+		Type:    req.Type,
+		Unit:    req.Unit,
+		Body:    req.Body,
+		Creator: claims.Identity,
+	})
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	res := fmt.Sprintf("Document berhasil dibuat, ID: %d", insertID)
+	return c.JSON(fiber.Map{"error": nil, "data": res})
+}
+
+// Edit
+// @Summary edit document
+// @Description melakukan perubahan data pada document, hanya pembuat dan selama berstatus draft
+// @ID document-edit
+// @Accept json
+// @Produce json
+// @Tags Document
+// @Security bearerAuth
+// @Param id path int true "Document ID"
+// @Param ReqBody body dto.DocumentRequest true "Body raw JSON"
+// @Success 200 {object} payload.RespWrap{data=dto.Document}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /documents/{id} [put]
+func (d *DocumentHandler) Edit(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	documentID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		apiErr := rest_err.NewBadRequestError("kesalahan input, id harus berupa angka")
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	var req dto.DocumentRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	documentEdited, apiErr := d.service.EditDocument(c.Context(), dto.Document{
+		ID:      documentID,
+		Title:   req.Title,
+		Type:    req.Type,
+		Unit:    req.Unit,
+		Body:    req.Body,
+		Creator: claims.Identity,
+	})
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": documentEdited})
+}
+
+// Delete menghapus document
+// @Summary delete document by ID
+// @Description menghapus document draft milik user yang sedang login
+// @ID document-delete
+// @Accept json
+// @Produce json
+// @Tags Document
+// @Security bearerAuth
+// @Param id path int true "Document ID"
+// @Success 200 {object} payload.RespMsgExample
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /documents/{id} [delete]
+func (d *DocumentHandler) Delete(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	documentID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		apiErr := rest_err.NewBadRequestError("kesalahan input, id harus berupa angka")
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	apiErr := d.service.DeleteDocument(c.Context(), documentID, claims.Identity)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": fmt.Sprintf("document %d berhasil dihapus", documentID)})
+}
+
+// Get menampilkan document berdasarkan ID
+// @Summary get document by ID
+// @Description menampilkan document berdasarkan ID
+// @ID document-get
+// @Accept json
+// @Produce json
+// @Tags Document
+// @Security bearerAuth
+// @Param id path int true "Document ID"
+// @Success 200 {object} payload.RespWrap{data=dto.Document}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /documents/{id} [get]
+func (d *DocumentHandler) Get(c *fiber.Ctx) error {
+	documentID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		apiErr := rest_err.NewBadRequestError("kesalahan input, id harus berupa angka")
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	document, apiErr := d.service.GetDocument(c.Context(), documentID)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": document})
+}
+
+// Find menampilkan list document
+// @Summary find document
+// @Description menampilkan daftar document tanpa body
+// @ID document-find
+// @Accept json
+// @Produce json
+// @Tags Document
+// @Security bearerAuth
+// @Param limit query int false "Limit"
+// @Param last_id query int false "Last ID sebagai cursor untuk page selanjutnya"
+// @Param search query string false "Search apabila di isi akan melakukan pencarian judul dan nomor surat"
+// @Param type query string false "Filter tipe document"
+// @Param unit query string false "Filter unit document"
+// @Param status query string false "Filter status document"
+// @Param creator query int false "Filter ID pembuat document"
+// @Success 200 {object} payload.RespWrap{data=[]dto.Document}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /documents [get]
+func (d *DocumentHandler) Find(c *fiber.Ctx) error {
+	limit := sfunc.StrToInt(c.Query("limit"), 10)
+	cursor := sfunc.StrToInt(c.Query("last_id"), 0)
+	filter := dto.DocumentFilter{
+		Search:  c.Query("search"),
+		Type:    c.Query("type"),
+		Unit:    c.Query("unit"),
+		Status:  c.Query("status"),
+		Creator: sfunc.StrToInt(c.Query("creator"), 0),
+	}
+
+	documentList, apiErr := d.service.FindDocuments(c.Context(), filter, limit, cursor)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if documentList == nil {
+		documentList = []dto.Document{}
+	}
+	return c.JSON(fiber.Map{"error": nil, "data": documentList})
+}
+
+// GetPDF menampilkan document dalam bentuk pdf
+// @Summary get document pdf
+// @Description menyusun document menjadi pdf berisi kop surat, judul, isi dan blok tanda tangan
+// @ID document-pdf
+// @Produce application/pdf
+// @Tags Document
+// @Security bearerAuth
+// @Param id path int true "Document ID"
+// @Success 200 {file} file
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /documents/{id}/pdf [get]
+func (d *DocumentHandler) GetPDF(c *fiber.Ctx) error {
+	documentID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		apiErr := rest_err.NewBadRequestError("kesalahan input, id harus berupa angka")
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	pdf, apiErr := d.service.RenderPDF(c.Context(), documentID)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	c.Set(fiber.HeaderContentType, mpdf.ContentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("inline; filename=\"berita-acara-%d.pdf\"", documentID))
+	return c.SendStream(bytes.NewReader(pdf), len(pdf))
+}
+
+// RequestSignature meminta tanda tangan
+// @Summary request signature
+// @Description meminta tanda tangan kepada user_ids, document berpindah dari draft menjadi waiting_signature.
+// @Description Jika sequential maka tanda tangan harus berurutan sesuai urutan user_ids.
+// @ID document-request-signature
+// @Accept json
+// @Produce json
+// @Tags Document
+// @Security bearerAuth
+// @Param id path int true "Document ID"
+// @Param ReqBody body dto.SignatureRequest true "Body raw JSON"
+// @Success 200 {object} payload.RespWrap{data=dto.Document}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /documents/{id}/signatories [post]
+func (d *DocumentHandler) RequestSignature(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	documentID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		apiErr := rest_err.NewBadRequestError("kesalahan input, id harus berupa angka")
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	var req dto.SignatureRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	document, apiErr := d.service.RequestSignature(c.Context(), documentID, claims.Identity, req)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": document})
+}
+
+// Sign menandatangani document
+// @Summary sign document
+// @Description menandatangani document oleh user yang login, memerlukan fresh token
+// @ID document-sign
+// @Accept json
+// @Produce json
+// @Tags Document
+// @Security bearerAuth
+// @Param id path int true "Document ID"
+// @Success 200 {object} payload.RespWrap{data=dto.Document}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /documents/{id}/sign [post]
+func (d *DocumentHandler) Sign(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	documentID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		apiErr := rest_err.NewBadRequestError("kesalahan input, id harus berupa angka")
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	document, apiErr := d.service.SignDocument(c.Context(), documentID, claims.Identity)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": document})
+}
+
+// Reject menolak document
+// @Summary reject document
+// @Description menolak document oleh user yang login, status document menjadi rejected
+// @ID document-reject
+// @Accept json
+// @Produce json
+// @Tags Document
+// @Security bearerAuth
+// @Param id path int true "Document ID"
+// @Param ReqBody body dto.SignatureRejectRequest true "Body raw JSON"
+// @Success 200 {object} payload.RespWrap{data=dto.Document}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /documents/{id}/reject [post]
+func (d *DocumentHandler) Reject(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	documentID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		apiErr := rest_err.NewBadRequestError("kesalahan input, id harus berupa angka")
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	var req dto.SignatureRejectRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	document, apiErr := d.service.RejectDocument(c.Context(), documentID, claims.Identity, req.Note)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": document})
+}
+
+// Verify memeriksa tanda tangan document
+// @Summary verify document signatures
+// @Description memeriksa setiap CMS signature yang tersimpan terhadap pdf document saat ini dan rantai sertifikat CA
+// @ID document-verify
+// @Accept json
+// @Produce json
+// @Tags Document
+// @Security bearerAuth
+// @Param id path int true "Document ID"
+// @Success 200 {object} payload.RespWrap{data=dto.DocumentVerification}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /documents/{id}/verify [get]
+func (d *DocumentHandler) Verify(c *fiber.Ctx) error {
+	documentID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		apiErr := rest_err.NewBadRequestError("kesalahan input, id harus berupa angka")
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	verification, apiErr := d.service.VerifyDocument(c.Context(), documentID)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": verification})
+}
+
+// VerifyPage halaman publik verifikasi keaslian document dari QR code pada pdf
+// tidak memerlukan token login, document dicari berdasarkan verify_token acak sehingga id tidak terekspos
+func (d *DocumentHandler) VerifyPage(c *fiber.Ctx) error {
+	data := fiber.Map{}
+	status := fiber.StatusOK
+
+	verification, apiErr := d.service.VerifyByToken(c.Context(), c.Params("token"))
+	if apiErr != nil {
+		status = apiErr.Status()
+		data["Error"] = apiErr.Message()
+	} else {
+		data["Verification"] = verification
+	}
+
+	var buf bytes.Buffer
+	if err := verifyPage.Execute(&buf, data); err != nil {
+		apiErr := rest_err.NewInternalServerError("gagal menyusun halaman verifikasi", err)
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Status(status).Send(buf.Bytes())
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/muchlist/berita_acara/utils/mjwt"
+)
+
+func NewJwksHandler(jwt mjwt.JWTAssumer) *JwksHandler {
+	return &JwksHandler{
+		jwt: jwt,
+	}
+}
+
+type JwksHandler struct {
+	jwt mjwt.JWTAssumer
+}
+
+// JWKS menampilkan public key untuk memverifikasi token yang diterbitkan aplikasi ini.
+// Format response mengikuti RFC 7517 (tidak dibungkus error/data) agar dapat dibaca library jwt lain,
+// daftar key kosong apabila token ditandatangani menggunakan HS256.
+func (j *JwksHandler) JWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(j.jwt.JWKS())
+}
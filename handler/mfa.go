@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/mjwt"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+// LoginMFA langkah kedua login
+// @Summary login mfa
+// @Description menukar mfa_token dari response login dan code TOTP atau recovery code dengan JWT Token
+// @ID user-login-mfa
+// @Accept json
+// @Produce json
+// @Tags Access
+// @Param ReqBody body dto.MFALoginRequest true "Body raw JSON"
+// @Success 200 {object} payload.RespWrap{data=dto.UserLoginResponse}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 429 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /login/mfa [post]
+func (u *UserHandler) LoginMFA(c *fiber.Ctx) error {
+	var req dto.MFALoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	response, apiErr := u.service.LoginMFA(c.Context(), req, c.IP(), c.Get(fiber.HeaderUserAgent))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": response})
+}
+
+// EnrollMFA membuat secret TOTP untuk user yang sedang login
+// @Summary enroll mfa
+// @Description membuat secret TOTP beserta QR code, MFA belum aktif sampai dikonfirmasi melalui /profile/mfa/enable. Memerlukan fresh token atau mfa_token
+// @ID user-mfa-enroll
+// @Accept json
+// @Produce json
+// @Tags Access
+// @Security bearerAuth
+// @Success 200 {object} payload.RespWrap{data=dto.MFAEnrollResponse}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /profile/mfa/enroll [post]
+func (u *UserHandler) EnrollMFA(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	response, apiErr := u.service.EnrollMFA(c.Context(), claims.Identity)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": response})
+}
+
+// EnableMFA mengaktifkan MFA user yang sedang login
+// @Summary enable mfa
+// @Description mengaktifkan MFA menggunakan code TOTP pertama dan menampilkan recovery code sekali. Memerlukan fresh token atau mfa_token
+// @ID user-mfa-enable
+// @Accept json
+// @Produce json
+// @Tags Access
+// @Security bearerAuth
+// @Param ReqBody body dto.MFACodeRequest true "Body raw JSON"
+// @Success 200 {object} payload.RespWrap{data=dto.MFAEnableResponse}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /profile/mfa/enable [post]
+func (u *UserHandler) EnableMFA(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	var req dto.MFACodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	response, apiErr := u.service.EnableMFA(c.Context(), *claims, req.Code, c.IP(), c.Get(fiber.HeaderUserAgent))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": response})
+}
+
+// DisableMFA menonaktifkan MFA user yang sedang login
+// @Summary disable mfa
+// @Description menonaktifkan MFA menggunakan code TOTP atau recovery code, memerlukan fresh token
+// @ID user-mfa-disable
+// @Accept json
+// @Produce json
+// @Tags Access
+// @Security bearerAuth
+// @Param ReqBody body dto.MFACodeRequest true "Body raw JSON"
+// @Success 200 {object} payload.RespMsgExample
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /profile/mfa/disable [post]
+func (u *UserHandler) DisableMFA(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	var req dto.MFACodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	apiErr := u.service.DisableMFA(c.Context(), *claims, req.Code)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": "MFA berhasil dinonaktifkan"})
+}
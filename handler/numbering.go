@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/services/numberingserv"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+func NewNumberingHandler(numberingService numberingserv.NumberingServiceAssumer) *NumberingHandler {
+	return &NumberingHandler{
+		service: numberingService,
+	}
+}
+
+type NumberingHandler struct {
+	service numberingserv.NumberingServiceAssumer
+}
+
+// Upsert membuat atau merubah skema penomoran unit
+// @Summary upsert numbering scheme
+// @Description menyimpan pola nomor surat unit. Token yang didukung : {seq} atau {seq:N} nomor urut dengan padding nol,
+// @Description {unit}, {type}, {month}, {month_roman} dan {year}. Nomor urut direset setiap tahun per unit sehingga {seq}, {unit} dan {year} wajib ada.
+// @ID numbering-upsert
+// @Accept json
+// @Produce json
+// @Tags Numbering
+// @Security bearerAuth
+// @Param unit path string true "Kode unit"
+// @Param ReqBody body dto.NumberingSchemeRequest true "Body raw JSON"
+// @Success 200 {object} payload.RespWrap{data=dto.NumberingScheme}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /numbering-schemes/{unit} [put]
+func (n *NumberingHandler) Upsert(c *fiber.Ctx) error {
+	var req dto.NumberingSchemeRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	scheme, apiErr := n.service.UpsertScheme(c.Context(), dto.NumberingScheme{
+		Unit:    c.Params("unit"),
+		Pattern: req.Pattern,
+	})
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": scheme})
+}
+
+// Delete menghapus skema penomoran unit
+// @Summary delete numbering scheme
+// @Description menghapus skema penomoran unit, unit akan kembali menggunakan pola default
+// @ID numbering-delete
+// @Accept json
+// @Produce json
+// @Tags Numbering
+// @Security bearerAuth
+// @Param unit path string true "Kode unit"
+// @Success 200 {object} payload.RespMsgExample
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /numbering-schemes/{unit} [delete]
+func (n *NumberingHandler) Delete(c *fiber.Ctx) error {
+	unit := c.Params("unit")
+	apiErr := n.service.DeleteScheme(c.Context(), unit)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": fmt.Sprintf("skema penomoran unit %s berhasil dihapus", unit)})
+}
+
+// Get menampilkan skema penomoran unit
+// @Summary get numbering scheme
+// @Description menampilkan skema penomoran unit
+// @ID numbering-get
+// @Accept json
+// @Produce json
+// @Tags Numbering
+// @Security bearerAuth
+// @Param unit path string true "Kode unit"
+// @Success 200 {object} payload.RespWrap{data=dto.NumberingScheme}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /numbering-schemes/{unit} [get]
+func (n *NumberingHandler) Get(c *fiber.Ctx) error {
+	scheme, apiErr := n.service.GetScheme(c.Context(), c.Params("unit"))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": scheme})
+}
+
+// Find menampilkan seluruh skema penomoran
+// @Summary find numbering scheme
+// @Description menampilkan daftar skema penomoran seluruh unit
+// @ID numbering-find
+// @Accept json
+// @Produce json
+// @Tags Numbering
+// @Security bearerAuth
+// @Success 200 {object} payload.RespWrap{data=[]dto.NumberingScheme}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /numbering-schemes [get]
+func (n *NumberingHandler) Find(c *fiber.Ctx) error {
+	schemes, apiErr := n.service.FindSchemes(c.Context())
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": schemes})
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+// OIDCLogin mengarahkan browser ke halaman login identity provider
+// @Summary login oidc
+// @Description mengarahkan ke halaman login identity provider OIDC menggunakan authorization code flow dengan PKCE
+// @ID user-oidc-login
+// @Tags Access
+// @Success 302
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /oidc/login [get]
+func (u *UserHandler) OIDCLogin(c *fiber.Ctx) error {
+	authURL, apiErr := u.service.OIDCLogin(c.Context())
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.Redirect(authURL)
+}
+
+// OIDCCallback callback dari identity provider
+// @Summary callback oidc
+// @Description menukar authorization code dari identity provider dengan JWT Token. User baru dibuat dengan role BASIC apabila belum terdaftar dan BA_OIDC_AUTO_PROVISION aktif
+// @ID user-oidc-callback
+// @Produce json
+// @Tags Access
+// @Param code query string true "Authorization code"
+// @Param state query string true "State"
+// @Success 200 {object} payload.RespWrap{data=dto.UserLoginResponse}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /oidc/callback [get]
+func (u *UserHandler) OIDCCallback(c *fiber.Ctx) error {
+	if idpErr := c.Query("error"); idpErr != "" {
+		apiErr := rest_err.NewBadRequestError(fmt.Sprintf("login OIDC gagal: %s %s", idpErr, c.Query("error_description")))
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		apiErr := rest_err.NewBadRequestError("code dan state tidak boleh kosong")
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	response, apiErr := u.service.OIDCCallback(c.Context(), code, state, c.IP(), c.Get(fiber.HeaderUserAgent))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": response})
+}
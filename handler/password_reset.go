@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+// ForgotPassword mengirim token reset password ke email
+// @Summary forgot password
+// @Description mengirim tautan reset password ke email apabila email terdaftar, response selalu sama untuk email yang tidak terdaftar
+// @ID user-forgot-password
+// @Accept json
+// @Produce json
+// @Tags Access
+// @Param ReqBody body dto.ForgotPasswordRequest true "Body raw JSON"
+// @Success 200 {object} payload.RespMsgExample
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /forgot-password [post]
+func (u *UserHandler) ForgotPassword(c *fiber.Ctx) error {
+	var req dto.ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	apiErr := u.service.ForgotPassword(c.Context(), req.Email)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": "Apabila email terdaftar, tautan reset password akan dikirim ke email tersebut"})
+}
+
+// ResetPassword mengganti password menggunakan token reset password
+// @Summary reset password
+// @Description mengganti password menggunakan token dari email reset password, token hanya dapat digunakan sekali dan seluruh sesi user direvoke
+// @ID user-reset-password
+// @Accept json
+// @Produce json
+// @Tags Access
+// @Param ReqBody body dto.ResetPasswordRequest true "Body raw JSON"
+// @Success 200 {object} payload.RespMsgExample
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /reset-password [post]
+func (u *UserHandler) ResetPassword(c *fiber.Ctx) error {
+	var req dto.ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	apiErr := u.service.ResetPassword(c.Context(), req.Token, req.NewPassword, c.IP())
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": "Password berhasil diubah, silakan login kembali"})
+}
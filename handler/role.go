@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/services/roleserv"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+func NewRoleHandler(roleService roleserv.RoleServiceAssumer) *RoleHandler {
+	return &RoleHandler{
+		service: roleService,
+	}
+}
+
+type RoleHandler struct {
+	service roleserv.RoleServiceAssumer
+}
+
+// Insert menambahkan role
+// @Summary insert role
+// @Description membuat role baru tanpa permission, nama role berupa huruf besar
+// @ID role-insert
+// @Accept json
+// @Produce json
+// @Tags Role
+// @Security bearerAuth
+// @Param ReqBody body dto.RoleRequest true "Body raw JSON"
+// @Success 200 {object} payload.RespMsgExample
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /roles [post]
+func (r *RoleHandler) Insert(c *fiber.Ctx) error {
+	var req dto.RoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	apiErr := r.service.InsertRole(c.Context(), req.Name)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	res := fmt.Sprintf("Role %s berhasil dibuat", req.Name)
+	return c.JSON(fiber.Map{"error": nil, "data": res})
+}
+
+// Rename merubah nama role
+// @Summary rename role
+// @Description merubah nama role, user yang memiliki role tersebut ikut berubah. Role bawaan tidak dapat diubah
+// @ID role-rename
+// @Accept json
+// @Produce json
+// @Tags Role
+// @Security bearerAuth
+// @Param name path string true "Nama role"
+// @Param ReqBody body dto.RoleRequest true "Body raw JSON"
+// @Success 200 {object} payload.RespWrap{data=dto.Role}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /roles/{name} [put]
+func (r *RoleHandler) Rename(c *fiber.Ctx) error {
+	var req dto.RoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	role, apiErr := r.service.RenameRole(c.Context(), c.Params("name"), req.Name)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": role})
+}
+
+// Delete menghapus role
+// @Summary delete role
+// @Description menghapus role beserta permissionnya. Role bawaan dan role yang masih dimiliki user tidak dapat dihapus
+// @ID role-delete
+// @Accept json
+// @Produce json
+// @Tags Role
+// @Security bearerAuth
+// @Param name path string true "Nama role"
+// @Success 200 {object} payload.RespMsgExample
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /roles/{name} [delete]
+func (r *RoleHandler) Delete(c *fiber.Ctx) error {
+	name := c.Params("name")
+	apiErr := r.service.DeleteRole(c.Context(), name)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": fmt.Sprintf("role %s berhasil dihapus", name)})
+}
+
+// AddPermissions menambahkan permission pada role
+// @Summary add role permissions
+// @Description menambahkan permission pada role, permission yang sudah dimiliki diabaikan
+// @ID role-add-permissions
+// @Accept json
+// @Produce json
+// @Tags Role
+// @Security bearerAuth
+// @Param name path string true "Nama role"
+// @Param ReqBody body dto.RolePermissionRequest true "Body raw JSON"
+// @Success 200 {object} payload.RespWrap{data=dto.Role}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /roles/{name}/permissions [post]
+func (r *RoleHandler) AddPermissions(c *fiber.Ctx) error {
+	var req dto.RolePermissionRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	role, apiErr := r.service.AddPermissions(c.Context(), c.Params("name"), req.Permissions)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": role})
+}
+
+// RemovePermission menghapus permission dari role
+// @Summary remove role permission
+// @Description menghapus permission dari role
+// @ID role-remove-permission
+// @Accept json
+// @Produce json
+// @Tags Role
+// @Security bearerAuth
+// @Param name path string true "Nama role"
+// @Param permission path string true "Nama permission"
+// @Success 200 {object} payload.RespWrap{data=dto.Role}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /roles/{name}/permissions/{permission} [delete]
+func (r *RoleHandler) RemovePermission(c *fiber.Ctx) error {
+	role, apiErr := r.service.RemovePermission(c.Context(), c.Params("name"), c.Params("permission"))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": role})
+}
+
+// Get menampilkan role berdasarkan nama
+// @Summary get role by name
+// @Description menampilkan role beserta daftar permission
+// @ID role-get
+// @Accept json
+// @Produce json
+// @Tags Role
+// @Security bearerAuth
+// @Param name path string true "Nama role"
+// @Success 200 {object} payload.RespWrap{data=dto.Role}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /roles/{name} [get]
+func (r *RoleHandler) Get(c *fiber.Ctx) error {
+	role, apiErr := r.service.GetRole(c.Context(), c.Params("name"))
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": role})
+}
+
+// Find menampilkan seluruh role
+// @Summary find role
+// @Description menampilkan daftar role beserta permission
+// @ID role-find
+// @Accept json
+// @Produce json
+// @Tags Role
+// @Security bearerAuth
+// @Success 200 {object} payload.RespWrap{data=[]dto.Role}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /roles [get]
+func (r *RoleHandler) Find(c *fiber.Ctx) error {
+	roleList, apiErr := r.service.FindRoles(c.Context())
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": roleList})
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/muchlist/berita_acara/utils/mjwt"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"strconv"
+)
+
+// GetProfileSessions menampilkan sesi aktif milik user yang sedang login
+// @Summary get profile sessions
+// @Description menampilkan perangkat dan ip tempat akun sedang login, sesi yang sedang digunakan ditandai current
+// @ID user-profile-sessions
+// @Accept json
+// @Produce json
+// @Tags Access
+// @Security bearerAuth
+// @Success 200 {object} payload.RespWrap{data=[]dto.Session}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /profile/sessions [get]
+func (u *UserHandler) GetProfileSessions(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	sessions, apiErr := u.service.FindSessions(c.Context(), claims.Identity, claims.SessionID)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": sessions})
+}
+
+// DeleteProfileSession mengakhiri sesi milik user yang sedang login
+// @Summary delete profile session
+// @Description mengakhiri sesi, refresh token dan access token pada sesi tersebut tidak dapat digunakan lagi
+// @ID user-profile-session-delete
+// @Accept json
+// @Produce json
+// @Tags Access
+// @Security bearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} payload.RespMsgExample
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /profile/sessions/{id} [delete]
+func (u *UserHandler) DeleteProfileSession(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	sessionID := c.Params("id")
+	apiErr := u.service.TerminateSession(c.Context(), claims.Identity, sessionID, claims.Identity, c.IP())
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": fmt.Sprintf("sesi %s berhasil diakhiri", sessionID)})
+}
+
+// GetSessions menampilkan sesi aktif milik user
+// @Summary get user sessions
+// @Description menampilkan perangkat dan ip tempat user sedang login
+// @ID user-sessions
+// @Accept json
+// @Produce json
+// @Tags Access
+// @Security bearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} payload.RespWrap{data=[]dto.Session}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /users/{id}/sessions [get]
+func (u *UserHandler) GetSessions(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	userIDInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		apiErr := rest_err.NewBadRequestError("kesalahan input, id harus berupa angka")
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	sessions, apiErr := u.service.FindSessions(c.Context(), userIDInt, claims.SessionID)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": sessions})
+}
+
+// DeleteSession mengakhiri sesi milik user
+// @Summary delete user session
+// @Description mengakhiri salah satu sesi user, refresh token dan access token pada sesi tersebut tidak dapat digunakan lagi
+// @ID user-session-delete
+// @Accept json
+// @Produce json
+// @Tags Access
+// @Security bearerAuth
+// @Param id path int true "User ID"
+// @Param session_id path string true "Session ID"
+// @Success 200 {object} payload.RespMsgExample
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /users/{id}/sessions/{session_id} [delete]
+func (u *UserHandler) DeleteSession(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	userIDInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		apiErr := rest_err.NewBadRequestError("kesalahan input, id harus berupa angka")
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	sessionID := c.Params("session_id")
+	apiErr := u.service.TerminateSession(c.Context(), userIDInt, sessionID, claims.Identity, c.IP())
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": fmt.Sprintf("sesi %s milik user %d berhasil diakhiri", sessionID, userIDInt)})
+}
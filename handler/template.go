@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/services/templateserv"
+	"github.com/muchlist/berita_acara/utils/mjwt"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/muchlist/berita_acara/utils/sfunc"
+	"strconv"
+)
+
+func NewTemplateHandler(templateService templateserv.TemplateServiceAssumer) *TemplateHandler {
+	return &TemplateHandler{
+		service: templateService,
+	}
+}
+
+type TemplateHandler struct {
+	service templateserv.TemplateServiceAssumer
+}
+
+// Insert menambahkan template
+// @Summary insert template
+// @Description membuat template berita acara, setiap placeholder {{nama_field}} wajib dideklarasikan pada fields
+// @ID template-insert
+// @Accept json
+// @Produce json
+// @Tags Template
+// @Security bearerAuth
+// @Param ReqBody body dto.TemplateRequest true "Body raw JSON"
+// @Success 200 {object} payload.RespMsgExample
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /templates [post]
+func (t *TemplateHandler) Insert(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	var req dto.TemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	insertID, apiErr := t.service.InsertTemplate(c.Context(), dto.Template{
+		Name:    req.Name,
+		Type:    req.Type,
+		Title:   req.Title,
+		Body:    req.Body,
+		Fields:  req.Fields,
+		Creator: claims.Identity,
+	})
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	res := fmt.Sprintf("Template berhasil dibuat, ID: %d", insertID)
+	return c.JSON(fiber.Map{"error": nil, "data": res})
+}
+
+// Edit
+// @Summary edit template
+// @Description melakukan perubahan data pada template
+// @ID template-edit
+// @Accept json
+// @Produce json
+// @Tags Template
+// @Security bearerAuth
+// @Param id path int true "Template ID"
+// @Param ReqBody body dto.TemplateRequest true "Body raw JSON"
+// @Success 200 {object} payload.RespWrap{data=dto.Template}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /templates/{id} [put]
+func (t *TemplateHandler) Edit(c *fiber.Ctx) error {
+	templateID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		apiErr := rest_err.NewBadRequestError("kesalahan input, id harus berupa angka")
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	var req dto.TemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	templateEdited, apiErr := t.service.EditTemplate(c.Context(), dto.Template{
+		ID:     templateID,
+		Name:   req.Name,
+		Type:   req.Type,
+		Title:  req.Title,
+		Body:   req.Body,
+		Fields: req.Fields,
+	})
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": templateEdited})
+}
+
+// Delete menghapus template
+// @Summary delete template by ID
+// @Description menghapus template berdasarkan ID
+// @ID template-delete
+// @Accept json
+// @Produce json
+// @Tags Template
+// @Security bearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} payload.RespMsgExample
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /templates/{id} [delete]
+func (t *TemplateHandler) Delete(c *fiber.Ctx) error {
+	templateID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		apiErr := rest_err.NewBadRequestError("kesalahan input, id harus berupa angka")
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	apiErr := t.service.DeleteTemplate(c.Context(), templateID)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": fmt.Sprintf("template %d berhasil dihapus", templateID)})
+}
+
+// Get menampilkan template berdasarkan ID
+// @Summary get template by ID
+// @Description menampilkan template beserta daftar field berdasarkan ID
+// @ID template-get
+// @Accept json
+// @Produce json
+// @Tags Template
+// @Security bearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} payload.RespWrap{data=dto.Template}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /templates/{id} [get]
+func (t *TemplateHandler) Get(c *fiber.Ctx) error {
+	templateID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		apiErr := rest_err.NewBadRequestError("kesalahan input, id harus berupa angka")
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	template, apiErr := t.service.GetTemplate(c.Context(), templateID)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": template})
+}
+
+// Find menampilkan list template
+// @Summary find template
+// @Description menampilkan daftar template
+// @ID template-find
+// @Accept json
+// @Produce json
+// @Tags Template
+// @Security bearerAuth
+// @Param limit query int false "Limit"
+// @Param last_id query int false "Last ID sebagai cursor untuk page selanjutnya"
+// @Param search query string false "Search apabila di isi akan melakukan pencarian nama template"
+// @Success 200 {object} payload.RespWrap{data=[]dto.Template}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /templates [get]
+func (t *TemplateHandler) Find(c *fiber.Ctx) error {
+	limit := sfunc.StrToInt(c.Query("limit"), 10)
+	cursor := sfunc.StrToInt(c.Query("last_id"), 0)
+	search := c.Query("search")
+
+	templateList, apiErr := t.service.FindTemplates(c.Context(), search, limit, cursor)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if templateList == nil {
+		templateList = []dto.Template{}
+	}
+	return c.JSON(fiber.Map{"error": nil, "data": templateList})
+}
+
+// Render mengisi template
+// @Summary render template
+// @Description mengisi placeholder template dengan values, field required wajib diisi
+// @ID template-render
+// @Accept json
+// @Produce json
+// @Tags Template
+// @Security bearerAuth
+// @Param id path int true "Template ID"
+// @Param ReqBody body dto.TemplateRenderRequest true "Body raw JSON"
+// @Success 200 {object} payload.RespWrap{data=dto.TemplateRenderResponse}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /templates/{id}/render [post]
+func (t *TemplateHandler) Render(c *fiber.Ctx) error {
+	templateID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		apiErr := rest_err.NewBadRequestError("kesalahan input, id harus berupa angka")
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	var req dto.TemplateRenderRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	rendered, apiErr := t.service.RenderTemplate(c.Context(), templateID, req)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": rendered})
+}
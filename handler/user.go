@@ -25,7 +25,7 @@ type UserHandler struct {
 
 // Login login
 // @Summary login
-// @Description login menggunakan userID dan password untuk mendapatkan JWT Token
+// @Description login menggunakan user_id atau email dan password untuk mendapatkan JWT Token. Email tidak membedakan huruf besar dan kecil, apabila keduanya diisi maka email yang digunakan
 // @ID user-login
 // @Accept json
 // @Produce json
@@ -33,6 +33,7 @@ type UserHandler struct {
 // @Param ReqBody body dto.UserLoginRequest true "Body raw JSON"
 // @Success 200 {object} payload.RespWrap{data=dto.UserLoginResponse}
 // @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 429 {object} payload.RespWrap{error=payload.ErrorExample400}
 // @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
 // @Router /login [post]
 func (u *UserHandler) Login(c *fiber.Ctx) error {
@@ -42,12 +43,12 @@ func (u *UserHandler) Login(c *fiber.Ctx) error {
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
 	}
 
-	if login.UserID == 0 || login.Password == "" {
-		apiErr := rest_err.NewBadRequestError("user_id atau password tidak boleh kosong")
+	if (login.UserID == 0 && login.Email == "") || login.Password == "" {
+		apiErr := rest_err.NewBadRequestError("user_id atau email dan password tidak boleh kosong")
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
 	}
 
-	response, apiErr := u.service.Login(c.Context(), login)
+	response, apiErr := u.service.Login(c.Context(), login, c.IP(), c.Get(fiber.HeaderUserAgent))
 	if apiErr != nil {
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
 	}
@@ -84,6 +85,7 @@ func (u *UserHandler) Register(c *fiber.Ctx) error {
 		ID:        user.ID,
 		Email:     user.Email,
 		Name:      dto.UppercaseString(user.Name),
+		Position:  user.Position,
 		Password:  user.Password,
 		Roles:     user.Roles,
 		CreatedAt: time.Now().Unix(),
@@ -128,10 +130,11 @@ func (u *UserHandler) Edit(c *fiber.Ctx) error {
 	}
 
 	userEdited, apiErr := u.service.EditUser(c.Context(), dto.User{
-		ID:    userIDInt,
-		Email: req.Email,
-		Name:  dto.UppercaseString(req.Name),
-		Roles: req.Roles,
+		ID:       userIDInt,
+		Email:    req.Email,
+		Name:     dto.UppercaseString(req.Name),
+		Position: req.Position,
+		Roles:    req.Roles,
 	})
 	if apiErr != nil {
 		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
@@ -167,9 +170,127 @@ func (u *UserHandler) RefreshToken(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"error": nil, "data": response})
 }
 
+// Logout
+// @Summary logout
+// @Description merevoke refresh token beserta seluruh hasil rotasinya, access token pada header (opsional) ikut ditolak
+// @ID user-logout
+// @Accept json
+// @Produce json
+// @Tags Access
+// @Security bearerAuth
+// @Param ReqBody body dto.UserRefreshTokenRequest true "Body raw JSON"
+// @Success 200 {object} payload.RespMsgExample
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /logout [post]
+func (u *UserHandler) Logout(c *fiber.Ctx) error {
+	var req dto.UserRefreshTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	// claims hanya ada apabila request membawa access token yang valid (middle.OptionalAuth)
+	claims, _ := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+	apiErr := u.service.Logout(c.Context(), req, claims)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": "logout berhasil"})
+}
+
+// RevokeSessions merevoke seluruh sesi user
+// @Summary revoke user sessions
+// @Description merevoke seluruh refresh token milik user sehingga user harus login kembali
+// @ID user-revoke-sessions
+// @Accept json
+// @Produce json
+// @Tags Access
+// @Security bearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} payload.RespMsgExample
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /users/{id}/revoke-sessions [post]
+func (u *UserHandler) RevokeSessions(c *fiber.Ctx) error {
+	userIDInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		apiErr := rest_err.NewBadRequestError("kesalahan input, id harus berupa angka")
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	apiErr := u.service.RevokeSessions(c.Context(), userIDInt)
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": fmt.Sprintf("sesi user %d berhasil direvoke", userIDInt)})
+}
+
+// Unlock membuka kunci login user
+// @Summary unlock user
+// @Description menghapus hitungan login gagal dan kunci login user, kunci berdasarkan ip tidak ikut dihapus
+// @ID user-unlock
+// @Accept json
+// @Produce json
+// @Tags Access
+// @Security bearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} payload.RespMsgExample
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /users/{id}/unlock [post]
+func (u *UserHandler) Unlock(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	userIDInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		apiErr := rest_err.NewBadRequestError("kesalahan input, id harus berupa angka")
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	apiErr := u.service.UnlockUser(c.Context(), userIDInt, claims.Identity, c.IP())
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": fmt.Sprintf("kunci login user %d berhasil dibuka", userIDInt)})
+}
+
+// Impersonate menerbitkan token atas nama user
+// @Summary impersonate user
+// @Description menerbitkan access token berumur pendek atas nama user untuk melihat aplikasi seperti user tersebut. Token tidak fresh, tidak memiliki refresh token dan setiap request yang menggunakannya dicatat pada audit log. User dengan role ADMIN dan service account tidak dapat di-impersonate
+// @ID user-impersonate
+// @Accept json
+// @Produce json
+// @Tags Access
+// @Security bearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} payload.RespWrap{data=dto.ImpersonateResponse}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /users/{id}/impersonate [post]
+func (u *UserHandler) Impersonate(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	userIDInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		apiErr := rest_err.NewBadRequestError("kesalahan input, id harus berupa angka")
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	response, apiErr := u.service.Impersonate(c.Context(), userIDInt, claims.Identity, c.IP())
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": response})
+}
+
 // Delete menghapus user
 // @Summary delete user by ID
-// @Description menghapus user berdasarkan userID
+// @Description menghapus user berdasarkan userID, user yang masih menjadi pembuat atau penandatangan document tidak dapat dihapus
 // @ID user-delete
 // @Accept json
 // @Produce json
@@ -255,6 +376,82 @@ func (u *UserHandler) GetProfile(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"error": nil, "data": user})
 }
 
+// EditProfile merubah profile user yang sedang login
+// @Summary edit current profile
+// @Description merubah email, nama dan jabatan user yang login saat ini, role tidak dapat diubah
+// @ID user-profile-edit
+// @Accept json
+// @Produce json
+// @Tags Access
+// @Security bearerAuth
+// @Param ReqBody body dto.UserProfileEditRequest true "Body raw JSON"
+// @Success 200 {object} payload.RespWrap{data=dto.User}
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Router /profile [put]
+func (u *UserHandler) EditProfile(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	var req dto.UserProfileEditRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	userEdited, apiErr := u.service.EditProfile(c.Context(), dto.User{
+		ID:       claims.Identity,
+		Email:    req.Email,
+		Name:     dto.UppercaseString(req.Name),
+		Position: req.Position,
+	})
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": userEdited})
+}
+
+// ChangePassword merubah password user yang sedang login
+// @Summary change current password
+// @Description merubah password user yang login saat ini, memerlukan password lama dan fresh token. Seluruh sesi lain milik user diakhiri
+// @ID user-profile-password
+// @Accept json
+// @Produce json
+// @Tags Access
+// @Security bearerAuth
+// @Param ReqBody body dto.UserChangePasswordRequest true "Body raw JSON"
+// @Success 200 {object} payload.RespMsgExample
+// @Failure 400 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Failure 500 {object} payload.RespWrap{error=payload.ErrorExample500}
+// @Failure 429 {object} payload.RespWrap{error=payload.ErrorExample400}
+// @Router /profile/password [post]
+func (u *UserHandler) ChangePassword(c *fiber.Ctx) error {
+	claims := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+
+	var req dto.UserChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	if err := req.Validate(); err != nil {
+		apiErr := rest_err.NewBadRequestError(err.Error())
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	apiErr := u.service.ChangePassword(c.Context(), claims.Identity, claims.SessionID, req.OldPassword, req.NewPassword, c.IP())
+	if apiErr != nil {
+		return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+	}
+
+	return c.JSON(fiber.Map{"error": nil, "data": "Password berhasil diubah"})
+}
+
 // Find menampilkan list user
 // @Summary find user
 // @Description menampilkan daftar user
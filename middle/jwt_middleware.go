@@ -1,12 +1,14 @@
 package middle
 
 import (
+	"context"
 	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/muchlist/berita_acara/utils/mjwt"
 	"github.com/muchlist/berita_acara/utils/rest_err"
 	"github.com/muchlist/berita_acara/utils/sfunc"
 	"strings"
+	"time"
 )
 
 var (
@@ -14,16 +16,56 @@ var (
 )
 
 const (
-	headerKey = "Authorization"
-	bearerKey = "Bearer"
+	headerKey    = "Authorization"
+	bearerKey    = "Bearer"
+	apiKeyHeader = "X-API-Key"
 )
 
+// TokenDenylist sumber denylist access token, dipenuhi oleh denylistdao
+type TokenDenylist interface {
+	IsTokenDenied(ctx context.Context, tokenID string) (bool, rest_err.APIError)
+	GetUserCutoff(ctx context.Context, userID int) (int64, rest_err.APIError)
+}
+
+var tokenDenylist TokenDenylist
+
+// SetTokenDenylist mengaktifkan pemeriksaan denylist pada seluruh middleware auth,
+// jika tidak dipanggil maka token hanya divalidasi berdasarkan signature dan exp
+func SetTokenDenylist(denylist TokenDenylist) {
+	tokenDenylist = denylist
+}
+
+// APIKeyAuthenticator memvalidasi api key service account, dipenuhi oleh apikeyserv
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*mjwt.CustomClaim, rest_err.APIError)
+}
+
+var apiKeyAuthenticator APIKeyAuthenticator
+
+// SetAPIKeyAuthenticator mengaktifkan autentikasi header X-API-Key pada NormalAuth, OptionalAuth dan
+// RequirePermission, jika tidak dipanggil maka request dengan header X-API-Key ditolak
+func SetAPIKeyAuthenticator(authenticator APIKeyAuthenticator) {
+	apiKeyAuthenticator = authenticator
+}
+
+// ImpersonationRecorder mencatat request yang menggunakan token impersonation, dipenuhi oleh userserv
+type ImpersonationRecorder interface {
+	RecordImpersonatedRequest(ctx context.Context, claims *mjwt.CustomClaim, method string, path string, ip string) rest_err.APIError
+}
+
+var impersonationRecorder ImpersonationRecorder
+
+// SetImpersonationRecorder mengaktifkan pencatatan request dengan token impersonation,
+// jika tidak dipanggil maka token impersonation ditolak
+func SetImpersonationRecorder(recorder ImpersonationRecorder) {
+	impersonationRecorder = recorder
+}
+
 // NormalAuth memerlukan salah satu role inputan agar diloloskan ke proses berikutnya
 // token tidak perlu fresh
 func NormalAuth(rolesReq ...string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		authHeader := c.Get(headerKey)
-		claims, err := authHaveRoleValidator(authHeader, false, rolesReq)
+		claims, err := authHaveRoleValidator(c, false, rolesReq)
 		if err != nil {
 			return c.Status(err.Status()).JSON(fiber.Map{"error": err, "data": nil})
 		}
@@ -33,11 +75,10 @@ func NormalAuth(rolesReq ...string) fiber.Handler {
 }
 
 // FreshAuth memerlukan salah satu role inputan agar diloloskan ke proses berikutnya
-// token harus fresh (tidak hasil dari refresh token)
+// token harus fresh (tidak hasil dari refresh token), api key dan token impersonation tidak pernah dianggap fresh
 func FreshAuth(rolesReq ...string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		authHeader := c.Get(headerKey)
-		claims, err := authHaveRoleValidator(authHeader, true, rolesReq)
+		claims, err := authHaveRoleValidator(c, true, rolesReq)
 		if err != nil {
 			return c.Status(err.Status()).JSON(fiber.Map{"error": err, "data": nil})
 		}
@@ -47,26 +88,52 @@ func FreshAuth(rolesReq ...string) fiber.Handler {
 	}
 }
 
-func authHaveRoleValidator(authHeader string, mustFresh bool, rolesAllowed []string) (*mjwt.CustomClaim, rest_err.APIError) {
-	if !strings.Contains(authHeader, bearerKey) {
-		apiErr := rest_err.NewUnauthorizedError("Unauthorized")
-		return nil, apiErr
-	}
-	tokenString := strings.Split(authHeader, " ")
-	if len(tokenString) != 2 {
-		apiErr := rest_err.NewUnauthorizedError("Unauthorized")
-		return nil, apiErr
+// OptionalAuth menyimpan claims apabila request membawa access token yang valid,
+// request tanpa token atau dengan token tidak valid tetap diteruskan tanpa claims
+func OptionalAuth() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, err := authHaveRoleValidator(c, false, nil)
+		if err == nil {
+			c.Locals(mjwt.CLAIMS, claims)
+		}
+		return c.Next()
 	}
-	token, apiErr := jwt.ValidateToken(tokenString[1])
-	if apiErr != nil {
-		return nil, apiErr
+}
+
+// MFAAuth hanya untuk endpoint pendaftaran MFA, menerima token challenge MFA dari login
+// (pendaftaran wajib) atau access token yang masih fresh
+func MFAAuth() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, err := readBearerClaims(c.Context(), c.Get(headerKey))
+		if err != nil {
+			return c.Status(err.Status()).JSON(fiber.Map{"error": err, "data": nil})
+		}
+		if err := recordImpersonation(c, claims); err != nil {
+			return c.Status(err.Status()).JSON(fiber.Map{"error": err, "data": nil})
+		}
+		switch {
+		case claims.Type == mjwt.MFAChallenge:
+		case claims.Type == mjwt.Access && claims.IsFresh(time.Now()):
+		default:
+			apiErr := rest_err.NewUnauthorizedError("Memerlukan token yang baru untuk mengakses halaman ini")
+			return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+		}
+		c.Locals(mjwt.CLAIMS, claims)
+		return c.Next()
 	}
-	claims, apiErr := jwt.ReadToken(token)
+}
+
+// authHaveRoleValidator membaca claims dari header X-API-Key apabila ada, selain itu dari access token
+func authHaveRoleValidator(c *fiber.Ctx, mustFresh bool, rolesAllowed []string) (*mjwt.CustomClaim, rest_err.APIError) {
+	claims, apiErr := readRequestClaims(c)
 	if apiErr != nil {
 		return nil, apiErr
 	}
 	if mustFresh {
-		if !claims.Fresh {
+		if claims.IsImpersonated() {
+			return nil, rest_err.NewUnauthorizedError("Token impersonation tidak dapat mengakses halaman ini")
+		}
+		if !claims.IsFresh(time.Now()) {
 			apiErr := rest_err.NewUnauthorizedError("Memerlukan token yang baru untuk mengakses halaman ini")
 			return nil, apiErr
 		}
@@ -85,3 +152,94 @@ func authHaveRoleValidator(authHeader string, mustFresh bool, rolesAllowed []str
 	apiErr = rest_err.NewUnauthorizedError(fmt.Sprintf("Unauthorized, memerlukan hak akses %s", rolesAllowed))
 	return nil, apiErr
 }
+
+func readRequestClaims(c *fiber.Ctx) (*mjwt.CustomClaim, rest_err.APIError) {
+	if apiKey := c.Get(apiKeyHeader); apiKey != "" {
+		if apiKeyAuthenticator == nil {
+			return nil, rest_err.NewUnauthorizedError("Unauthorized, api key tidak didukung")
+		}
+		return apiKeyAuthenticator.AuthenticateAPIKey(c.Context(), apiKey)
+	}
+
+	claims, apiErr := readBearerClaims(c.Context(), c.Get(headerKey))
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	// refresh token dan token challenge MFA tidak boleh digunakan sebagai access token
+	if claims.Type != mjwt.Access {
+		return nil, rest_err.NewUnauthorizedError("Unauthorized, memerlukan access token")
+	}
+	if apiErr := recordImpersonation(c, claims); apiErr != nil {
+		return nil, apiErr
+	}
+	return claims, nil
+}
+
+// recordImpersonation mencatat setiap request dengan token impersonation sebelum diteruskan,
+// termasuk request yang kemudian ditolak karena role atau fresh
+func recordImpersonation(c *fiber.Ctx, claims *mjwt.CustomClaim) rest_err.APIError {
+	if !claims.IsImpersonated() {
+		return nil
+	}
+	if impersonationRecorder == nil {
+		return rest_err.NewUnauthorizedError("Unauthorized, token impersonation tidak didukung")
+	}
+	return impersonationRecorder.RecordImpersonatedRequest(c.Context(), claims, c.Method(), c.Path(), c.IP())
+}
+
+// readBearerClaims membaca token dari header Authorization, memvalidasi signature serta claim
+// dan memeriksa denylist tanpa memperhatikan tipe token
+func readBearerClaims(ctx context.Context, authHeader string) (*mjwt.CustomClaim, rest_err.APIError) {
+	if !strings.Contains(authHeader, bearerKey) {
+		apiErr := rest_err.NewUnauthorizedError("Unauthorized")
+		return nil, apiErr
+	}
+	tokenString := strings.Split(authHeader, " ")
+	if len(tokenString) != 2 {
+		apiErr := rest_err.NewUnauthorizedError("Unauthorized")
+		return nil, apiErr
+	}
+	token, apiErr := jwt.ValidateToken(tokenString[1])
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	claims, apiErr := jwt.ReadToken(token)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := checkDenylist(ctx, claims); apiErr != nil {
+		return nil, apiErr
+	}
+	return claims, nil
+}
+
+// checkDenylist menolak token yang jti atau sid nya masuk denylist atau diterbitkan pada atau sebelum
+// batas waktu milik user (misalnya setelah role diubah atau user dihapus)
+func checkDenylist(ctx context.Context, claims *mjwt.CustomClaim) rest_err.APIError {
+	if tokenDenylist == nil {
+		return nil
+	}
+
+	// sesi yang diakhiri dimasukkan ke denylist menggunakan sid
+	for _, tokenID := range []string{claims.TokenID, claims.SessionID} {
+		if tokenID == "" {
+			continue
+		}
+		denied, apiErr := tokenDenylist.IsTokenDenied(ctx, tokenID)
+		if apiErr != nil {
+			return apiErr
+		}
+		if denied {
+			return rest_err.NewUnauthorizedError("Token sudah tidak berlaku, silakan login kembali")
+		}
+	}
+
+	issuedBefore, apiErr := tokenDenylist.GetUserCutoff(ctx, claims.Identity)
+	if apiErr != nil {
+		return apiErr
+	}
+	if issuedBefore != 0 && claims.IssuedAt <= issuedBefore {
+		return rest_err.NewUnauthorizedError("Token sudah tidak berlaku, silakan login kembali")
+	}
+	return nil
+}
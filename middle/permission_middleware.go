@@ -0,0 +1,61 @@
+package middle
+
+import (
+	"context"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/muchlist/berita_acara/utils/mjwt"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+// PermissionResolver menentukan apakah salah satu role memiliki permission, dipenuhi oleh roleserv
+type PermissionResolver interface {
+	HasPermission(ctx context.Context, roleNames []string, permissionName string) (bool, rest_err.APIError)
+}
+
+var permissionResolver PermissionResolver
+
+// SetPermissionResolver wajib dipanggil sebelum route yang menggunakan RequirePermission menerima request
+func SetPermissionResolver(resolver PermissionResolver) {
+	permissionResolver = resolver
+}
+
+// RequirePermission memerlukan salah satu permission inputan yang dimiliki role pada token.
+// Jika dipasang setelah NormalAuth atau FreshAuth maka claims yang sudah divalidasi digunakan kembali,
+// contoh : middle.FreshAuth(), middle.RequirePermission(permission.RoleWrite)
+func RequirePermission(permissionsReq ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := c.Locals(mjwt.CLAIMS).(*mjwt.CustomClaim)
+		if !ok {
+			var apiErr rest_err.APIError
+			claims, apiErr = authHaveRoleValidator(c, false, nil)
+			if apiErr != nil {
+				return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+			}
+			c.Locals(mjwt.CLAIMS, claims)
+		}
+
+		if apiErr := havePermission(c.Context(), claims, permissionsReq); apiErr != nil {
+			return c.Status(apiErr.Status()).JSON(fiber.Map{"error": apiErr, "data": nil})
+		}
+		return c.Next()
+	}
+}
+
+func havePermission(ctx context.Context, claims *mjwt.CustomClaim, permissionsAllowed []string) rest_err.APIError {
+	if permissionResolver == nil {
+		return rest_err.NewInternalServerError("permission resolver belum diinisialisasi", nil)
+	}
+
+	for _, permissionName := range permissionsAllowed {
+		allowed, apiErr := permissionResolver.HasPermission(ctx, claims.Roles, permissionName)
+		if apiErr != nil {
+			return apiErr
+		}
+		if allowed {
+			return nil
+		}
+	}
+
+	return rest_err.NewUnauthorizedError(fmt.Sprintf("Unauthorized, memerlukan permission %s", permissionsAllowed))
+}
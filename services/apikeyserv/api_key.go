@@ -0,0 +1,224 @@
+package apikeyserv
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"github.com/muchlist/berita_acara/dao/apikeydao"
+	"github.com/muchlist/berita_acara/dao/auditdao"
+	"github.com/muchlist/berita_acara/dao/userdao"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/mcrypt"
+	"github.com/muchlist/berita_acara/utils/mjwt"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/muchlist/berita_acara/utils/sfunc"
+	"strings"
+	"time"
+)
+
+func NewAPIKeyService(
+	dao apikeydao.APIKeyDaoAssumer,
+	userDao userdao.UserDaoAssumer,
+	auditDao auditdao.AuditDaoAssumer,
+	crypto mcrypt.HasherAssumer,
+) APIKeyServiceAssumer {
+	return &apiKeyService{
+		dao:      dao,
+		userDao:  userDao,
+		auditDao: auditDao,
+		crypto:   crypto,
+	}
+}
+
+type apiKeyService struct {
+	dao      apikeydao.APIKeyDaoAssumer
+	userDao  userdao.UserDaoAssumer
+	auditDao auditdao.AuditDaoAssumer
+	crypto   mcrypt.HasherAssumer
+}
+
+// CreateServiceAccount membuat user yang hanya dapat mengakses API menggunakan api key,
+// password diisi hash dari token acak sehingga tidak dapat digunakan untuk login
+func (a *apiKeyService) CreateServiceAccount(ctx context.Context, request dto.ServiceAccountRequest) (int, rest_err.APIError) {
+	hashPassword, apiErr := mcrypt.GenerateUnusablePasswordHash(a.crypto)
+	if apiErr != nil {
+		return 0, apiErr
+	}
+
+	now := time.Now().Unix()
+	return a.userDao.Insert(ctx, dto.User{
+		ID:             request.ID,
+		Email:          request.Email,
+		Name:           dto.UppercaseString(request.Name),
+		Position:       request.Position,
+		Password:       hashPassword,
+		Roles:          request.Roles,
+		ServiceAccount: true,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	})
+}
+
+// CreateAPIKey membuat api key untuk service account, scope harus merupakan role milik service account.
+// Key lengkap hanya dikembalikan sekali dan tidak disimpan.
+func (a *apiKeyService) CreateAPIKey(ctx context.Context, userID int, request dto.APIKeyRequest, actorID int, ip string) (*dto.APIKeyResponse, rest_err.APIError) {
+	user, apiErr := a.userDao.Get(ctx, userID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if user.ID == 0 {
+		return nil, rest_err.NewBadRequestError(fmt.Sprintf("User dengan username %d tidak ditemukan", userID))
+	}
+	if !user.ServiceAccount {
+		return nil, rest_err.NewBadRequestError("API key hanya dapat dibuat untuk service account")
+	}
+	for _, scope := range request.Scopes {
+		if !sfunc.InSlice(scope, user.Roles) {
+			return nil, rest_err.NewBadRequestError(fmt.Sprintf("scope %s bukan role milik service account", scope))
+		}
+	}
+
+	keyID, key, apiErr := mcrypt.GenerateAPIKey()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	now := time.Now()
+	var expiresAt int64
+	if request.ExpiresInDays != 0 {
+		expiresAt = now.AddDate(0, 0, request.ExpiresInDays).Unix()
+	}
+	apiKey := dto.APIKey{
+		ID:        keyID,
+		UserID:    user.ID,
+		Name:      strings.TrimSpace(request.Name),
+		KeyHash:   mcrypt.HashToken(key),
+		Scopes:    uniqueScopes(request.Scopes),
+		ExpiresAt: expiresAt,
+		CreatedBy: actorID,
+		CreatedAt: now.Unix(),
+	}
+	if apiErr := a.dao.Insert(ctx, apiKey); apiErr != nil {
+		return nil, apiErr
+	}
+
+	a.audit(ctx, dto.AuditLog{
+		Action:    dto.AuditAPIKeyCreated,
+		UserID:    user.ID,
+		ActorID:   actorID,
+		IP:        ip,
+		Detail:    keyID,
+		CreatedAt: now.Unix(),
+	})
+
+	return &dto.APIKeyResponse{
+		APIKey: apiKey,
+		Key:    key,
+	}, nil
+}
+
+// RevokeAPIKey api key langsung tidak dapat digunakan pada request berikutnya
+func (a *apiKeyService) RevokeAPIKey(ctx context.Context, keyID string, actorID int, ip string) rest_err.APIError {
+	apiKey, apiErr := a.dao.Get(ctx, keyID)
+	if apiErr != nil {
+		return apiErr
+	}
+	if apiKey == nil {
+		return rest_err.NewBadRequestError(fmt.Sprintf("API key %s tidak ditemukan", keyID))
+	}
+
+	now := time.Now().Unix()
+	if apiErr := a.dao.Revoke(ctx, keyID, now); apiErr != nil {
+		return apiErr
+	}
+
+	a.audit(ctx, dto.AuditLog{
+		Action:    dto.AuditAPIKeyRevoked,
+		UserID:    apiKey.UserID,
+		ActorID:   actorID,
+		IP:        ip,
+		Detail:    keyID,
+		CreatedAt: now,
+	})
+	return nil
+}
+
+// FindAPIKeys menampilkan api key milik user tanpa key maupun hash nya
+func (a *apiKeyService) FindAPIKeys(ctx context.Context, userID int) ([]dto.APIKey, rest_err.APIError) {
+	return a.dao.FindByUser(ctx, userID)
+}
+
+// AuthenticateAPIKey memvalidasi key dari header X-API-Key dan mengembalikan claims yang sama bentuknya
+// dengan claims access token. Role pada claims adalah scope key yang masih dimiliki service account.
+func (a *apiKeyService) AuthenticateAPIKey(ctx context.Context, key string) (*mjwt.CustomClaim, rest_err.APIError) {
+	invalidErr := rest_err.NewUnauthorizedError("API key tidak valid")
+
+	keyID, ok := mcrypt.ParseAPIKeyID(key)
+	if !ok {
+		return nil, invalidErr
+	}
+	apiKey, apiErr := a.dao.Get(ctx, keyID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiKey == nil || subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(mcrypt.HashToken(key))) != 1 {
+		return nil, invalidErr
+	}
+
+	now := time.Now().Unix()
+	if apiKey.RevokedAt != 0 {
+		return nil, rest_err.NewUnauthorizedError("API key sudah direvoke")
+	}
+	if apiKey.ExpiresAt != 0 && apiKey.ExpiresAt < now {
+		return nil, rest_err.NewUnauthorizedError("API key sudah kadaluarsa")
+	}
+
+	user, apiErr := a.userDao.Get(ctx, apiKey.UserID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if user.ID == 0 || !user.ServiceAccount {
+		return nil, invalidErr
+	}
+
+	// role yang dicabut dari service account ikut tidak berlaku untuk key yang sudah ada
+	keyRoles := make([]string, 0, len(apiKey.Scopes))
+	for _, scope := range apiKey.Scopes {
+		if sfunc.InSlice(scope, user.Roles) {
+			keyRoles = append(keyRoles, scope)
+		}
+	}
+	if len(keyRoles) == 0 {
+		return nil, rest_err.NewUnauthorizedError("API key tidak memiliki hak akses")
+	}
+
+	if apiErr := a.dao.TouchLastUsed(ctx, apiKey.ID, now); apiErr != nil {
+		return nil, apiErr
+	}
+
+	return &mjwt.CustomClaim{
+		Identity: user.ID,
+		Name:     string(user.Name),
+		Roles:    keyRoles,
+		Exp:      apiKey.ExpiresAt,
+		IssuedAt: apiKey.CreatedAt,
+		Type:     mjwt.APIKey,
+		Fresh:    false,
+		TokenID:  apiKey.ID,
+	}, nil
+}
+
+// audit kegagalan penyimpanan sudah dicatat oleh dao dan tidak menggagalkan proses utama
+func (a *apiKeyService) audit(ctx context.Context, log dto.AuditLog) {
+	_ = a.auditDao.Insert(ctx, log)
+}
+
+func uniqueScopes(scopes []string) []string {
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !sfunc.InSlice(scope, result) {
+			result = append(result, scope)
+		}
+	}
+	return result
+}
@@ -0,0 +1,24 @@
+package apikeyserv
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/mjwt"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+type APIKeyServiceAssumer interface {
+	APIKeyServiceModifier
+	APIKeyServiceReader
+}
+
+type APIKeyServiceModifier interface {
+	CreateServiceAccount(ctx context.Context, request dto.ServiceAccountRequest) (int, rest_err.APIError)
+	CreateAPIKey(ctx context.Context, userID int, request dto.APIKeyRequest, actorID int, ip string) (*dto.APIKeyResponse, rest_err.APIError)
+	RevokeAPIKey(ctx context.Context, keyID string, actorID int, ip string) rest_err.APIError
+}
+
+type APIKeyServiceReader interface {
+	FindAPIKeys(ctx context.Context, userID int) ([]dto.APIKey, rest_err.APIError)
+	AuthenticateAPIKey(ctx context.Context, key string) (*mjwt.CustomClaim, rest_err.APIError)
+}
@@ -0,0 +1,388 @@
+package beritaacaraserv
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/muchlist/berita_acara/configs"
+	"github.com/muchlist/berita_acara/configs/docstatus"
+	"github.com/muchlist/berita_acara/dao/beritaacaradao"
+	"github.com/muchlist/berita_acara/dao/keydao"
+	"github.com/muchlist/berita_acara/dao/userdao"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/mcrypt"
+	"github.com/muchlist/berita_acara/utils/mpdf"
+	"github.com/muchlist/berita_acara/utils/mpki"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"strings"
+	"time"
+)
+
+// verifyTokenLength panjang byte acak token verifikasi, tidak dapat ditebak dan tidak berhubungan dengan id document
+const verifyTokenLength = 32
+
+func NewDocumentService(
+	dao beritaacaradao.DocumentDaoAssumer,
+	userDao userdao.UserReader,
+	keyDao keydao.KeyDaoAssumer,
+	pdf mpdf.PdfAssumer,
+	pki mpki.PkiAssumer,
+) DocumentServiceAssumer {
+	return &documentService{
+		dao:     dao,
+		userDao: userDao,
+		keyDao:  keyDao,
+		pdf:     pdf,
+		pki:     pki,
+	}
+}
+
+type documentService struct {
+	dao     beritaacaradao.DocumentDaoAssumer
+	userDao userdao.UserReader
+	keyDao  keydao.KeyDaoAssumer
+	pdf     mpdf.PdfAssumer
+	pki     mpki.PkiAssumer
+}
+
+// InsertDocument membuat document baru dengan status draft beserta token acak untuk halaman verifikasi publik
+func (d *documentService) InsertDocument(ctx context.Context, document dto.Document) (int, rest_err.APIError) {
+	verifyToken, err := mcrypt.GenerateRandomToken(verifyTokenLength)
+	if err != nil {
+		return 0, err
+	}
+
+	document.VerifyToken = verifyToken
+	document.Type = strings.ToUpper(document.Type)
+	document.Unit = strings.ToUpper(document.Unit)
+	document.Status = docstatus.Draft
+	document.CreatedAt = time.Now().Unix()
+	document.UpdatedAt = time.Now().Unix()
+
+	insertedID, apiErr := d.dao.Insert(ctx, document)
+	if apiErr != nil {
+		return 0, apiErr
+	}
+	return insertedID, nil
+}
+
+// EditDocument
+func (d *documentService) EditDocument(ctx context.Context, request dto.Document) (*dto.Document, rest_err.APIError) {
+	request.Type = strings.ToUpper(request.Type)
+	request.Unit = strings.ToUpper(request.Unit)
+	request.UpdatedAt = time.Now().Unix()
+	result, err := d.dao.Edit(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteDocument
+func (d *documentService) DeleteDocument(ctx context.Context, documentID int, creator int) rest_err.APIError {
+	err := d.dao.Delete(ctx, documentID, creator)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetDocument mendapatkan document dari database
+func (d *documentService) GetDocument(ctx context.Context, documentID int) (*dto.Document, rest_err.APIError) {
+	document, err := d.dao.Get(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// FindDocuments
+func (d *documentService) FindDocuments(ctx context.Context, filter dto.DocumentFilter, limit int, cursor int) ([]dto.Document, rest_err.APIError) {
+	filter.Type = strings.ToUpper(filter.Type)
+	filter.Unit = strings.ToUpper(filter.Unit)
+	documentList, err := d.dao.FindWithCursor(ctx, filter, uint64(limit), cursor)
+	if err != nil {
+		return nil, err
+	}
+	return documentList, nil
+}
+
+// RequestSignature meminta tanda tangan kepada user pada request, document berpindah dari draft
+// menjadi waiting_signature dan mendapatkan nomor surat sesuai skema penomoran unit. Nama dan jabatan penandatangan disalin dari data user saat ini.
+func (d *documentService) RequestSignature(ctx context.Context, documentID int, creator int, request dto.SignatureRequest) (*dto.Document, rest_err.APIError) {
+	signatories := make([]dto.Signatory, len(request.UserIDs))
+	for i, userID := range request.UserIDs {
+		user, err := d.userDao.Get(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if user.ID == 0 {
+			return nil, rest_err.NewBadRequestError(fmt.Sprintf("User %d tidak ditemukan", userID))
+		}
+
+		// urutan yang sama berarti boleh tanda tangan secara paralel
+		order := 1
+		if request.Sequential {
+			order = i + 1
+		}
+
+		signer := signerFromUser(*user)
+		signatories[i] = dto.Signatory{
+			UserID:   user.ID,
+			Name:     user.Name,
+			Position: signer.Position,
+			Order:    order,
+		}
+	}
+
+	// kop surat disalin agar perubahan BA_LETTERHEAD_* tidak merubah pdf yang ditandatangani
+	letterhead := dto.Letterhead{
+		Title:   configs.Config.LETTERHEADTITLE,
+		Address: configs.Config.LETTERHEADADDRESS,
+	}
+	if err := d.dao.RequestSignature(ctx, documentID, creator, signatories, letterhead, time.Now().Unix()); err != nil {
+		return nil, err
+	}
+	return d.dao.Get(ctx, documentID)
+}
+
+// SignDocument menandatangani document oleh userID. Pdf document disusun ulang lalu ditandatangani
+// dengan detached CMS menggunakan key user, signature dan hash SHA-256 pdf disimpan pada penandatangan.
+func (d *documentService) SignDocument(ctx context.Context, documentID int, userID int) (*dto.Document, rest_err.APIError) {
+	document, err := d.dao.Get(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if document.Status != docstatus.WaitingSignature {
+		return nil, rest_err.NewBadRequestError(fmt.Sprintf("Document berstatus %s tidak dapat ditandatangani", document.Status))
+	}
+	// diperiksa sebelum key user dibuat agar hanya penandatangan yang gilirannya tiba yang mendapat sertifikat dari CA,
+	// pemeriksaan diulang oleh dao di dalam transaksi
+	if err := checkSignerTurn(document.Signatories, userID); err != nil {
+		return nil, err
+	}
+
+	pdf, err := d.renderDocument(ctx, *document, "")
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := d.userKey(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := d.pki.Sign(pdf, key.Certificate, key.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256(pdf)
+	_, err = d.dao.Sign(ctx, dto.Signatory{
+		DocumentID: documentID,
+		UserID:     userID,
+		SignedAt:   time.Now().Unix(),
+		Signature:  signature,
+		DocHash:    hex.EncodeToString(hash[:]),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d.dao.Get(ctx, documentID)
+}
+
+// checkSignerTurn memastikan userID adalah penandatangan yang masih pending dan seluruh penandatangan
+// dengan urutan lebih kecil sudah tanda tangan
+func checkSignerTurn(signatories []dto.Signatory, userID int) rest_err.APIError {
+	var signer *dto.Signatory
+	for i := range signatories {
+		if signatories[i].UserID == userID {
+			signer = &signatories[i]
+			break
+		}
+	}
+	if signer == nil {
+		return rest_err.NewUnauthorizedError("Anda bukan penandatangan document ini")
+	}
+	if signer.Status != docstatus.SignerPending {
+		return rest_err.NewBadRequestError(fmt.Sprintf("Tanda tangan anda sudah berstatus %s", signer.Status))
+	}
+	for _, signatory := range signatories {
+		if signatory.Status == docstatus.SignerPending && signatory.Order < signer.Order {
+			return rest_err.NewBadRequestError(fmt.Sprintf("Menunggu tanda tangan %s terlebih dahulu", signatory.Name))
+		}
+	}
+	return nil
+}
+
+// RejectDocument menolak document oleh userID beserta alasannya
+func (d *documentService) RejectDocument(ctx context.Context, documentID int, userID int, note string) (*dto.Document, rest_err.APIError) {
+	if err := d.dao.Reject(ctx, documentID, userID, note, time.Now().Unix()); err != nil {
+		return nil, err
+	}
+	return d.dao.Get(ctx, documentID)
+}
+
+// VerifyDocument memeriksa setiap signature yang tersimpan terhadap pdf document saat ini dan rantai sertifikat CA
+func (d *documentService) VerifyDocument(ctx context.Context, documentID int) (*dto.DocumentVerification, rest_err.APIError) {
+	document, err := d.dao.Get(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	return d.verify(ctx, *document)
+}
+
+// VerifyByToken memeriksa tanda tangan document berdasarkan token pada QR code, digunakan oleh halaman verifikasi publik
+func (d *documentService) VerifyByToken(ctx context.Context, token string) (*dto.DocumentVerification, rest_err.APIError) {
+	document, err := d.dao.GetByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return d.verify(ctx, *document)
+}
+
+func (d *documentService) verify(ctx context.Context, document dto.Document) (*dto.DocumentVerification, rest_err.APIError) {
+	pdf, err := d.renderDocument(ctx, document, "")
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(pdf)
+	currentHash := hex.EncodeToString(hash[:])
+
+	result := dto.DocumentVerification{
+		DocumentID: document.ID,
+		Number:     document.Number,
+		Title:      document.Title,
+		Type:       document.Type,
+		Status:     document.Status,
+		Hash:       currentHash,
+		Valid:      document.Status == docstatus.Signed,
+		Signatures: []dto.SignatureVerification{},
+	}
+	for _, signatory := range document.Signatories {
+		if signatory.Status != docstatus.SignerSigned {
+			continue
+		}
+
+		verification := dto.SignatureVerification{
+			SignatoryID: signatory.ID,
+			Name:        signatory.Name,
+			Position:    signatory.Position,
+			SignedAt:    signatory.SignedAt,
+			HashMatch:   signatory.DocHash == currentHash,
+			Valid:       true,
+		}
+		if apiErr := d.pki.Verify(pdf, signatory.Signature, signatory.UserID); apiErr != nil {
+			verification.Valid = false
+			verification.Message = apiErr.Message()
+		}
+		if !verification.HashMatch || !verification.Valid {
+			result.Valid = false
+		}
+		result.Signatures = append(result.Signatures, verification)
+	}
+
+	return &result, nil
+}
+
+// RenderPDF menyusun document menjadi pdf lengkap dengan QR code menuju halaman verifikasi publik
+func (d *documentService) RenderPDF(ctx context.Context, documentID int) ([]byte, rest_err.APIError) {
+	document, err := d.dao.Get(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	return d.renderDocument(ctx, *document, verifyURL(document.VerifyToken))
+}
+
+// renderDocument menyusun pdf document, blok tanda tangan berisi nama dan jabatan penandatangan,
+// apabila belum ada permintaan tanda tangan maka diisi oleh pembuat document.
+// Pdf kanonik yang ditandatangani dibuat dengan verifyURL kosong dan kop surat salinan document agar
+// signature tidak bergantung pada BA_PUBLIC_URL maupun BA_LETTERHEAD_* yang dapat berubah.
+func (d *documentService) renderDocument(ctx context.Context, document dto.Document, verifyURL string) ([]byte, rest_err.APIError) {
+	signers := make([]mpdf.Signer, len(document.Signatories))
+	for i, signatory := range document.Signatories {
+		signers[i] = mpdf.Signer{
+			Name:     string(signatory.Name),
+			Position: signatory.Position,
+		}
+	}
+	if len(signers) == 0 {
+		creator, err := d.userDao.Get(ctx, document.Creator)
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, signerFromUser(*creator))
+	}
+
+	var letterhead *mpdf.Letterhead
+	if document.Letterhead != nil {
+		letterhead = &mpdf.Letterhead{
+			Title:   document.Letterhead.Title,
+			Address: document.Letterhead.Address,
+		}
+	}
+
+	return d.pdf.Render(mpdf.Document{
+		Number:     document.Number,
+		Title:      document.Title,
+		Type:       document.Type,
+		Body:       document.Body,
+		CreatedAt:  time.Unix(document.CreatedAt, 0),
+		Signers:    signers,
+		Letterhead: letterhead,
+		VerifyURL:  verifyURL,
+	})
+}
+
+// verifyURL alamat halaman verifikasi publik, kosong untuk document yang belum memiliki token
+func verifyURL(token string) string {
+	if token == "" {
+		return ""
+	}
+	return configs.Config.PUBLICURL + "/verify/" + token
+}
+
+// userKey mendapatkan key user, key baru diterbitkan jika belum ada atau sudah kadaluarsa
+func (d *documentService) userKey(ctx context.Context, userID int) (*dto.UserKey, rest_err.APIError) {
+	key, err := d.keyDao.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if key != nil && key.NotAfter > time.Now().Unix() {
+		return key, nil
+	}
+
+	user, err := d.userDao.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	certificate, privateKey, notAfter, err := d.pki.IssueUserKey(user.ID, string(user.Name))
+	if err != nil {
+		return nil, err
+	}
+	key = &dto.UserKey{
+		UserID:      user.ID,
+		Certificate: certificate,
+		PrivateKey:  privateKey,
+		NotAfter:    notAfter.Unix(),
+		CreatedAt:   time.Now().Unix(),
+	}
+	if err := d.keyDao.Upsert(ctx, *key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// signerFromUser jabatan yang kosong diganti dengan nama role pertama user
+func signerFromUser(user dto.User) mpdf.Signer {
+	position := user.Position
+	if position == "" && len(user.Roles) > 0 {
+		position = user.Roles[0]
+	}
+	return mpdf.Signer{
+		Name:     string(user.Name),
+		Position: position,
+	}
+}
@@ -0,0 +1,33 @@
+package beritaacaraserv
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+type DocumentServiceAssumer interface {
+	DocumentServiceModifier
+	DocumentServiceSigner
+	DocumentServiceReader
+}
+
+type DocumentServiceModifier interface {
+	InsertDocument(ctx context.Context, document dto.Document) (int, rest_err.APIError)
+	EditDocument(ctx context.Context, request dto.Document) (*dto.Document, rest_err.APIError)
+	DeleteDocument(ctx context.Context, documentID int, creator int) rest_err.APIError
+}
+
+type DocumentServiceSigner interface {
+	RequestSignature(ctx context.Context, documentID int, creator int, request dto.SignatureRequest) (*dto.Document, rest_err.APIError)
+	SignDocument(ctx context.Context, documentID int, userID int) (*dto.Document, rest_err.APIError)
+	RejectDocument(ctx context.Context, documentID int, userID int, note string) (*dto.Document, rest_err.APIError)
+}
+
+type DocumentServiceReader interface {
+	GetDocument(ctx context.Context, documentID int) (*dto.Document, rest_err.APIError)
+	FindDocuments(ctx context.Context, filter dto.DocumentFilter, limit int, cursor int) ([]dto.Document, rest_err.APIError)
+	RenderPDF(ctx context.Context, documentID int) ([]byte, rest_err.APIError)
+	VerifyDocument(ctx context.Context, documentID int) (*dto.DocumentVerification, rest_err.APIError)
+	VerifyByToken(ctx context.Context, token string) (*dto.DocumentVerification, rest_err.APIError)
+}
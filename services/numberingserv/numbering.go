@@ -0,0 +1,64 @@
+package numberingserv
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dao/numberingdao"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/mnumber"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"strings"
+	"time"
+)
+
+func NewNumberingService(dao numberingdao.NumberingDaoAssumer) NumberingServiceAssumer {
+	return &numberingService{
+		dao: dao,
+	}
+}
+
+type numberingService struct {
+	dao numberingdao.NumberingDaoAssumer
+}
+
+// UpsertScheme membuat atau merubah skema penomoran unit
+func (n *numberingService) UpsertScheme(ctx context.Context, scheme dto.NumberingScheme) (*dto.NumberingScheme, rest_err.APIError) {
+	scheme.Unit = strings.ToUpper(scheme.Unit)
+	if !mnumber.IsValidUnit(scheme.Unit) {
+		return nil, rest_err.NewBadRequestError("unit hanya boleh berisi huruf besar, angka, - dan _")
+	}
+	scheme.CreatedAt = time.Now().Unix()
+	scheme.UpdatedAt = time.Now().Unix()
+
+	result, err := n.dao.Upsert(ctx, scheme)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteScheme
+func (n *numberingService) DeleteScheme(ctx context.Context, unit string) rest_err.APIError {
+	err := n.dao.Delete(ctx, strings.ToUpper(unit))
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetScheme mendapatkan skema penomoran unit, unit tanpa skema menggunakan mnumber.DefaultPattern
+func (n *numberingService) GetScheme(ctx context.Context, unit string) (*dto.NumberingScheme, rest_err.APIError) {
+	scheme, err := n.dao.Get(ctx, strings.ToUpper(unit))
+	if err != nil {
+		return nil, err
+	}
+	return scheme, nil
+}
+
+// FindSchemes
+func (n *numberingService) FindSchemes(ctx context.Context) ([]dto.NumberingScheme, rest_err.APIError) {
+	schemes, err := n.dao.Find(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return schemes, nil
+}
@@ -0,0 +1,14 @@
+package numberingserv
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+type NumberingServiceAssumer interface {
+	UpsertScheme(ctx context.Context, scheme dto.NumberingScheme) (*dto.NumberingScheme, rest_err.APIError)
+	DeleteScheme(ctx context.Context, unit string) rest_err.APIError
+	GetScheme(ctx context.Context, unit string) (*dto.NumberingScheme, rest_err.APIError)
+	FindSchemes(ctx context.Context) ([]dto.NumberingScheme, rest_err.APIError)
+}
@@ -0,0 +1,213 @@
+package roleserv
+
+import (
+	"context"
+	"fmt"
+	"github.com/muchlist/berita_acara/configs/permission"
+	"github.com/muchlist/berita_acara/configs/roles"
+	"github.com/muchlist/berita_acara/dao/roledao"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"strings"
+	"sync"
+	"time"
+)
+
+func NewRoleService(dao roledao.RoleDaoAssumer) RoleServiceAssumer {
+	return &roleService{
+		dao: dao,
+	}
+}
+
+type roleService struct {
+	dao roledao.RoleDaoAssumer
+
+	// permissionCache pasangan nama role dan permissionnya, nil berarti harus dimuat ulang dari database.
+	// generation bertambah setiap invalidate agar hasil pemuatan yang sudah usang tidak disimpan.
+	mu              sync.RWMutex
+	permissionCache map[string][]string
+	generation      uint64
+}
+
+// LoadRoles membuat role bawaan apabila belum ada beserta permission bawaan ADMIN lalu memuat
+// seluruh role dari database ke roles.GetRolesAvailable, dipanggil sekali saat aplikasi start
+func (r *roleService) LoadRoles(ctx context.Context) rest_err.APIError {
+	if err := r.dao.Seed(ctx, roles.GetRolesDefault(), time.Now().Unix()); err != nil {
+		return err
+	}
+	if err := r.dao.AddPermissions(ctx, roles.RoleAdmin, permission.GetAdminDefault(), time.Now().Unix()); err != nil {
+		return err
+	}
+	return r.refresh(ctx)
+}
+
+// HasPermission return true jika salah satu role memiliki permission. Pasangan role dan permission
+// disimpan di memory dan dimuat ulang setelah terjadi perubahan role maupun permission.
+func (r *roleService) HasPermission(ctx context.Context, roleNames []string, permissionName string) (bool, rest_err.APIError) {
+	cache, err := r.permissions(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, roleName := range roleNames {
+		for _, p := range cache[roleName] {
+			if p == permissionName {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// InsertRole
+func (r *roleService) InsertRole(ctx context.Context, name string) rest_err.APIError {
+	err := r.dao.Insert(ctx, dto.Role{
+		Name:      strings.ToUpper(name),
+		CreatedAt: time.Now().Unix(),
+		UpdatedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		return err
+	}
+	return r.refresh(ctx)
+}
+
+// RenameRole merubah nama role, user yang memiliki role tersebut ikut berubah.
+// Role bawaan tidak dapat diubah karena digunakan langsung pada kode.
+func (r *roleService) RenameRole(ctx context.Context, oldName string, newName string) (*dto.Role, rest_err.APIError) {
+	oldName = strings.ToUpper(oldName)
+	newName = strings.ToUpper(newName)
+	if roles.IsDefault(oldName) {
+		return nil, rest_err.NewBadRequestError(fmt.Sprintf("Role bawaan %s tidak dapat diubah", oldName))
+	}
+
+	if err := r.dao.Rename(ctx, oldName, newName, time.Now().Unix()); err != nil {
+		return nil, err
+	}
+	if err := r.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return r.dao.Get(ctx, newName)
+}
+
+// DeleteRole role bawaan dan role yang masih dimiliki user tidak dapat dihapus
+func (r *roleService) DeleteRole(ctx context.Context, name string) rest_err.APIError {
+	name = strings.ToUpper(name)
+	if roles.IsDefault(name) {
+		return rest_err.NewBadRequestError(fmt.Sprintf("Role bawaan %s tidak dapat dihapus", name))
+	}
+
+	if err := r.dao.Delete(ctx, name); err != nil {
+		return err
+	}
+	return r.refresh(ctx)
+}
+
+// AddPermissions
+func (r *roleService) AddPermissions(ctx context.Context, name string, permissions []string) (*dto.Role, rest_err.APIError) {
+	role, err := r.dao.Get(ctx, strings.ToUpper(name))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.dao.AddPermissions(ctx, role.Name, permissions, time.Now().Unix()); err != nil {
+		return nil, err
+	}
+	r.invalidate()
+	return r.dao.Get(ctx, role.Name)
+}
+
+// RemovePermission permission bawaan ADMIN tidak dapat dihapus agar pengelolaan role tetap dapat diakses
+func (r *roleService) RemovePermission(ctx context.Context, name string, permissionName string) (*dto.Role, rest_err.APIError) {
+	name = strings.ToUpper(name)
+	if name == roles.RoleAdmin {
+		for _, p := range permission.GetAdminDefault() {
+			if p == permissionName {
+				return nil, rest_err.NewBadRequestError(fmt.Sprintf("Permission bawaan %s tidak dapat dihapus dari %s", permissionName, name))
+			}
+		}
+	}
+	if err := r.dao.RemovePermission(ctx, name, permissionName); err != nil {
+		return nil, err
+	}
+	r.invalidate()
+	return r.dao.Get(ctx, name)
+}
+
+// GetRole mendapatkan role beserta permissionnya
+func (r *roleService) GetRole(ctx context.Context, name string) (*dto.Role, rest_err.APIError) {
+	role, err := r.dao.Get(ctx, strings.ToUpper(name))
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// FindRoles
+func (r *roleService) FindRoles(ctx context.Context) ([]dto.Role, rest_err.APIError) {
+	roleList, err := r.dao.Find(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return roleList, nil
+}
+
+// refresh memperbarui daftar role yang digunakan untuk validasi input user sekaligus cache permission
+func (r *roleService) refresh(ctx context.Context) rest_err.APIError {
+	roleList, err := r.dao.Find(ctx)
+	if err != nil {
+		return err
+	}
+	names := make([]string, len(roleList))
+	for i, role := range roleList {
+		names[i] = role.Name
+	}
+	roles.SetRolesAvailable(names)
+	r.setPermissions(roleList)
+	return nil
+}
+
+func (r *roleService) permissions(ctx context.Context) (map[string][]string, rest_err.APIError) {
+	r.mu.RLock()
+	cache := r.permissionCache
+	generation := r.generation
+	r.mu.RUnlock()
+	if cache != nil {
+		return cache, nil
+	}
+
+	roleList, err := r.dao.Find(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cache = permissionMap(roleList)
+	r.mu.Lock()
+	if r.generation == generation {
+		r.permissionCache = cache
+	}
+	r.mu.Unlock()
+	return cache, nil
+}
+
+func (r *roleService) setPermissions(roleList []dto.Role) {
+	cache := permissionMap(roleList)
+	r.mu.Lock()
+	r.permissionCache = cache
+	r.generation++
+	r.mu.Unlock()
+}
+
+func (r *roleService) invalidate() {
+	r.mu.Lock()
+	r.permissionCache = nil
+	r.generation++
+	r.mu.Unlock()
+}
+
+func permissionMap(roleList []dto.Role) map[string][]string {
+	cache := make(map[string][]string, len(roleList))
+	for _, role := range roleList {
+		cache[role.Name] = role.Permissions
+	}
+	return cache
+}
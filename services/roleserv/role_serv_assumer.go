@@ -0,0 +1,27 @@
+package roleserv
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+type RoleServiceAssumer interface {
+	RoleServiceModifier
+	RoleServiceReader
+}
+
+type RoleServiceModifier interface {
+	LoadRoles(ctx context.Context) rest_err.APIError
+	InsertRole(ctx context.Context, name string) rest_err.APIError
+	RenameRole(ctx context.Context, oldName string, newName string) (*dto.Role, rest_err.APIError)
+	DeleteRole(ctx context.Context, name string) rest_err.APIError
+	AddPermissions(ctx context.Context, name string, permissions []string) (*dto.Role, rest_err.APIError)
+	RemovePermission(ctx context.Context, name string, permission string) (*dto.Role, rest_err.APIError)
+}
+
+type RoleServiceReader interface {
+	HasPermission(ctx context.Context, roleNames []string, permissionName string) (bool, rest_err.APIError)
+	GetRole(ctx context.Context, name string) (*dto.Role, rest_err.APIError)
+	FindRoles(ctx context.Context) ([]dto.Role, rest_err.APIError)
+}
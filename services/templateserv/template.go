@@ -0,0 +1,92 @@
+package templateserv
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dao/templatedao"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/mtemplate"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"strings"
+	"time"
+)
+
+func NewTemplateService(dao templatedao.TemplateDaoAssumer) TemplateServiceAssumer {
+	return &templateService{
+		dao: dao,
+	}
+}
+
+type templateService struct {
+	dao templatedao.TemplateDaoAssumer
+}
+
+// InsertTemplate
+func (t *templateService) InsertTemplate(ctx context.Context, template dto.Template) (int, rest_err.APIError) {
+	template.Type = strings.ToUpper(template.Type)
+	template.CreatedAt = time.Now().Unix()
+	template.UpdatedAt = time.Now().Unix()
+
+	insertedID, err := t.dao.Insert(ctx, template)
+	if err != nil {
+		return 0, err
+	}
+	return insertedID, nil
+}
+
+// EditTemplate
+func (t *templateService) EditTemplate(ctx context.Context, request dto.Template) (*dto.Template, rest_err.APIError) {
+	request.Type = strings.ToUpper(request.Type)
+	request.UpdatedAt = time.Now().Unix()
+	result, err := t.dao.Edit(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteTemplate
+func (t *templateService) DeleteTemplate(ctx context.Context, templateID int) rest_err.APIError {
+	err := t.dao.Delete(ctx, templateID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetTemplate mendapatkan template dari database
+func (t *templateService) GetTemplate(ctx context.Context, templateID int) (*dto.Template, rest_err.APIError) {
+	template, err := t.dao.Get(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// FindTemplates
+func (t *templateService) FindTemplates(ctx context.Context, search string, limit int, cursor int) ([]dto.Template, rest_err.APIError) {
+	templateList, err := t.dao.FindWithCursor(ctx, search, uint64(limit), cursor)
+	if err != nil {
+		return nil, err
+	}
+	return templateList, nil
+}
+
+// RenderTemplate mengisi placeholder pada judul dan isi template dengan values dari request,
+// field yang required wajib diisi
+func (t *templateService) RenderTemplate(ctx context.Context, templateID int, request dto.TemplateRenderRequest) (*dto.TemplateRenderResponse, rest_err.APIError) {
+	template, err := t.dao.Get(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := request.Validate(template.Fields); err != nil {
+		return nil, rest_err.NewBadRequestError(err.Error())
+	}
+
+	return &dto.TemplateRenderResponse{
+		TemplateID: template.ID,
+		Title:      mtemplate.Render(template.Title, request.Values),
+		Type:       template.Type,
+		Body:       mtemplate.Render(template.Body, request.Values),
+	}, nil
+}
@@ -0,0 +1,24 @@
+package templateserv
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+)
+
+type TemplateServiceAssumer interface {
+	TemplateServiceModifier
+	TemplateServiceReader
+}
+
+type TemplateServiceModifier interface {
+	InsertTemplate(ctx context.Context, template dto.Template) (int, rest_err.APIError)
+	EditTemplate(ctx context.Context, request dto.Template) (*dto.Template, rest_err.APIError)
+	DeleteTemplate(ctx context.Context, templateID int) rest_err.APIError
+}
+
+type TemplateServiceReader interface {
+	GetTemplate(ctx context.Context, templateID int) (*dto.Template, rest_err.APIError)
+	FindTemplates(ctx context.Context, search string, limit int, cursor int) ([]dto.Template, rest_err.APIError)
+	RenderTemplate(ctx context.Context, templateID int, request dto.TemplateRenderRequest) (*dto.TemplateRenderResponse, rest_err.APIError)
+}
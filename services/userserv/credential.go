@@ -0,0 +1,85 @@
+package userserv
+
+import (
+	"context"
+	"fmt"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// checkCredential memeriksa password sesuai BA_AUTH_BACKEND, return false tanpa error apabila password salah.
+// Backend local membandingkan hash pada tabel users, backend ldap melakukan bind ke directory menggunakan email user
+// lalu menyinkronkan role user dari grup LDAP sehingga user.Roles dapat berubah
+func (u *userService) checkCredential(ctx context.Context, user *dto.User, password string, ip string) (bool, rest_err.APIError) {
+	if !u.ldap.Enabled() {
+		if !u.crypto.IsPWAndHashPWMatch(password, user.Password) {
+			return false, nil
+		}
+		u.rehashPassword(ctx, *user, password)
+		return true, nil
+	}
+
+	entry, apiErr := u.ldap.Authenticate(user.Email, password)
+	if apiErr != nil {
+		if apiErr.Status() == http.StatusUnauthorized {
+			return false, nil
+		}
+		return false, apiErr
+	}
+	return true, u.syncRoles(ctx, user, entry.Roles, ip)
+}
+
+// syncRoles menyimpan role hasil pemetaan grup LDAP ke users_roles apabila berbeda dengan role user saat ini
+func (u *userService) syncRoles(ctx context.Context, user *dto.User, roles []string, ip string) rest_err.APIError {
+	if len(roles) == 0 {
+		return rest_err.NewUnauthorizedError("Akun tidak tergabung dalam grup LDAP yang memiliki akses")
+	}
+	if sameRoles(user.Roles, roles) {
+		return nil
+	}
+
+	now := time.Now().Unix()
+	if apiErr := u.dao.ChangeRoles(ctx, user.ID, roles, now); apiErr != nil {
+		return apiErr
+	}
+	// access token yang masih membawa role lama tidak boleh digunakan lagi
+	if apiErr := u.denylist.DenyUser(ctx, user.ID, now); apiErr != nil {
+		return apiErr
+	}
+	u.audit(ctx, dto.AuditLog{
+		Action:    dto.AuditRolesSynced,
+		UserID:    user.ID,
+		ActorID:   user.ID,
+		IP:        ip,
+		Detail:    fmt.Sprintf("%s -> %s", strings.Join(user.Roles, ","), strings.Join(roles, ",")),
+		CreatedAt: now,
+	})
+	user.Roles = roles
+	return nil
+}
+
+// localPasswordOnly menolak perubahan password apabila password dikelola oleh LDAP
+func (u *userService) localPasswordOnly() rest_err.APIError {
+	if u.ldap.Enabled() {
+		return rest_err.NewBadRequestError("Password dikelola oleh LDAP, perubahan password dilakukan melalui directory")
+	}
+	return nil
+}
+
+func sameRoles(a []string, b []string) bool {
+	setA := make(map[string]bool, len(a))
+	for _, role := range a {
+		setA[role] = true
+	}
+	setB := make(map[string]bool, len(b))
+	for _, role := range b {
+		if !setA[role] {
+			return false
+		}
+		setB[role] = true
+	}
+	return len(setA) == len(setB)
+}
@@ -0,0 +1,92 @@
+package userserv
+
+import (
+	"context"
+	"fmt"
+	"github.com/muchlist/berita_acara/configs"
+	"github.com/muchlist/berita_acara/configs/roles"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/mcrypt"
+	"github.com/muchlist/berita_acara/utils/mjwt"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/muchlist/berita_acara/utils/sfunc"
+	"time"
+)
+
+// Impersonate menerbitkan access token atas nama user untuk admin (actorID) agar dapat melihat aplikasi seperti user
+// tersebut. Token berumur BA_IMPERSONATE_TOKEN_MINUTE, tidak memiliki refresh token dan tidak pernah fresh.
+// User dengan role ADMIN dan service account tidak dapat di-impersonate.
+func (u *userService) Impersonate(ctx context.Context, userID int, actorID int, ip string) (*dto.ImpersonateResponse, rest_err.APIError) {
+	if userID == actorID {
+		return nil, rest_err.NewBadRequestError("Tidak dapat melakukan impersonation terhadap akun sendiri")
+	}
+
+	user, apiErr := u.dao.Get(ctx, userID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if user.ID == 0 {
+		return nil, rest_err.NewBadRequestError(fmt.Sprintf("User dengan username %d tidak ditemukan", userID))
+	}
+	if user.ServiceAccount {
+		return nil, rest_err.NewBadRequestError("Service account tidak dapat di-impersonate")
+	}
+	if sfunc.InSlice(roles.RoleAdmin, user.Roles) {
+		return nil, rest_err.NewBadRequestError(fmt.Sprintf("User dengan role %s tidak dapat di-impersonate", roles.RoleAdmin))
+	}
+
+	tokenID, apiErr := mcrypt.GenerateRandomToken(tokenIDLength)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	issuedAt, apiErr := u.issuedAfterCutoff(ctx, user.ID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	accessToken, expired, apiErr := u.jwt.GenerateToken(mjwt.CustomClaim{
+		Identity:    user.ID,
+		Name:        string(user.Name),
+		Roles:       user.Roles,
+		ExtraMinute: time.Duration(configs.Config.IMPERSONATETOKENMINUTE),
+		Type:        mjwt.Access,
+		Fresh:       false,
+		TokenID:     tokenID,
+		IssuedAt:    issuedAt,
+		ActorID:     actorID,
+	})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	u.audit(ctx, dto.AuditLog{
+		Action:    dto.AuditImpersonationStarted,
+		UserID:    user.ID,
+		ActorID:   actorID,
+		IP:        ip,
+		Detail:    fmt.Sprintf("token %s", tokenID),
+		CreatedAt: time.Now().Unix(),
+	})
+
+	return &dto.ImpersonateResponse{
+		ID:          user.ID,
+		Email:       user.Email,
+		Name:        string(user.Name),
+		Roles:       user.Roles,
+		ActorID:     actorID,
+		AccessToken: accessToken,
+		Expired:     expired,
+	}, nil
+}
+
+// RecordImpersonatedRequest mencatat request yang menggunakan token impersonation, dipanggil oleh middleware.
+// Berbeda dengan audit lainnya kegagalan pencatatan dikembalikan agar request tidak diteruskan tanpa tercatat
+func (u *userService) RecordImpersonatedRequest(ctx context.Context, claims *mjwt.CustomClaim, method string, path string, ip string) rest_err.APIError {
+	return u.auditDao.Insert(ctx, dto.AuditLog{
+		Action:    dto.AuditImpersonatedRequest,
+		UserID:    claims.Identity,
+		ActorID:   claims.ActorID,
+		IP:        ip,
+		Detail:    fmt.Sprintf("%s %s token %s", method, path, claims.TokenID),
+		CreatedAt: time.Now().Unix(),
+	})
+}
@@ -0,0 +1,136 @@
+package userserv
+
+import (
+	"context"
+	"fmt"
+	"github.com/muchlist/berita_acara/configs"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/mlockout"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// loginClaimSecond batas waktu klaim percobaan login, mencakup timeout server LDAP
+const loginClaimSecond = 30
+
+func accountSubject(userID int) string {
+	return "user:" + strconv.Itoa(userID)
+}
+
+// emailSubject hanya digunakan untuk email yang tidak terdaftar, login dengan email milik user
+// terdaftar dihitung menggunakan accountSubject agar berbagi hitungan dengan login menggunakan user_id
+func emailSubject(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipSubject(ip string) string {
+	return "ip:" + ip
+}
+
+func accountPolicy() mlockout.Policy {
+	return mlockout.NewPolicy(configs.Config.LOGINMAXFAILURE, configs.Config.LOGINBACKOFFSECOND, configs.Config.LOGINLOCKMINUTE)
+}
+
+func ipPolicy() mlockout.Policy {
+	return mlockout.NewPolicy(configs.Config.LOGINMAXFAILUREIP, configs.Config.LOGINBACKOFFSECOND, configs.Config.LOGINLOCKMINUTE)
+}
+
+// claimLogin menolak login apabila user atau ip sedang diblokir lalu mengklaim subject secara atomik sebelum
+// credential diperiksa, sehingga percobaan paralel tidak dapat melewati backoff sebelum kegagalan sebelumnya
+// tercatat. Klaim dilepas oleh fungsi yang dikembalikan dan berakhir sendiri setelah loginClaimSecond.
+// Pesan tidak menyebutkan mana yang diblokir agar tidak dapat digunakan untuk menebak user yang terdaftar.
+func (u *userService) claimLogin(ctx context.Context, subject string, ip string) (func(), rest_err.APIError) {
+	now := time.Now().Unix()
+	blockedUntil, apiErr := u.attemptDao.GetBlockedUntil(ctx, subject, ipSubject(ip))
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if blockedUntil <= now {
+		claimed, apiErr := u.attemptDao.Claim(ctx, subject, now, now+loginClaimSecond)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		if claimed {
+			return func() { _ = u.attemptDao.Release(ctx, subject) }, nil
+		}
+
+		// kegagalan percobaan lain dapat tercatat di antara pemeriksaan blokir dan klaim
+		blockedUntil, apiErr = u.attemptDao.GetBlockedUntil(ctx, subject)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		if blockedUntil <= now {
+			return nil, rest_err.NewAPIError(
+				"Percobaan login lain sedang diproses, coba lagi beberapa saat lagi",
+				http.StatusTooManyRequests,
+				"too_many_requests",
+				[]interface{}{},
+			)
+		}
+	}
+	return nil, rest_err.NewAPIError(
+		fmt.Sprintf("Terlalu banyak percobaan login gagal, coba lagi dalam %d detik", blockedUntil-now),
+		http.StatusTooManyRequests,
+		"too_many_requests",
+		[]interface{}{},
+	)
+}
+
+// recordLoginFailure mencatat kegagalan login untuk subject user dan ip, memblokir percobaan berikutnya
+// sesuai policy dan mencatat audit log. userID bernilai 0 apabila login menggunakan email yang tidak terdaftar.
+func (u *userService) recordLoginFailure(ctx context.Context, subject string, userID int, ip string, reason string) rest_err.APIError {
+	now := time.Now()
+	u.audit(ctx, dto.AuditLog{
+		Action:    dto.AuditLoginFailed,
+		UserID:    userID,
+		IP:        ip,
+		Detail:    fmt.Sprintf("%s (%s)", reason, subject),
+		CreatedAt: now.Unix(),
+	})
+
+	for key, policy := range map[string]mlockout.Policy{
+		subject:       accountPolicy(),
+		ipSubject(ip): ipPolicy(),
+	} {
+		failures, apiErr := u.attemptDao.RecordFailure(ctx, key, now.Unix(), now.Add(-policy.LockDuration).Unix())
+		if apiErr != nil {
+			return apiErr
+		}
+		if apiErr := u.attemptDao.Block(ctx, key, now.Add(policy.Delay(failures)).Unix()); apiErr != nil {
+			return apiErr
+		}
+		if failures == policy.MaxFailure {
+			u.audit(ctx, dto.AuditLog{
+				Action:    dto.AuditLoginLocked,
+				UserID:    userID,
+				IP:        ip,
+				Detail:    fmt.Sprintf("%s dikunci selama %s setelah %d kali gagal", key, policy.LockDuration, failures),
+				CreatedAt: now.Unix(),
+			})
+		}
+	}
+	return nil
+}
+
+// UnlockUser menghapus kunci login user yang dilakukan oleh admin actorID
+func (u *userService) UnlockUser(ctx context.Context, userID int, actorID int, ip string) rest_err.APIError {
+	if apiErr := u.attemptDao.Reset(ctx, accountSubject(userID)); apiErr != nil {
+		return apiErr
+	}
+	u.audit(ctx, dto.AuditLog{
+		Action:    dto.AuditAccountUnlocked,
+		UserID:    userID,
+		ActorID:   actorID,
+		IP:        ip,
+		CreatedAt: time.Now().Unix(),
+	})
+	return nil
+}
+
+// audit menyimpan audit log, kegagalan penyimpanan sudah dicatat oleh dao dan tidak menggagalkan proses utama
+func (u *userService) audit(ctx context.Context, log dto.AuditLog) {
+	_ = u.auditDao.Insert(ctx, log)
+}
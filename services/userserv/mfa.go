@@ -0,0 +1,273 @@
+package userserv
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/configs"
+	"github.com/muchlist/berita_acara/configs/roles"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/mcrypt"
+	"github.com/muchlist/berita_acara/utils/mjwt"
+	"github.com/muchlist/berita_acara/utils/mtotp"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/muchlist/berita_acara/utils/sfunc"
+	"net/http"
+	"time"
+)
+
+const (
+	mfaChallengeMinute = 5
+	recoveryCodeCount  = 10
+)
+
+// LoginMFA langkah kedua login, code berupa code TOTP atau recovery code
+func (u *userService) LoginMFA(ctx context.Context, request dto.MFALoginRequest, ip string, userAgent string) (*dto.UserLoginResponse, rest_err.APIError) {
+	claims, apiErr := u.readMFAChallenge(ctx, request.MFAToken)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	release, apiErr := u.claimLogin(ctx, accountSubject(claims.Identity), ip)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	defer release()
+
+	user, apiErr := u.dao.Get(ctx, claims.Identity)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if user.ID == 0 {
+		return nil, rest_err.NewUnauthorizedError("User tidak ditemukan, silakan login kembali")
+	}
+
+	mfa, apiErr := u.mfaDao.Get(ctx, user.ID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if mfa == nil || !mfa.Enabled {
+		return nil, rest_err.NewBadRequestError("MFA belum aktif, lakukan pendaftaran MFA terlebih dahulu")
+	}
+
+	if apiErr := u.verifyMFACode(ctx, *mfa, request.Code); apiErr != nil {
+		// code salah ikut dihitung sebagai login gagal agar code tidak dapat ditebak selama mfa_token berlaku
+		if apiErr.Status() == http.StatusUnauthorized {
+			if recordErr := u.recordLoginFailure(ctx, accountSubject(user.ID), user.ID, ip, "code MFA salah"); recordErr != nil {
+				return nil, recordErr
+			}
+		}
+		return nil, apiErr
+	}
+
+	// mfa_token hanya dapat ditukar sekali
+	apiErr = u.denylist.DenyToken(ctx, dto.DeniedToken{
+		TokenID:   claims.TokenID,
+		UserID:    claims.Identity,
+		ExpiresAt: claims.Exp,
+		CreatedAt: time.Now().Unix(),
+	})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	return u.issueTokens(ctx, *user, ip, userAgent)
+}
+
+// EnrollMFA membuat secret TOTP baru yang belum aktif sampai dikonfirmasi melalui EnableMFA
+func (u *userService) EnrollMFA(ctx context.Context, userID int) (*dto.MFAEnrollResponse, rest_err.APIError) {
+	user, apiErr := u.dao.Get(ctx, userID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if user.ID == 0 {
+		return nil, rest_err.NewBadRequestError("User tidak ditemukan")
+	}
+
+	secret, uri, apiErr := u.totp.Generate(user.Email)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	encryptedSecret, apiErr := u.totp.EncryptSecret(secret)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	png, apiErr := u.totp.QRCode(uri)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	now := time.Now().Unix()
+	apiErr = u.mfaDao.Enroll(ctx, dto.UserMFA{
+		UserID:    user.ID,
+		Secret:    encryptedSecret,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	return &dto.MFAEnrollResponse{
+		Secret: secret,
+		URI:    uri,
+		QRCode: mtotp.FormatQRDataURI(png),
+	}, nil
+}
+
+// EnableMFA mengaktifkan MFA setelah code TOTP pertama cocok dan membuat recovery code.
+// Jika dipanggil dengan mfa_token (pendaftaran wajib saat login) maka token login ikut dikembalikan.
+func (u *userService) EnableMFA(ctx context.Context, claims mjwt.CustomClaim, code string, ip string, userAgent string) (*dto.MFAEnableResponse, rest_err.APIError) {
+	mfa, apiErr := u.mfaDao.Get(ctx, claims.Identity)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if mfa == nil {
+		return nil, rest_err.NewBadRequestError("MFA belum dienroll")
+	}
+	if mfa.Enabled {
+		return nil, rest_err.NewBadRequestError("MFA sudah aktif")
+	}
+
+	secret, apiErr := u.totp.DecryptSecret(mfa.Secret)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	step, valid := u.totp.Validate(secret, code, time.Now())
+	if !valid {
+		return nil, rest_err.NewBadRequestError("Code MFA tidak valid")
+	}
+
+	recoveryCodes, apiErr := mtotp.GenerateRecoveryCodes(recoveryCodeCount)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	hashes := make([]string, len(recoveryCodes))
+	for i, recoveryCode := range recoveryCodes {
+		hashes[i] = mtotp.HashRecoveryCode(recoveryCode)
+	}
+
+	if apiErr := u.mfaDao.Enable(ctx, claims.Identity, step, hashes, time.Now().Unix()); apiErr != nil {
+		return nil, apiErr
+	}
+
+	response := dto.MFAEnableResponse{RecoveryCodes: recoveryCodes}
+	if claims.Type == mjwt.MFAChallenge {
+		user, apiErr := u.dao.Get(ctx, claims.Identity)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		apiErr = u.denylist.DenyToken(ctx, dto.DeniedToken{
+			TokenID:   claims.TokenID,
+			UserID:    claims.Identity,
+			ExpiresAt: claims.Exp,
+			CreatedAt: time.Now().Unix(),
+		})
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		response.Login, apiErr = u.issueTokens(ctx, *user, ip, userAgent)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+	}
+	return &response, nil
+}
+
+// DisableMFA menonaktifkan MFA setelah code TOTP atau recovery code cocok.
+// Ditolak apabila MFA wajib untuk role user.
+func (u *userService) DisableMFA(ctx context.Context, claims mjwt.CustomClaim, code string) rest_err.APIError {
+	if isMFARequired(claims.Roles) {
+		return rest_err.NewBadRequestError("MFA wajib untuk role " + roles.RoleAdmin)
+	}
+
+	mfa, apiErr := u.mfaDao.Get(ctx, claims.Identity)
+	if apiErr != nil {
+		return apiErr
+	}
+	if mfa == nil || !mfa.Enabled {
+		return rest_err.NewBadRequestError("MFA belum aktif")
+	}
+
+	if apiErr := u.verifyMFACode(ctx, *mfa, code); apiErr != nil {
+		return apiErr
+	}
+	return u.mfaDao.Delete(ctx, claims.Identity)
+}
+
+// verifyMFACode mencocokkan code TOTP (sekali pakai per step) atau recovery code (sekali pakai)
+func (u *userService) verifyMFACode(ctx context.Context, mfa dto.UserMFA, code string) rest_err.APIError {
+	if !mtotp.IsTotpCode(code) {
+		return u.mfaDao.UseRecoveryCode(ctx, mfa.UserID, mtotp.HashRecoveryCode(code), time.Now().Unix())
+	}
+
+	secret, apiErr := u.totp.DecryptSecret(mfa.Secret)
+	if apiErr != nil {
+		return apiErr
+	}
+	step, valid := u.totp.Validate(secret, code, time.Now())
+	if !valid {
+		return rest_err.NewUnauthorizedError("Code MFA tidak valid")
+	}
+	return u.mfaDao.UseStep(ctx, mfa.UserID, step)
+}
+
+// mfaChallenge membuat token berumur pendek yang hanya dapat digunakan untuk langkah kedua login
+// atau pendaftaran MFA apabila enrollment bernilai true
+func (u *userService) mfaChallenge(ctx context.Context, user dto.User, enrollment bool) (*dto.UserLoginResponse, rest_err.APIError) {
+	tokenID, apiErr := mcrypt.GenerateRandomToken(tokenIDLength)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	issuedAt, apiErr := u.issuedAfterCutoff(ctx, user.ID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	mfaToken, expired, apiErr := u.jwt.GenerateToken(mjwt.CustomClaim{
+		Identity:    user.ID,
+		Name:        string(user.Name),
+		Roles:       user.Roles,
+		ExtraMinute: mfaChallengeMinute,
+		Type:        mjwt.MFAChallenge,
+		TokenID:     tokenID,
+		IssuedAt:    issuedAt,
+	})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	return &dto.UserLoginResponse{
+		ID:                    user.ID,
+		Email:                 user.Email,
+		Name:                  string(user.Name),
+		Roles:                 user.Roles,
+		Expired:               expired,
+		MFARequired:           !enrollment,
+		MFAEnrollmentRequired: enrollment,
+		MFAToken:              mfaToken,
+	}, nil
+}
+
+func (u *userService) readMFAChallenge(ctx context.Context, mfaToken string) (*mjwt.CustomClaim, rest_err.APIError) {
+	token, apiErr := u.jwt.ValidateToken(mfaToken)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	claims, apiErr := u.jwt.ReadToken(token)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if claims.Type != mjwt.MFAChallenge {
+		return nil, rest_err.NewAPIError("Token tidak valid", http.StatusUnprocessableEntity, "jwt_error", []interface{}{"not a mfa token"})
+	}
+	denied, apiErr := u.denylist.IsTokenDenied(ctx, claims.TokenID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if denied {
+		return nil, rest_err.NewUnauthorizedError("mfa_token sudah digunakan, silakan login kembali")
+	}
+	return claims, nil
+}
+
+// isMFARequired return true jika BA_MFA_REQUIRE_ADMIN aktif dan user memiliki role ADMIN
+func isMFARequired(userRoles []string) bool {
+	return configs.Config.MFAREQUIREADMIN && sfunc.InSlice(roles.RoleAdmin, userRoles)
+}
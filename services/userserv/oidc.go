@@ -0,0 +1,173 @@
+package userserv
+
+import (
+	"context"
+	"fmt"
+	"github.com/muchlist/berita_acara/configs"
+	"github.com/muchlist/berita_acara/configs/roles"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/mcrypt"
+	"github.com/muchlist/berita_acara/utils/moidc"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"strings"
+	"time"
+)
+
+const oidcRandomLength = 32
+
+// OIDCLogin menyimpan state, nonce dan code verifier lalu mengembalikan alamat halaman login identity provider
+func (u *userService) OIDCLogin(ctx context.Context) (string, rest_err.APIError) {
+	if !u.oidc.Enabled() {
+		return "", rest_err.NewBadRequestError("Login OIDC tidak diaktifkan")
+	}
+
+	state, apiErr := mcrypt.GenerateRandomToken(oidcRandomLength)
+	if apiErr != nil {
+		return "", apiErr
+	}
+	nonce, apiErr := mcrypt.GenerateRandomToken(oidcRandomLength)
+	if apiErr != nil {
+		return "", apiErr
+	}
+	codeVerifier, apiErr := mcrypt.GenerateRandomToken(oidcRandomLength)
+	if apiErr != nil {
+		return "", apiErr
+	}
+
+	now := time.Now()
+	apiErr = u.oidcDao.InsertState(ctx, dto.OIDCState{
+		StateHash:    mcrypt.HashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    now.Add(time.Duration(configs.Config.OIDCSTATEMINUTE) * time.Minute).Unix(),
+		CreatedAt:    now.Unix(),
+	})
+	if apiErr != nil {
+		return "", apiErr
+	}
+
+	return u.oidc.AuthCodeURL(ctx, state, nonce, codeVerifier)
+}
+
+// OIDCCallback menukar authorization code lalu login sebagai user yang terhubung dengan identitas tersebut.
+// Identitas baru dihubungkan dengan user yang memiliki email sama apabila email sudah diverifikasi identity provider,
+// selain itu user baru dengan role BASIC dibuat apabila BA_OIDC_AUTO_PROVISION aktif
+func (u *userService) OIDCCallback(ctx context.Context, code string, state string, ip string, userAgent string) (*dto.UserLoginResponse, rest_err.APIError) {
+	oidcState, apiErr := u.oidcDao.UseState(ctx, mcrypt.HashToken(state), time.Now().Unix())
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	identity, apiErr := u.oidc.Exchange(ctx, code, oidcState.CodeVerifier, oidcState.Nonce)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	userID, apiErr := u.oidcDao.GetUserID(ctx, identity.Issuer, identity.Subject)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if userID == 0 && identity.Email != "" && identity.EmailVerified {
+		userID, apiErr = u.linkIdentity(ctx, *identity, ip)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+	}
+	if userID == 0 {
+		userID, apiErr = u.provisionUser(ctx, *identity, ip)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+	}
+
+	user, apiErr := u.dao.Get(ctx, userID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if user.ID == 0 || user.ServiceAccount {
+		return nil, rest_err.NewUnauthorizedError("Akun tidak dapat login menggunakan OIDC")
+	}
+
+	return u.completeLogin(ctx, *user, ip, userAgent)
+}
+
+// linkIdentity menghubungkan identitas dengan user yang memiliki email sama, mengembalikan 0 apabila tidak ada
+func (u *userService) linkIdentity(ctx context.Context, identity moidc.Identity, ip string) (int, rest_err.APIError) {
+	user, apiErr := u.dao.GetByEmail(ctx, identity.Email)
+	if apiErr != nil {
+		return 0, apiErr
+	}
+	if user.ID == 0 {
+		return 0, nil
+	}
+	if user.ServiceAccount {
+		return 0, rest_err.NewUnauthorizedError("Akun tidak dapat login menggunakan OIDC")
+	}
+
+	now := time.Now().Unix()
+	apiErr = u.oidcDao.LinkIdentity(ctx, dto.UserIdentity{
+		Issuer:    identity.Issuer,
+		Subject:   identity.Subject,
+		UserID:    user.ID,
+		CreatedAt: now,
+	})
+	if apiErr != nil {
+		return 0, apiErr
+	}
+
+	u.audit(ctx, dto.AuditLog{
+		Action:    dto.AuditOIDCLinked,
+		UserID:    user.ID,
+		ActorID:   user.ID,
+		IP:        ip,
+		Detail:    fmt.Sprintf("%s %s", identity.Issuer, identity.Subject),
+		CreatedAt: now,
+	})
+	return user.ID, nil
+}
+
+// provisionUser membuat user baru dengan role BASIC, password diisi acak sehingga user hanya dapat login melalui OIDC
+// sampai melakukan reset password
+func (u *userService) provisionUser(ctx context.Context, identity moidc.Identity, ip string) (int, rest_err.APIError) {
+	if !configs.Config.OIDCAUTOPROVISION {
+		return 0, rest_err.NewUnauthorizedError("Akun belum terdaftar, hubungi admin")
+	}
+	if identity.Email == "" || !identity.EmailVerified {
+		return 0, rest_err.NewUnauthorizedError("Email belum diverifikasi oleh identity provider")
+	}
+
+	hashPassword, apiErr := mcrypt.GenerateUnusablePasswordHash(u.crypto)
+	if apiErr != nil {
+		return 0, apiErr
+	}
+
+	name := identity.Name
+	if name == "" {
+		name = strings.Split(identity.Email, "@")[0]
+	}
+	now := time.Now().Unix()
+	userID, apiErr := u.oidcDao.Provision(ctx, dto.User{
+		Email:     identity.Email,
+		Name:      dto.UppercaseString(truncate(name, 100)),
+		Password:  hashPassword,
+		Roles:     []string{roles.RoleBasic},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, dto.UserIdentity{
+		Issuer:    identity.Issuer,
+		Subject:   identity.Subject,
+		CreatedAt: now,
+	})
+	if apiErr != nil {
+		return 0, apiErr
+	}
+
+	u.audit(ctx, dto.AuditLog{
+		Action:    dto.AuditOIDCProvisioned,
+		UserID:    userID,
+		ActorID:   userID,
+		IP:        ip,
+		Detail:    fmt.Sprintf("%s %s", identity.Issuer, identity.Subject),
+		CreatedAt: now,
+	})
+	return userID, nil
+}
@@ -0,0 +1,40 @@
+package userserv
+
+import (
+	"context"
+	"fmt"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/logger"
+)
+
+// dummyPasswordHash hash dengan algoritma dan parameter yang sama dengan hash baru, dibandingkan saat
+// user tidak ditemukan agar waktu response login tidak membedakan user yang tidak ada
+func (u *userService) dummyPasswordHash() string {
+	u.dummyHashOnce.Do(func() {
+		u.dummyHash, _ = u.crypto.GenerateHash("berita_acara_dummy_password")
+	})
+	return u.dummyHash
+}
+
+// rehashPassword memperbarui hash password yang dibuat dengan algoritma atau parameter lama,
+// hanya dipanggil setelah password cocok. Kegagalan tidak menggagalkan login.
+func (u *userService) rehashPassword(ctx context.Context, user dto.User, password string) {
+	if !u.crypto.NeedsRehash(user.Password) {
+		return
+	}
+
+	hashPassword, apiErr := u.crypto.GenerateHash(password)
+	if apiErr != nil {
+		logger.Error(fmt.Sprintf("gagal membuat hash baru password user %d", user.ID), apiErr)
+		return
+	}
+	// updated_at tidak diubah karena data user tidak berubah
+	apiErr = u.dao.ChangePassword(ctx, dto.User{
+		ID:        user.ID,
+		Password:  hashPassword,
+		UpdatedAt: user.UpdatedAt,
+	})
+	if apiErr != nil {
+		logger.Error(fmt.Sprintf("gagal memperbarui hash password user %d", user.ID), apiErr)
+	}
+}
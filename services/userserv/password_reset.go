@@ -0,0 +1,104 @@
+package userserv
+
+import (
+	"context"
+	"fmt"
+	"github.com/muchlist/berita_acara/configs"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/logger"
+	"github.com/muchlist/berita_acara/utils/mailer"
+	"github.com/muchlist/berita_acara/utils/mcrypt"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"net/url"
+	"time"
+)
+
+const resetTokenLength = 32
+
+// ForgotPassword mengirim token reset password ke email user. Email yang tidak terdaftar atau milik service account tidak
+// menghasilkan error dan email dikirim di background agar response tidak membedakan keduanya.
+func (u *userService) ForgotPassword(ctx context.Context, email string) rest_err.APIError {
+	if apiErr := u.localPasswordOnly(); apiErr != nil {
+		return apiErr
+	}
+
+	user, apiErr := u.dao.GetByEmail(ctx, email)
+	if apiErr != nil {
+		return apiErr
+	}
+	if user.ID == 0 || user.ServiceAccount {
+		return nil
+	}
+
+	token, apiErr := mcrypt.GenerateRandomToken(resetTokenLength)
+	if apiErr != nil {
+		return apiErr
+	}
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(configs.Config.RESETTOKENMINUTE) * time.Minute)
+	apiErr = u.resetDao.Insert(ctx, dto.PasswordReset{
+		TokenHash: mcrypt.HashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: expiresAt.Unix(),
+		CreatedAt: now.Unix(),
+	})
+	if apiErr != nil {
+		return apiErr
+	}
+
+	msg := mailer.Message{
+		To:      []string{user.Email},
+		Subject: "Reset password Berita Acara",
+		Body: fmt.Sprintf("Halo %s,\n\nKami menerima permintaan reset password untuk akun anda. "+
+			"Buka tautan berikut untuk membuat password baru:\n\n%s?token=%s\n\n"+
+			"Tautan hanya dapat digunakan sekali dan berlaku sampai %s. "+
+			"Abaikan email ini apabila anda tidak meminta reset password.\n",
+			user.Name, configs.Config.RESETPASSWORDURL, url.QueryEscape(token), expiresAt.Format("02-01-2006 15:04 MST")),
+	}
+	go func() {
+		if apiErr := u.mailer.Send(msg); apiErr != nil {
+			logger.Error(fmt.Sprintf("gagal mengirim email reset password user %d", user.ID), apiErr)
+		}
+	}()
+	return nil
+}
+
+// ResetPassword mengganti password menggunakan token dari ForgotPassword. Token hanya dapat digunakan sekali,
+// seluruh sesi user direvoke dan kunci login user dibuka.
+func (u *userService) ResetPassword(ctx context.Context, token string, newPassword string, ip string) rest_err.APIError {
+	if apiErr := u.localPasswordOnly(); apiErr != nil {
+		return apiErr
+	}
+
+	now := time.Now().Unix()
+	userID, apiErr := u.resetDao.Use(ctx, mcrypt.HashToken(token), now)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	hashPassword, apiErr := u.crypto.GenerateHash(newPassword)
+	if apiErr != nil {
+		return apiErr
+	}
+	apiErr = u.dao.ChangePassword(ctx, dto.User{
+		ID:        userID,
+		Password:  hashPassword,
+		UpdatedAt: now,
+	})
+	if apiErr != nil {
+		return apiErr
+	}
+
+	u.audit(ctx, dto.AuditLog{
+		Action:    dto.AuditPasswordReset,
+		UserID:    userID,
+		ActorID:   userID,
+		IP:        ip,
+		CreatedAt: now,
+	})
+
+	if apiErr := u.attemptDao.Reset(ctx, accountSubject(userID)); apiErr != nil {
+		return apiErr
+	}
+	return u.RevokeSessions(ctx, userID)
+}
@@ -0,0 +1,86 @@
+package userserv
+
+import (
+	"context"
+	"github.com/muchlist/berita_acara/configs"
+	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"time"
+)
+
+const (
+	userAgentLength = 255
+)
+
+// FindSessions menampilkan sesi user yang masih aktif, currentSessionID ditandai sebagai sesi yang sedang digunakan
+func (u *userService) FindSessions(ctx context.Context, userID int, currentSessionID string) ([]dto.Session, rest_err.APIError) {
+	sessions, apiErr := u.sessionDao.FindActive(ctx, userID, time.Now().Unix())
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	for i := range sessions {
+		sessions[i].Current = currentSessionID != "" && sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// TerminateSession merevoke refresh token pada sesi dan memasukkan sid ke denylist sehingga
+// access token yang sudah terbit pada sesi tersebut ikut ditolak
+func (u *userService) TerminateSession(ctx context.Context, userID int, sessionID string, actorID int, ip string) rest_err.APIError {
+	now := time.Now().Unix()
+	if apiErr := u.sessionDao.Terminate(ctx, userID, sessionID, now); apiErr != nil {
+		return apiErr
+	}
+
+	// access token terakhir pada sesi paling lambat kadaluarsa setelah umur access token ditambah leeway
+	apiErr := u.denylist.DenyToken(ctx, dto.DeniedToken{
+		TokenID:   sessionID,
+		UserID:    userID,
+		ExpiresAt: now + int64(configs.Config.ACCESSTOKENMINUTE)*60 + int64(configs.Config.JWTLEEWAY),
+		CreatedAt: now,
+	})
+	if apiErr != nil {
+		return apiErr
+	}
+
+	u.audit(ctx, dto.AuditLog{
+		Action:    dto.AuditSessionTerminated,
+		UserID:    userID,
+		ActorID:   actorID,
+		IP:        ip,
+		Detail:    sessionID,
+		CreatedAt: now,
+	})
+	return nil
+}
+
+// terminateOtherSessions mengakhiri seluruh sesi aktif user selain currentSessionID, apabila currentSessionID
+// kosong (token tanpa sid) seluruh sesi direvoke
+func (u *userService) terminateOtherSessions(ctx context.Context, userID int, currentSessionID string, ip string) rest_err.APIError {
+	if currentSessionID == "" {
+		return u.RevokeSessions(ctx, userID)
+	}
+
+	sessions, apiErr := u.sessionDao.FindActive(ctx, userID, time.Now().Unix())
+	if apiErr != nil {
+		return apiErr
+	}
+	for _, session := range sessions {
+		if session.ID == currentSessionID {
+			continue
+		}
+		if apiErr := u.TerminateSession(ctx, userID, session.ID, userID, ip); apiErr != nil {
+			return apiErr
+		}
+	}
+	return nil
+}
+
+// truncate memotong text menjadi maksimal length karakter
+func truncate(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	return string(runes[:length])
+}
@@ -2,75 +2,223 @@ package userserv
 
 import (
 	"context"
+	"fmt"
+	"github.com/muchlist/berita_acara/configs"
+	"github.com/muchlist/berita_acara/dao/attemptdao"
+	"github.com/muchlist/berita_acara/dao/auditdao"
+	"github.com/muchlist/berita_acara/dao/denylistdao"
+	"github.com/muchlist/berita_acara/dao/mfadao"
+	"github.com/muchlist/berita_acara/dao/oidcdao"
+	"github.com/muchlist/berita_acara/dao/resetdao"
+	"github.com/muchlist/berita_acara/dao/sessiondao"
+	"github.com/muchlist/berita_acara/dao/tokendao"
 	"github.com/muchlist/berita_acara/dao/userdao"
 	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/mailer"
 	"github.com/muchlist/berita_acara/utils/mcrypt"
 	"github.com/muchlist/berita_acara/utils/mjwt"
+	"github.com/muchlist/berita_acara/utils/mldap"
+	"github.com/muchlist/berita_acara/utils/moidc"
+	"github.com/muchlist/berita_acara/utils/mtotp"
 	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/muchlist/berita_acara/utils/sfunc"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
-func NewUserService(dao userdao.UserDaoAssumer, crypto mcrypt.BcryptAssumer, jwt mjwt.JWTAssumer) UserServiceAssumer {
+const (
+	tokenIDLength = 16
+)
+
+func NewUserService(
+	dao userdao.UserDaoAssumer,
+	tokenDao tokendao.TokenDaoAssumer,
+	mfaDao mfadao.MFADaoAssumer,
+	attemptDao attemptdao.AttemptDaoAssumer,
+	auditDao auditdao.AuditDaoAssumer,
+	resetDao resetdao.ResetDaoAssumer,
+	sessionDao sessiondao.SessionDaoAssumer,
+	oidcDao oidcdao.OIDCDaoAssumer,
+	denylist denylistdao.DenylistDaoAssumer,
+	crypto mcrypt.HasherAssumer,
+	jwt mjwt.JWTAssumer,
+	totp mtotp.TotpAssumer,
+	mailer mailer.MailerAssumer,
+	oidc moidc.OIDCAssumer,
+	ldap mldap.LDAPAssumer,
+) UserServiceAssumer {
 	return &userService{
-		dao:    dao,
-		crypto: crypto,
-		jwt:    jwt,
+		dao:        dao,
+		tokenDao:   tokenDao,
+		mfaDao:     mfaDao,
+		attemptDao: attemptDao,
+		auditDao:   auditDao,
+		resetDao:   resetDao,
+		sessionDao: sessionDao,
+		oidcDao:    oidcDao,
+		denylist:   denylist,
+		crypto:     crypto,
+		jwt:        jwt,
+		totp:       totp,
+		mailer:     mailer,
+		oidc:       oidc,
+		ldap:       ldap,
 	}
 }
 
 type userService struct {
-	dao    userdao.UserDaoAssumer
-	crypto mcrypt.BcryptAssumer
-	jwt    mjwt.JWTAssumer
+	dao        userdao.UserDaoAssumer
+	tokenDao   tokendao.TokenDaoAssumer
+	mfaDao     mfadao.MFADaoAssumer
+	attemptDao attemptdao.AttemptDaoAssumer
+	auditDao   auditdao.AuditDaoAssumer
+	resetDao   resetdao.ResetDaoAssumer
+	sessionDao sessiondao.SessionDaoAssumer
+	oidcDao    oidcdao.OIDCDaoAssumer
+	denylist   denylistdao.DenylistDaoAssumer
+	crypto     mcrypt.HasherAssumer
+	jwt        mjwt.JWTAssumer
+	totp       mtotp.TotpAssumer
+	mailer     mailer.MailerAssumer
+	oidc       moidc.OIDCAssumer
+	ldap       mldap.LDAPAssumer
+
+	dummyHashOnce sync.Once
+	dummyHash     string
 }
 
-// Login
-func (u *userService) Login(ctx context.Context, login dto.UserLoginRequest) (*dto.UserLoginResponse, rest_err.APIError) {
-	user, err := u.dao.Get(ctx, login.UserID)
+// Login membuat access token dan refresh token, refresh token disimpan sebagai awal family sesi baru.
+// Apabila MFA aktif atau wajib bagi user, yang dikembalikan hanya mfa_token untuk langkah kedua login.
+// Login gagal dihitung per user dan per ip, response untuk user yang tidak ada dan password salah selalu sama.
+// Password diperiksa sesuai BA_AUTH_BACKEND (lihat checkCredential).
+func (u *userService) Login(ctx context.Context, login dto.UserLoginRequest, ip string, userAgent string) (*dto.UserLoginResponse, rest_err.APIError) {
+	var user *dto.User
+	var err rest_err.APIError
+	if login.Email != "" {
+		user, err = u.dao.GetByEmail(ctx, login.Email)
+	} else {
+		user, err = u.dao.Get(ctx, login.UserID)
+	}
 	if err != nil {
-		return nil, rest_err.NewBadRequestError("Username atau password tidak valid")
+		return nil, err
+	}
+
+	// user yang tidak terdaftar tetap dihitung agar response tidak membedakan user yang ada dan tidak ada
+	subject, userID := accountSubject(user.ID), user.ID
+	if user.ID == 0 {
+		if login.Email != "" {
+			subject, userID = emailSubject(login.Email), 0
+		} else {
+			subject, userID = accountSubject(login.UserID), login.UserID
+		}
+	}
+	release, apiErr := u.claimLogin(ctx, subject, ip)
+	if apiErr != nil {
+		return nil, apiErr
 	}
+	defer release()
 
-	if !u.crypto.IsPWAndHashPWMatch(login.Password, user.Password) {
+	if user.ID == 0 || user.ServiceAccount {
+		// tetap membandingkan hash agar waktu response tidak membedakan user yang tidak ada,
+		// service account diperlakukan sama karena hanya dapat menggunakan api key
+		u.crypto.IsPWAndHashPWMatch(login.Password, u.dummyPasswordHash())
+		reason := "user tidak terdaftar"
+		if user.ServiceAccount {
+			reason = "service account tidak dapat login menggunakan password"
+		}
+		if apiErr := u.recordLoginFailure(ctx, subject, userID, ip, reason); apiErr != nil {
+			return nil, apiErr
+		}
+		return nil, rest_err.NewUnauthorizedError("Username atau password tidak valid")
+	}
+	valid, apiErr := u.checkCredential(ctx, user, login.Password, ip)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if !valid {
+		if apiErr := u.recordLoginFailure(ctx, subject, userID, ip, "password salah"); apiErr != nil {
+			return nil, apiErr
+		}
 		return nil, rest_err.NewUnauthorizedError("Username atau password tidak valid")
 	}
 
-	AccessClaims := mjwt.CustomClaim{
-		Identity:    user.ID,
-		Name:        string(user.Name),
-		Roles:       user.Roles,
-		ExtraMinute: 60 * 24 * 1, // 1 Hour
-		Type:        mjwt.Access,
-		Fresh:       true,
+	return u.completeLogin(ctx, *user, ip, userAgent)
+}
+
+// completeLogin dipanggil setelah user terautentikasi, mengembalikan mfa_token apabila MFA aktif
+// atau wajib bagi user, selain itu langsung menerbitkan token
+func (u *userService) completeLogin(ctx context.Context, user dto.User, ip string, userAgent string) (*dto.UserLoginResponse, rest_err.APIError) {
+	mfa, err := u.mfaDao.Get(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa != nil && mfa.Enabled {
+		return u.mfaChallenge(ctx, user, false)
+	}
+	if isMFARequired(user.Roles) {
+		return u.mfaChallenge(ctx, user, true)
 	}
 
-	RefreshClaims := mjwt.CustomClaim{
-		Identity:    user.ID,
-		Name:        string(user.Name),
-		Roles:       user.Roles,
-		ExtraMinute: 60 * 24 * 10, // 60 days
-		Type:        mjwt.Refresh,
+	return u.issueTokens(ctx, user, ip, userAgent)
+}
+
+// issueTokens membuat access token fresh dan refresh token sebagai awal family sesi baru beserta catatan sesinya,
+// dipanggil setelah seluruh langkah login berhasil sehingga hitungan login gagal user ikut dihapus
+func (u *userService) issueTokens(ctx context.Context, user dto.User, ip string, userAgent string) (*dto.UserLoginResponse, rest_err.APIError) {
+	if err := u.attemptDao.Reset(ctx, accountSubject(user.ID)); err != nil {
+		return nil, err
 	}
 
-	accessToken, err := u.jwt.GenerateToken(AccessClaims)
+	familyID, err := mcrypt.GenerateRandomToken(tokenIDLength)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, accessExpired, err := u.generateAccessToken(ctx, user, true, familyID)
+	if err != nil {
+		return nil, err
+	}
+	tokenID, err := mcrypt.GenerateRandomToken(tokenIDLength)
 	if err != nil {
 		return nil, err
 	}
-	refreshToken, err := u.jwt.GenerateToken(RefreshClaims)
+	refreshToken, refreshExpired, err := u.generateRefreshToken(user, tokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.tokenDao.Insert(ctx, dto.RefreshToken{
+		TokenID:   tokenID,
+		FamilyID:  familyID,
+		UserID:    user.ID,
+		ExpiresAt: refreshExpired,
+		CreatedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.sessionDao.Insert(ctx, dto.Session{
+		ID:        familyID,
+		UserID:    user.ID,
+		UserAgent: truncate(userAgent, userAgentLength),
+		IP:        ip,
+		CreatedAt: time.Now().Unix(),
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	userResponse := dto.UserLoginResponse{
-		ID:           user.ID,
-		Email:        user.Email,
-		Name:         string(user.Name),
-		Roles:        user.Roles,
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		Expired:      time.Now().Add(time.Minute * time.Duration(60*24*1)).Unix(),
+		ID:             user.ID,
+		Email:          user.Email,
+		Name:           string(user.Name),
+		Roles:          user.Roles,
+		AccessToken:    accessToken,
+		RefreshToken:   refreshToken,
+		Expired:        accessExpired,
+		RefreshExpired: refreshExpired,
 	}
 
 	return &userResponse, nil
@@ -94,19 +242,172 @@ func (u *userService) InsertUser(ctx context.Context, user dto.User) (int, rest_
 	return insertedUserID, nil
 }
 
-// EditUser
+// EditUser jika role berubah maka access token user yang sudah terbit tidak berlaku lagi
 func (u *userService) EditUser(ctx context.Context, request dto.User) (*dto.User, rest_err.APIError) {
+	oldUser, err := u.dao.Get(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	request.UpdatedAt = time.Now().Unix()
 	result, err := u.dao.Edit(ctx, request)
 	if err != nil {
 		return nil, err
 	}
+
+	if !sfunc.AllValueInSliceIsValid(oldUser.Roles, result.Roles) || !sfunc.AllValueInSliceIsValid(result.Roles, oldUser.Roles) {
+		if err := u.denylist.DenyUser(ctx, result.ID, request.UpdatedAt); err != nil {
+			return nil, err
+		}
+	}
 	return result, nil
 }
 
-// Refresh token
+// EditProfile merubah profile user yang sedang login, role tidak ikut berubah
+func (u *userService) EditProfile(ctx context.Context, request dto.User) (*dto.User, rest_err.APIError) {
+	request.UpdatedAt = time.Now().Unix()
+	result, err := u.dao.EditProfile(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ChangePassword merubah password user setelah password lama dicocokkan, password lama yang salah dihitung
+// seperti login gagal. Seluruh sesi lain milik user diakhiri, sesi sessionID yang digunakan tetap berlaku
+func (u *userService) ChangePassword(ctx context.Context, userID int, sessionID string, oldPassword string, newPassword string, ip string) rest_err.APIError {
+	if apiErr := u.localPasswordOnly(); apiErr != nil {
+		return apiErr
+	}
+
+	user, err := u.dao.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.ID == 0 {
+		return rest_err.NewBadRequestError(fmt.Sprintf("User dengan username %d tidak ditemukan", userID))
+	}
+
+	subject := accountSubject(userID)
+	release, apiErr := u.claimLogin(ctx, subject, ip)
+	if apiErr != nil {
+		return apiErr
+	}
+	defer release()
+	if !u.crypto.IsPWAndHashPWMatch(oldPassword, user.Password) {
+		if apiErr := u.recordLoginFailure(ctx, subject, userID, ip, "password lama salah saat ganti password"); apiErr != nil {
+			return apiErr
+		}
+		return rest_err.NewBadRequestError("Password lama tidak valid")
+	}
+
+	hashPassword, err := u.crypto.GenerateHash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	err = u.dao.ChangePassword(ctx, dto.User{
+		ID:        userID,
+		Password:  hashPassword,
+		UpdatedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		return err
+	}
+	return u.terminateOtherSessions(ctx, userID, sessionID, ip)
+}
+
+// Refresh menukar refresh token dengan access token dan refresh token baru (rotasi).
+// Refresh token lama tidak dapat digunakan kembali, penggunaan ulang akan merevoke seluruh family sesi.
 func (u *userService) Refresh(ctx context.Context, payload dto.UserRefreshTokenRequest) (*dto.UserRefreshTokenResponse, rest_err.APIError) {
-	token, apiErr := u.jwt.ValidateToken(payload.RefreshToken)
+	claims, apiErr := u.readRefreshToken(payload.RefreshToken)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	// mendapatkan data terbaru dari user
+	user, apiErr := u.dao.Get(ctx, claims.Identity)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if user.ID == 0 {
+		return nil, rest_err.NewUnauthorizedError("User tidak ditemukan, silakan login kembali")
+	}
+
+	newTokenID, apiErr := mcrypt.GenerateRandomToken(tokenIDLength)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	refreshToken, refreshExpired, apiErr := u.generateRefreshToken(*user, newTokenID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	// token baru hanya dikembalikan apabila rotasi berhasil
+	rotated, apiErr := u.tokenDao.Rotate(ctx, claims.TokenID, dto.RefreshToken{
+		TokenID:   newTokenID,
+		ExpiresAt: refreshExpired,
+		CreatedAt: time.Now().Unix(),
+	})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := u.sessionDao.Touch(ctx, rotated.FamilyID, rotated.CreatedAt); apiErr != nil {
+		return nil, apiErr
+	}
+
+	accessToken, accessExpired, apiErr := u.generateAccessToken(ctx, *user, false, rotated.FamilyID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	userRefreshTokenResponse := dto.UserRefreshTokenResponse{
+		AccessToken:    accessToken,
+		RefreshToken:   refreshToken,
+		Expired:        accessExpired,
+		RefreshExpired: refreshExpired,
+	}
+
+	return &userRefreshTokenResponse, nil
+}
+
+// Logout merevoke family sesi dari refresh token sehingga refresh token tersebut maupun
+// hasil rotasinya tidak dapat digunakan lagi. Jika accessClaims tidak nil maka access token
+// yang sedang digunakan ikut dimasukkan ke denylist.
+func (u *userService) Logout(ctx context.Context, payload dto.UserRefreshTokenRequest, accessClaims *mjwt.CustomClaim) rest_err.APIError {
+	claims, apiErr := u.readRefreshToken(payload.RefreshToken)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	now := time.Now().Unix()
+	if apiErr := u.tokenDao.RevokeFamily(ctx, claims.TokenID, now); apiErr != nil {
+		return apiErr
+	}
+
+	if accessClaims != nil && accessClaims.Type == mjwt.Access && accessClaims.TokenID != "" && accessClaims.Identity == claims.Identity {
+		return u.denylist.DenyToken(ctx, dto.DeniedToken{
+			TokenID:   accessClaims.TokenID,
+			UserID:    accessClaims.Identity,
+			ExpiresAt: accessClaims.Exp,
+			CreatedAt: now,
+		})
+	}
+	return nil
+}
+
+// RevokeSessions merevoke seluruh refresh token milik user beserta access token yang sudah terbit
+func (u *userService) RevokeSessions(ctx context.Context, userID int) rest_err.APIError {
+	now := time.Now().Unix()
+	if apiErr := u.tokenDao.RevokeUser(ctx, userID, now); apiErr != nil {
+		return apiErr
+	}
+	return u.denylist.DenyUser(ctx, userID, now)
+}
+
+// readRefreshToken memvalidasi refresh token dan memastikan token memiliki jti
+func (u *userService) readRefreshToken(refreshToken string) (*mjwt.CustomClaim, rest_err.APIError) {
+	token, apiErr := u.jwt.ValidateToken(refreshToken)
 	if apiErr != nil {
 		return nil, apiErr
 	}
@@ -119,42 +420,67 @@ func (u *userService) Refresh(ctx context.Context, payload dto.UserRefreshTokenR
 	if claims.Type != mjwt.Refresh {
 		return nil, rest_err.NewAPIError("Token tidak valid", http.StatusUnprocessableEntity, "jwt_error", []interface{}{"not a refresh token"})
 	}
-
-	// mendapatkan data terbaru dari user
-	user, apiErr := u.dao.Get(ctx, claims.Identity)
-	if apiErr != nil {
-		return nil, apiErr
+	// refresh token yang dibuat sebelum rotasi diterapkan tidak memiliki jti
+	if claims.TokenID == "" {
+		return nil, rest_err.NewUnauthorizedError("Refresh token tidak dikenali, silakan login kembali")
 	}
+	return claims, nil
+}
 
-	AccessClaims := mjwt.CustomClaim{
+// generateAccessToken return token beserta waktu expired yang tertanam di dalam token,
+// sessionID adalah family refresh token yang diterbitkan bersamaan
+func (u *userService) generateAccessToken(ctx context.Context, user dto.User, fresh bool, sessionID string) (string, int64, rest_err.APIError) {
+	tokenID, err := mcrypt.GenerateRandomToken(tokenIDLength)
+	if err != nil {
+		return "", 0, err
+	}
+	issuedAt, err := u.issuedAfterCutoff(ctx, user.ID)
+	if err != nil {
+		return "", 0, err
+	}
+	return u.jwt.GenerateToken(mjwt.CustomClaim{
 		Identity:    user.ID,
 		Name:        string(user.Name),
 		Roles:       user.Roles,
-		ExtraMinute: time.Duration(60 * 60 * 1),
+		ExtraMinute: time.Duration(configs.Config.ACCESSTOKENMINUTE),
 		Type:        mjwt.Access,
-		Fresh:       false,
-	}
-
-	accessToken, err := u.jwt.GenerateToken(AccessClaims)
-	if err != nil {
-		return nil, err
-	}
+		Fresh:       fresh,
+		TokenID:     tokenID,
+		IssuedAt:    issuedAt,
+		SessionID:   sessionID,
+	})
+}
 
-	userRefreshTokenResponse := dto.UserRefreshTokenResponse{
-		AccessToken: accessToken,
-		Expired:     time.Now().Add(time.Minute * time.Duration(60*60*1)).Unix(),
+// issuedAfterCutoff mengembalikan iat minimal token baru milik user. Token yang diterbitkan pada atau sebelum
+// batas waktu denylist ditolak, sehingga token yang diterbitkan pada detik yang sama dengan batas waktu
+// (misalnya login ulang setelah ganti password) harus memiliki iat setelahnya
+func (u *userService) issuedAfterCutoff(ctx context.Context, userID int) (int64, rest_err.APIError) {
+	issuedBefore, apiErr := u.denylist.GetUserCutoff(ctx, userID)
+	if apiErr != nil || issuedBefore == 0 {
+		return 0, apiErr
 	}
+	return issuedBefore + 1, nil
+}
 
-	return &userRefreshTokenResponse, nil
+func (u *userService) generateRefreshToken(user dto.User, tokenID string) (string, int64, rest_err.APIError) {
+	return u.jwt.GenerateToken(mjwt.CustomClaim{
+		Identity:    user.ID,
+		Name:        string(user.Name),
+		Roles:       user.Roles,
+		ExtraMinute: time.Duration(configs.Config.REFRESHTOKENMINUTE),
+		Type:        mjwt.Refresh,
+		TokenID:     tokenID,
+	})
 }
 
-// DeleteUser
+// DeleteUser refresh token milik user ikut terhapus melalui ON DELETE CASCADE,
+// access token yang sudah terbit ditolak melalui denylist
 func (u *userService) DeleteUser(ctx context.Context, userID int) rest_err.APIError {
 	err := u.dao.Delete(ctx, userID)
 	if err != nil {
 		return err
 	}
-	return nil
+	return u.denylist.DenyUser(ctx, userID, time.Now().Unix())
 }
 
 // GetUser mendapatkan user dari database
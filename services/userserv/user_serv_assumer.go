@@ -3,6 +3,7 @@ package userserv
 import (
 	"context"
 	"github.com/muchlist/berita_acara/dto"
+	"github.com/muchlist/berita_acara/utils/mjwt"
 	"github.com/muchlist/berita_acara/utils/rest_err"
 )
 
@@ -18,12 +19,29 @@ type UserServiceReader interface {
 }
 
 type UserServiceAccess interface {
-	Login(ctx context.Context, login dto.UserLoginRequest) (*dto.UserLoginResponse, rest_err.APIError)
+	Login(ctx context.Context, login dto.UserLoginRequest, ip string, userAgent string) (*dto.UserLoginResponse, rest_err.APIError)
 	Refresh(ctx context.Context, payload dto.UserRefreshTokenRequest) (*dto.UserRefreshTokenResponse, rest_err.APIError)
+	Logout(ctx context.Context, payload dto.UserRefreshTokenRequest, accessClaims *mjwt.CustomClaim) rest_err.APIError
+	RevokeSessions(ctx context.Context, userID int) rest_err.APIError
+	UnlockUser(ctx context.Context, userID int, actorID int, ip string) rest_err.APIError
+	Impersonate(ctx context.Context, userID int, actorID int, ip string) (*dto.ImpersonateResponse, rest_err.APIError)
+	RecordImpersonatedRequest(ctx context.Context, claims *mjwt.CustomClaim, method string, path string, ip string) rest_err.APIError
+	ForgotPassword(ctx context.Context, email string) rest_err.APIError
+	ResetPassword(ctx context.Context, token string, newPassword string, ip string) rest_err.APIError
+	OIDCLogin(ctx context.Context) (string, rest_err.APIError)
+	OIDCCallback(ctx context.Context, code string, state string, ip string, userAgent string) (*dto.UserLoginResponse, rest_err.APIError)
+	LoginMFA(ctx context.Context, request dto.MFALoginRequest, ip string, userAgent string) (*dto.UserLoginResponse, rest_err.APIError)
+	EnrollMFA(ctx context.Context, userID int) (*dto.MFAEnrollResponse, rest_err.APIError)
+	EnableMFA(ctx context.Context, claims mjwt.CustomClaim, code string, ip string, userAgent string) (*dto.MFAEnableResponse, rest_err.APIError)
+	DisableMFA(ctx context.Context, claims mjwt.CustomClaim, code string) rest_err.APIError
+	FindSessions(ctx context.Context, userID int, currentSessionID string) ([]dto.Session, rest_err.APIError)
+	TerminateSession(ctx context.Context, userID int, sessionID string, actorID int, ip string) rest_err.APIError
 }
 
 type UserServiceModifier interface {
 	InsertUser(ctx context.Context, user dto.User) (int, rest_err.APIError)
 	EditUser(ctx context.Context, request dto.User) (*dto.User, rest_err.APIError)
+	EditProfile(ctx context.Context, request dto.User) (*dto.User, rest_err.APIError)
+	ChangePassword(ctx context.Context, userID int, sessionID string, oldPassword string, newPassword string, ip string) rest_err.APIError
 	DeleteUser(ctx context.Context, userID int) rest_err.APIError
 }
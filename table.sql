@@ -2,11 +2,21 @@ CREATE TABLE IF NOT EXISTS users (
     id INT PRIMARY KEY,
     name VARCHAR (100) NOT NULL,
     email VARCHAR ( 255 ) UNIQUE NOT NULL,
-    password VARCHAR (100) NOT NULL,
+    position VARCHAR (100) NOT NULL DEFAULT '',
+    password VARCHAR (255) NOT NULL,
+    service_account BOOLEAN NOT NULL DEFAULT FALSE,
     created_at BIGINT NOT NULL,
     updated_at BIGINT NOT NULL
 );
 
+-- kolom yang ditambahkan atau diperlebar setelah tabel users dibuat, untuk database yang sudah ada
+ALTER TABLE users ADD COLUMN IF NOT EXISTS position VARCHAR (100) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS service_account BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ALTER COLUMN password TYPE VARCHAR (255);
+
+-- login menggunakan email tidak membedakan huruf besar dan kecil
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_lower_idx ON users(LOWER(email));
+
 CREATE TABLE IF NOT EXISTS roles(
     role_name VARCHAR (20) PRIMARY KEY,
     created_at BIGINT NOT NULL,
@@ -15,14 +25,243 @@ CREATE TABLE IF NOT EXISTS roles(
 
 CREATE TABLE IF NOT EXISTS permissions(
     id SERIAL PRIMARY KEY,
-    roles_name VARCHAR(20) REFERENCES roles(role_name) ON DELETE CASCADE,
-    permission_name VARCHAR (20) UNIQUE NOT NULL,
+    roles_name VARCHAR(20) NOT NULL REFERENCES roles(role_name) ON DELETE CASCADE ON UPDATE CASCADE,
+    permission_name VARCHAR (50) NOT NULL,
     created_at BIGINT NOT NULL,
-    updated_at BIGINT NOT NULL
+    updated_at BIGINT NOT NULL,
+    UNIQUE (roles_name, permission_name)
+);
+
+-- database lama : permission_name unik untuk seluruh role dan hanya 20 karakter
+ALTER TABLE permissions ALTER COLUMN permission_name TYPE VARCHAR (50);
+ALTER TABLE permissions DROP CONSTRAINT IF EXISTS permissions_permission_name_key;
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'permissions_roles_name_permission_name_key') THEN
+        ALTER TABLE permissions ADD CONSTRAINT permissions_roles_name_permission_name_key UNIQUE (roles_name, permission_name);
+    END IF;
+END $$;
+-- database lama : permission bernama resource.action diubah menjadi resource:action
+UPDATE permissions p SET permission_name = replace(p.permission_name, '.', ':')
+WHERE p.permission_name LIKE '%.%' AND NOT EXISTS (
+    SELECT 1 FROM permissions q WHERE q.roles_name = p.roles_name AND q.permission_name = replace(p.permission_name, '.', ':')
 );
+DELETE FROM permissions WHERE permission_name LIKE '%.%';
 
 CREATE TABLE IF NOT EXISTS users_roles(
     id SERIAL PRIMARY KEY,
     users_id INT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
     roles_name VARCHAR (20) REFERENCES roles(role_name) ON DELETE CASCADE ON UPDATE CASCADE
 );
+
+CREATE TABLE IF NOT EXISTS documents(
+    id SERIAL PRIMARY KEY,
+    title VARCHAR (255) NOT NULL,
+    doc_type VARCHAR (50) NOT NULL,
+    body TEXT NOT NULL,
+    status VARCHAR (20) NOT NULL,
+    creator INT NOT NULL REFERENCES users(id) ON UPDATE CASCADE,
+    unit VARCHAR (20) NOT NULL,
+    doc_number VARCHAR (100) UNIQUE,
+    verify_token VARCHAR (64) UNIQUE NOT NULL,
+    letterhead_title VARCHAR (255),
+    letterhead_address TEXT,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
+
+-- kop surat yang disalin saat permintaan tanda tangan, untuk database yang sudah ada
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS letterhead_title VARCHAR (255);
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS letterhead_address TEXT;
+
+CREATE TABLE IF NOT EXISTS templates(
+    id SERIAL PRIMARY KEY,
+    name VARCHAR (100) UNIQUE NOT NULL,
+    doc_type VARCHAR (50) NOT NULL,
+    title VARCHAR (255) NOT NULL,
+    body TEXT NOT NULL,
+    fields JSONB NOT NULL,
+    creator INT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS signatories(
+    id SERIAL PRIMARY KEY,
+    documents_id INT NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    users_id INT NOT NULL REFERENCES users(id) ON UPDATE CASCADE,
+    name VARCHAR (100) NOT NULL,
+    position VARCHAR (100) NOT NULL,
+    sign_order INT NOT NULL,
+    status VARCHAR (20) NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    signed_at BIGINT NOT NULL DEFAULT 0,
+    signature BYTEA,
+    doc_hash VARCHAR (64) NOT NULL DEFAULT '',
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    UNIQUE (documents_id, users_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_keys(
+    users_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    certificate TEXT NOT NULL,
+    private_key BYTEA NOT NULL,
+    not_after BIGINT NOT NULL,
+    created_at BIGINT NOT NULL
+);
+
+-- secret TOTP terenkripsi, enabled bernilai false selama enroll belum dikonfirmasi dengan code
+CREATE TABLE IF NOT EXISTS user_mfa(
+    users_id INT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    secret BYTEA NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes(
+    id SERIAL PRIMARY KEY,
+    users_id INT NOT NULL REFERENCES user_mfa(users_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    code_hash VARCHAR (64) NOT NULL,
+    used_at BIGINT NOT NULL DEFAULT 0,
+    UNIQUE(users_id, code_hash)
+);
+
+CREATE TABLE IF NOT EXISTS numbering_schemes(
+    unit VARCHAR (20) PRIMARY KEY,
+    pattern VARCHAR (100) NOT NULL,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS numbering_counters(
+    unit VARCHAR (20) NOT NULL,
+    year INT NOT NULL,
+    last_value INT NOT NULL,
+    PRIMARY KEY (unit, year)
+);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens(
+    token_id VARCHAR (64) PRIMARY KEY,
+    family_id VARCHAR (64) NOT NULL,
+    users_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    expires_at BIGINT NOT NULL,
+    revoked_at BIGINT NOT NULL DEFAULT 0,
+    replaced_by VARCHAR (64) NOT NULL DEFAULT '',
+    created_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_idx ON refresh_tokens(family_id);
+CREATE INDEX IF NOT EXISTS refresh_tokens_users_idx ON refresh_tokens(users_id);
+
+-- satu sesi untuk setiap family refresh token (id sama dengan family_id), sesi aktif selama
+-- masih ada refresh token pada family yang belum direvoke dan belum kadaluarsa
+CREATE TABLE IF NOT EXISTS sessions(
+    id VARCHAR (64) PRIMARY KEY,
+    users_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    user_agent VARCHAR (255) NOT NULL DEFAULT '',
+    ip VARCHAR (50) NOT NULL DEFAULT '',
+    last_refresh_at BIGINT NOT NULL DEFAULT 0,
+    created_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS sessions_users_idx ON sessions(users_id);
+
+-- api key milik service account, hanya hash sha256 dari key yang disimpan
+CREATE TABLE IF NOT EXISTS api_keys(
+    id VARCHAR (16) PRIMARY KEY,
+    users_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    name VARCHAR (100) NOT NULL,
+    key_hash VARCHAR (64) NOT NULL,
+    expires_at BIGINT NOT NULL DEFAULT 0,
+    last_used_at BIGINT NOT NULL DEFAULT 0,
+    revoked_at BIGINT NOT NULL DEFAULT 0,
+    created_by INT NOT NULL,
+    created_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_users_idx ON api_keys(users_id);
+
+-- role yang boleh digunakan api key, harus dimiliki service account
+CREATE TABLE IF NOT EXISTS api_key_scopes(
+    api_keys_id VARCHAR (16) NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    roles_name VARCHAR (20) NOT NULL REFERENCES roles(role_name) ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY (api_keys_id, roles_name)
+);
+
+-- login OIDC yang sedang berjalan, state hanya dapat digunakan sekali karena dihapus saat callback
+CREATE TABLE IF NOT EXISTS oidc_states(
+    state_hash VARCHAR (64) PRIMARY KEY,
+    nonce VARCHAR (64) NOT NULL,
+    code_verifier VARCHAR (128) NOT NULL,
+    expires_at BIGINT NOT NULL,
+    created_at BIGINT NOT NULL
+);
+
+-- akun user pada identity provider OIDC, subject hanya unik untuk issuer yang sama
+CREATE TABLE IF NOT EXISTS user_identities(
+    issuer VARCHAR (255) NOT NULL,
+    subject VARCHAR (255) NOT NULL,
+    users_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    created_at BIGINT NOT NULL,
+    PRIMARY KEY (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS user_identities_users_idx ON user_identities(users_id);
+
+-- id user yang dibuat otomatis saat login OIDC, dimulai dari angka besar agar tidak bentrok dengan id yang diinput admin
+CREATE SEQUENCE IF NOT EXISTS users_provisioned_id_seq START 1000000000;
+
+-- hanya hash sha256 dari token yang disimpan, token dikirim ke email user
+CREATE TABLE IF NOT EXISTS password_resets(
+    token_hash VARCHAR (64) PRIMARY KEY,
+    users_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    expires_at BIGINT NOT NULL,
+    used_at BIGINT NOT NULL DEFAULT 0,
+    created_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS password_resets_users_idx ON password_resets(users_id);
+
+CREATE TABLE IF NOT EXISTS denied_tokens(
+    token_id VARCHAR (64) PRIMARY KEY,
+    users_id INT NOT NULL,
+    expires_at BIGINT NOT NULL,
+    created_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS denied_tokens_expires_idx ON denied_tokens(expires_at);
+
+-- tanpa foreign key agar batas tetap berlaku untuk token milik user yang sudah dihapus
+CREATE TABLE IF NOT EXISTS token_cutoffs(
+    users_id INT PRIMARY KEY,
+    issued_before BIGINT NOT NULL
+);
+
+-- subject berupa user:<id> atau ip:<alamat>, subject untuk user yang tidak terdaftar juga dicatat
+-- agar response login tidak membedakan user yang ada dan tidak ada
+CREATE TABLE IF NOT EXISTS login_attempts(
+    subject VARCHAR (100) PRIMARY KEY,
+    failures INT NOT NULL,
+    blocked_until BIGINT NOT NULL DEFAULT 0,
+    last_failed_at BIGINT NOT NULL,
+    claimed_until BIGINT NOT NULL DEFAULT 0
+);
+
+-- klaim percobaan login yang sedang diperiksa, untuk database yang sudah ada
+ALTER TABLE login_attempts ADD COLUMN IF NOT EXISTS claimed_until BIGINT NOT NULL DEFAULT 0;
+
+-- tanpa foreign key agar catatan tetap ada setelah user dihapus
+CREATE TABLE IF NOT EXISTS audit_logs(
+    id SERIAL PRIMARY KEY,
+    action VARCHAR (50) NOT NULL,
+    users_id INT NOT NULL DEFAULT 0,
+    actor_id INT NOT NULL DEFAULT 0,
+    ip VARCHAR (50) NOT NULL DEFAULT '',
+    detail TEXT NOT NULL DEFAULT '',
+    created_at BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_logs_users_idx ON audit_logs(users_id, created_at);
@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"fmt"
+	"github.com/muchlist/berita_acara/utils/logger"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NewLog tidak mengirim email melainkan menambahkan email ke file path, apabila path kosong
+// email ditulis ke log aplikasi. Digunakan untuk pengembangan dan pengujian.
+func NewLog(path string, from string) MailerAssumer {
+	return &logMailer{path: path, from: from}
+}
+
+type logMailer struct {
+	mu   sync.Mutex
+	path string
+	from string
+}
+
+func (l *logMailer) Send(msg Message) rest_err.APIError {
+	if apiErr := validate(msg); apiErr != nil {
+		return apiErr
+	}
+	mail := compose(l.from, msg, time.Now())
+
+	if l.path == "" {
+		logger.Info(fmt.Sprintf("email untuk %s\n%s", strings.Join(msg.To, ", "), mail))
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return rest_err.NewInternalServerError("gagal menulis email", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(mail, []byte("\r\n")...)); err != nil {
+		return rest_err.NewInternalServerError("gagal menulis email", err)
+	}
+	return nil
+}
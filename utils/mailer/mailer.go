@@ -0,0 +1,75 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/muchlist/berita_acara/configs"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"mime"
+	"strings"
+	"time"
+)
+
+// Driver yang dapat dipilih melalui BA_MAIL_DRIVER
+const (
+	DriverSMTP = "smtp"
+	DriverLog  = "log"
+)
+
+// Message email plain text
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+type MailerAssumer interface {
+	Send(msg Message) rest_err.APIError
+}
+
+// NewMailer memilih implementasi berdasarkan BA_MAIL_DRIVER, selain smtp email hanya ditulis ke
+// BA_MAIL_LOG_FILE atau ke log aplikasi sehingga dapat digunakan tanpa server email
+func NewMailer() MailerAssumer {
+	if configs.Config.MAILDRIVER == DriverSMTP {
+		return NewSMTP(SMTPConfig{
+			Host:     configs.Config.SMTPHOST,
+			Port:     configs.Config.SMTPPORT,
+			Username: configs.Config.SMTPUSERNAME,
+			Password: configs.Config.SMTPPASSWORD,
+			From:     configs.Config.MAILFROM,
+		})
+	}
+	return NewLog(configs.Config.MAILLOGFILE, configs.Config.MAILFROM)
+}
+
+// compose membuat email lengkap dengan header sesuai RFC 5322, baris dipisahkan CRLF
+func compose(from string, msg Message, now time.Time) []byte {
+	var b bytes.Buffer
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + strings.Join(msg.To, ", ") + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + now.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	body := strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n")
+	b.WriteString(body)
+	if !strings.HasSuffix(body, "\r\n") {
+		b.WriteString("\r\n")
+	}
+	return b.Bytes()
+}
+
+func validate(msg Message) rest_err.APIError {
+	if len(msg.To) == 0 {
+		return rest_err.NewInternalServerError("gagal mengirim email", fmt.Errorf("penerima email kosong"))
+	}
+	for _, to := range append(msg.To, msg.Subject) {
+		// mencegah header injection
+		if strings.ContainsAny(to, "\r\n") {
+			return rest_err.NewInternalServerError("gagal mengirim email", fmt.Errorf("header email tidak valid"))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package mailer
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// smtpStandIn server SMTP minimal yang menerima satu email tanpa TLS dan autentikasi
+type smtpStandIn struct {
+	listener net.Listener
+	received chan receivedMail
+}
+
+type receivedMail struct {
+	from string
+	to   []string
+	data string
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpStandIn{listener: listener, received: make(chan receivedMail, 1)}
+	t.Cleanup(func() { _ = listener.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *smtpStandIn) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpStandIn) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost ESMTP")
+
+	var mail receivedMail
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			mail.from = strings.Trim(strings.TrimPrefix(cmd, "MAIL FROM:"), "<>")
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			mail.to = append(mail.to, strings.Trim(strings.TrimPrefix(cmd, "RCPT TO:"), "<>"))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 lanjutkan")
+			var data strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			mail.data = data.String()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 bye")
+			s.received <- mail
+			return
+		default:
+			reply("502 tidak didukung")
+		}
+	}
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	server := newSMTPStandIn(t)
+	mailer := NewSMTP(SMTPConfig{Host: "127.0.0.1", Port: server.port(), From: "noreply@example.com"})
+
+	apiErr := mailer.Send(Message{
+		To:      []string{"muchlis@example.com"},
+		Subject: "Reset password",
+		Body:    "baris pertama\nbaris kedua",
+	})
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+
+	mail := <-server.received
+	if mail.from != "noreply@example.com" || len(mail.to) != 1 || mail.to[0] != "muchlis@example.com" {
+		t.Errorf("envelope tidak sesuai: %+v", mail)
+	}
+	for _, want := range []string{"Subject: Reset password\r\n", "To: muchlis@example.com\r\n", "\r\n\r\nbaris pertama\r\nbaris kedua\r\n"} {
+		if !strings.Contains(mail.data, want) {
+			t.Errorf("email harusnya berisi %q:\n%s", want, mail.data)
+		}
+	}
+}
+
+func TestLogMailer_Send(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	mailer := NewLog(path, "noreply@example.com")
+
+	for _, subject := range []string{"pertama", "kedua"} {
+		if apiErr := mailer.Send(Message{To: []string{"muchlis@example.com"}, Subject: subject, Body: "isi"}); apiErr != nil {
+			t.Fatal(apiErr)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "Subject: pertama") || !strings.Contains(string(content), "Subject: kedua") {
+		t.Errorf("seluruh email harusnya tertulis pada file:\n%s", content)
+	}
+}
+
+func TestSend_RejectHeaderInjection(t *testing.T) {
+	mailer := NewLog(filepath.Join(t.TempDir(), "mail.log"), "noreply@example.com")
+	if apiErr := mailer.Send(Message{To: []string{"a@example.com\r\nBcc: b@example.com"}, Subject: "x"}); apiErr == nil {
+		t.Error("penerima dengan baris baru harusnya ditolak")
+	}
+	if apiErr := mailer.Send(Message{Subject: "x"}); apiErr == nil {
+		t.Error("email tanpa penerima harusnya ditolak")
+	}
+}
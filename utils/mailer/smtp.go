@@ -0,0 +1,90 @@
+package mailer
+
+import (
+	"crypto/tls"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+const smtpTimeout = 10 * time.Second
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// NewSMTP mengirim email melalui server SMTP, STARTTLS digunakan apabila didukung server
+// dan autentikasi hanya dilakukan apabila Username diisi
+func NewSMTP(config SMTPConfig) MailerAssumer {
+	return &smtpMailer{config: config}
+}
+
+type smtpMailer struct {
+	config SMTPConfig
+}
+
+func (s *smtpMailer) Send(msg Message) rest_err.APIError {
+	if apiErr := validate(msg); apiErr != nil {
+		return apiErr
+	}
+	if err := s.send(msg); err != nil {
+		return rest_err.NewInternalServerError("gagal mengirim email", err)
+	}
+	return nil
+}
+
+func (s *smtpMailer) send(msg Message) error {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port)), smtpTimeout)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(smtpTimeout)); err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.config.Host}); err != nil {
+			return err
+		}
+	}
+	if s.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(s.config.From); err != nil {
+		return err
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(compose(s.config.From, msg, time.Now())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -1,25 +1,88 @@
 package mcrypt
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/muchlist/berita_acara/configs"
 	"github.com/muchlist/berita_acara/utils/rest_err"
 	"golang.org/x/crypto/bcrypt"
+	"strings"
 )
 
-func NewCrypto() BcryptAssumer {
-	return &cryptoObj{}
+// Algoritma hash password yang dapat dipilih melalui BA_PASSWORD_ALGORITHM
+const (
+	AlgBcrypt   = "bcrypt"
+	AlgArgon2id = "argon2id"
+)
+
+// HasherConfig parameter hash password baru, hash lama dengan parameter berbeda tetap dapat
+// diverifikasi dan ditandai NeedsRehash
+type HasherConfig struct {
+	Algorithm  string
+	BcryptCost int
+	Argon2     Argon2Params
+}
+
+// NewCrypto membuat hasher dari konfigurasi BA_PASSWORD_ALGORITHM, BA_BCRYPT_COST dan BA_ARGON2_*
+func NewCrypto() HasherAssumer {
+	return NewHasher(HasherConfig{
+		Algorithm:  configs.Config.PASSWORDALGORITHM,
+		BcryptCost: configs.Config.BCRYPTCOST,
+		Argon2: Argon2Params{
+			Memory:  uint32(configs.Config.ARGON2MEMORY),
+			Time:    uint32(configs.Config.ARGON2TIME),
+			Threads: uint8(configs.Config.ARGON2THREADS),
+			SaltLen: argon2SaltLength,
+			KeyLen:  argon2KeyLength,
+		},
+	})
+}
+
+// NewHasher membuat hasher dengan parameter config, algoritma selain bcrypt dianggap argon2id
+func NewHasher(config HasherConfig) HasherAssumer {
+	if config.Algorithm != AlgBcrypt {
+		config.Algorithm = AlgArgon2id
+	}
+	if config.BcryptCost < bcrypt.MinCost || config.BcryptCost > bcrypt.MaxCost {
+		config.BcryptCost = bcrypt.DefaultCost
+	}
+	if config.Argon2.Memory == 0 || config.Argon2.Time == 0 || config.Argon2.Threads == 0 {
+		config.Argon2 = Argon2Params{Memory: 64 * 1024, Time: 3, Threads: 2}
+	}
+	if config.Argon2.SaltLen == 0 {
+		config.Argon2.SaltLen = argon2SaltLength
+	}
+	if config.Argon2.KeyLen == 0 {
+		config.Argon2.KeyLen = argon2KeyLength
+	}
+	return &cryptoObj{config: config}
 }
 
-type BcryptAssumer interface {
+type HasherAssumer interface {
 	GenerateHash(password string) (string, rest_err.APIError)
 	IsPWAndHashPWMatch(password string, hashPass string) bool
+	NeedsRehash(hashPass string) bool
 }
 
-type cryptoObj struct{}
+type cryptoObj struct {
+	config HasherConfig
+}
 
-// GenerateHash membuat hashpassword, hash password 1 dengan yang lainnya akan berbeda meskipun
-// inputannya sama, sehingga untuk membandingkan hashpassword memerlukan method lain IsPWAndHashPWMatch
+// GenerateHash membuat hashpassword dengan algoritma dan parameter yang dikonfigurasi. Hash password 1
+// dengan yang lainnya akan berbeda meskipun inputannya sama karena menggunakan salt acak,
+// sehingga untuk membandingkan hashpassword memerlukan method lain IsPWAndHashPWMatch
 func (c *cryptoObj) GenerateHash(password string) (string, rest_err.APIError) {
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if c.config.Algorithm == AlgArgon2id {
+		passwordHash, err := generateArgon2id(password, c.config.Argon2)
+		if err != nil {
+			return "", rest_err.NewInternalServerError("Crypto error", err)
+		}
+		return passwordHash, nil
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), c.config.BcryptCost)
 	if err != nil {
 		restErr := rest_err.NewInternalServerError("Crypto error", err)
 		return "", restErr
@@ -27,8 +90,93 @@ func (c *cryptoObj) GenerateHash(password string) (string, rest_err.APIError) {
 	return string(passwordHash), nil
 }
 
-// IsPWAndHashPWMatch return true jika inputan password dan hashpassword sesuai
+// IsPWAndHashPWMatch return true jika inputan password dan hashpassword sesuai,
+// algoritma dikenali dari format hashpassword sehingga hash bcrypt dan argon2id dapat digunakan bersamaan
 func (c *cryptoObj) IsPWAndHashPWMatch(password string, hashPass string) bool {
+	if strings.HasPrefix(hashPass, argon2idPrefix) {
+		return compareArgon2id(password, hashPass)
+	}
 	err := bcrypt.CompareHashAndPassword([]byte(hashPass), []byte(password))
 	return err == nil
 }
+
+// NeedsRehash return true jika hashpassword dibuat dengan algoritma atau parameter yang berbeda dari
+// konfigurasi saat ini, dipanggil setelah password cocok agar hash dapat diperbarui
+func (c *cryptoObj) NeedsRehash(hashPass string) bool {
+	if strings.HasPrefix(hashPass, argon2idPrefix) {
+		if c.config.Algorithm != AlgArgon2id {
+			return true
+		}
+		params, _, _, err := decodeArgon2id(hashPass)
+		return err != nil || params != c.config.Argon2
+	}
+
+	if c.config.Algorithm != AlgBcrypt {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(hashPass))
+	return err != nil || cost != c.config.BcryptCost
+}
+
+// GenerateRandomToken membuat token acak yang tidak dapat ditebak dari crypto/rand
+// sepanjang byteLength byte dalam bentuk base64 url safe tanpa padding
+func GenerateRandomToken(byteLength int) (string, rest_err.APIError) {
+	b := make([]byte, byteLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", rest_err.NewInternalServerError("Crypto error", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// unusablePasswordLength panjang password acak yang tidak pernah diberikan kepada siapapun
+const unusablePasswordLength = 32
+
+// GenerateUnusablePasswordHash membuat hash dari password acak yang tidak pernah diberikan kepada siapapun,
+// digunakan untuk user yang tidak login menggunakan password seperti service account dan user hasil provisioning OIDC
+func GenerateUnusablePasswordHash(hasher HasherAssumer) (string, rest_err.APIError) {
+	password, apiErr := GenerateRandomToken(unusablePasswordLength)
+	if apiErr != nil {
+		return "", apiErr
+	}
+	return hasher.GenerateHash(password)
+}
+
+// HashToken hash sha256 hex dari token acak yang disimpan di database, token sudah cukup acak
+// sehingga tidak memerlukan salt maupun bcrypt
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+const (
+	apiKeyPrefix    = "ba_"
+	apiKeyIDLength  = 9  // 12 karakter base64
+	apiKeyLength    = 32 // 43 karakter base64
+	apiKeySeparator = "."
+)
+
+// GenerateAPIKey membuat api key dengan format ba_<id>.<secret>. id bersifat publik dan digunakan untuk
+// mencari key di database, hanya HashToken dari key lengkap yang disimpan
+func GenerateAPIKey() (id string, key string, apiErr rest_err.APIError) {
+	id, apiErr = GenerateRandomToken(apiKeyIDLength)
+	if apiErr != nil {
+		return "", "", apiErr
+	}
+	secret, apiErr := GenerateRandomToken(apiKeyLength)
+	if apiErr != nil {
+		return "", "", apiErr
+	}
+	return id, apiKeyPrefix + id + apiKeySeparator + secret, nil
+}
+
+// ParseAPIKeyID mengambil id dari api key, ok bernilai false jika format key tidak sesuai
+func ParseAPIKeyID(key string) (id string, ok bool) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(key, apiKeyPrefix), apiKeySeparator, 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+	return parts[0], true
+}
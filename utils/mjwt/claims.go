@@ -1,6 +1,10 @@
 package mjwt
 
 import (
+	"errors"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"net/http"
 	"time"
 )
 
@@ -8,6 +12,8 @@ import (
 const (
 	Access int = iota
 	Refresh
+	MFAChallenge // hanya dapat digunakan untuk langkah kedua login dan pendaftaran MFA
+	APIKey       // claims hasil autentikasi header X-API-Key, tidak pernah diterbitkan sebagai token
 )
 
 type CustomClaim struct {
@@ -18,4 +24,64 @@ type CustomClaim struct {
 	Type        int
 	Fresh       bool
 	Roles       []string
+	TokenID     string // jti, digunakan untuk rotasi refresh token dan denylist access token
+	IssuedAt    int64  // iat, diisi oleh GenerateToken. Apabila diisi melebihi waktu sekarang digunakan sebagai iat token baru
+	SessionID   string // sid, family refresh token tempat access token diterbitkan
+	ActorID     int    // act.sub, admin yang melakukan impersonation terhadap Identity, 0 apabila bukan impersonation
+}
+
+// IsImpersonated return true jika token diterbitkan untuk admin yang melakukan impersonation
+func (c *CustomClaim) IsImpersonated() bool {
+	return c.ActorID != 0
+}
+
+// IsFresh return true jika token fresh (hasil login, bukan refresh) dan belum melewati
+// lifetime fresh sejak diterbitkan. Lifetime 0 berarti fresh selama token berlaku.
+// Token impersonation tidak pernah fresh.
+func (c *CustomClaim) IsFresh(now time.Time) bool {
+	if !c.Fresh || c.IsImpersonated() {
+		return false
+	}
+	return freshLifetime == 0 || now.Unix() <= c.IssuedAt+int64(freshLifetime/time.Second)
+}
+
+// tokenClaims bentuk claims di dalam token, sub berisi identity dalam bentuk string
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Identity int      `json:"identity"`
+	Name     string   `json:"name"`
+	Roles    []string `json:"roles"`
+	Type     int      `json:"type"`
+	Fresh    bool     `json:"fresh"`
+	Session  string   `json:"sid,omitempty"`
+	Actor    *actor   `json:"act,omitempty"`
+}
+
+// actor claim act (RFC 8693), sub berisi identity admin yang melakukan impersonation
+type actor struct {
+	Subject string `json:"sub"`
+}
+
+// validate memeriksa waktu berlaku token dengan toleransi leeway serta issuer dan audience
+func (c *tokenClaims) validate(now time.Time) rest_err.APIError {
+	if !c.VerifyExpiresAt(now.Add(-leeway), true) {
+		return rest_err.NewAPIError("Token kadaluarsa", http.StatusUnauthorized, "jwt_expired", nil)
+	}
+	// iat dapat dimajukan 1 detik oleh GenerateToken agar melewati batas waktu denylist milik user
+	if !c.VerifyNotBefore(now.Add(leeway), false) || !c.VerifyIssuedAt(now.Add(leeway+time.Second), false) {
+		return rest_err.NewAPIError("Token belum berlaku", http.StatusUnauthorized, "jwt_not_valid_yet", nil)
+	}
+	if !c.VerifyIssuer(issuer, true) || !c.VerifyAudience(audience, true) {
+		return rest_err.NewAPIError("Issuer atau audience token tidak sesuai", http.StatusUnauthorized, "jwt_invalid_claims", nil)
+	}
+	return nil
+}
+
+// parseError membedakan token yang signaturenya tidak valid dengan token yang formatnya rusak
+func parseError(err error) rest_err.APIError {
+	var validationErr *jwt.ValidationError
+	if errors.As(err, &validationErr) && validationErr.Errors&(jwt.ValidationErrorUnverifiable|jwt.ValidationErrorSignatureInvalid) != 0 {
+		return rest_err.NewAPIError("Signature token tidak valid", http.StatusUnauthorized, "jwt_invalid_signature", []interface{}{err.Error()})
+	}
+	return rest_err.NewAPIError("Token tidak valid", http.StatusUnprocessableEntity, "jwt_error", []interface{}{err.Error()})
 }
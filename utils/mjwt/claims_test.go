@@ -0,0 +1,218 @@
+package mjwt
+
+import (
+	"github.com/golang-jwt/jwt/v4"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	issuer = "berita_acara"
+	audience = "berita_acara"
+	leeway = 30 * time.Second
+	os.Exit(m.Run())
+}
+
+func signClaims(t *testing.T, claims tokenClaims) string {
+	t.Helper()
+	ks, err := newKeySet(AlgHS256, []byte("secret"), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys = ks
+	token, err := ks.sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func registered(now time.Time) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Issuer:    issuer,
+		Subject:   "1",
+		Audience:  jwt.ClaimStrings{audience},
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		NotBefore: jwt.NewNumericDate(now),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ID:        "abc",
+	}
+}
+
+func TestValidateToken_Errors(t *testing.T) {
+	now := time.Now()
+
+	expired := registered(now.Add(-2 * time.Hour))
+	withinLeeway := registered(now)
+	withinLeeway.ExpiresAt = jwt.NewNumericDate(now.Add(-10 * time.Second))
+	notYet := registered(now.Add(time.Hour))
+	wrongAudience := registered(now)
+	wrongAudience.Audience = jwt.ClaimStrings{"service_lain"}
+	wrongIssuer := registered(now)
+	wrongIssuer.Issuer = "service_lain"
+
+	tests := []struct {
+		name    string
+		claims  jwt.RegisteredClaims
+		wantErr string
+	}{
+		{"valid", registered(now), ""},
+		{"exp masih dalam leeway", withinLeeway, ""},
+		{"kadaluarsa", expired, "jwt_expired"},
+		{"belum berlaku", notYet, "jwt_not_valid_yet"},
+		{"audience salah", wrongAudience, "jwt_invalid_claims"},
+		{"issuer salah", wrongIssuer, "jwt_invalid_claims"},
+	}
+	for _, tt := range tests {
+		token := signClaims(t, tokenClaims{RegisteredClaims: tt.claims, Identity: 1, Name: "muchlis"})
+		_, apiErr := NewJwt().ValidateToken(token)
+		switch {
+		case tt.wantErr == "" && apiErr != nil:
+			t.Errorf("%s: error = %v", tt.name, apiErr)
+		case tt.wantErr != "" && (apiErr == nil || !strings.Contains(apiErr.Error(), tt.wantErr)):
+			t.Errorf("%s: error = %v, want %s", tt.name, apiErr, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateToken_BadSignature(t *testing.T) {
+	token := signClaims(t, tokenClaims{RegisteredClaims: registered(time.Now()), Identity: 1})
+
+	other, err := newKeySet(AlgHS256, []byte("secret lain"), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys = other
+	_, apiErr := NewJwt().ValidateToken(token)
+	if apiErr == nil || !strings.Contains(apiErr.Error(), "jwt_invalid_signature") {
+		t.Errorf("error = %v, want jwt_invalid_signature", apiErr)
+	}
+
+	_, apiErr = NewJwt().ValidateToken("bukan.token")
+	if apiErr == nil || !strings.Contains(apiErr.Error(), "jwt_error") {
+		t.Errorf("error = %v, want jwt_error", apiErr)
+	}
+}
+
+func TestReadToken_Typed(t *testing.T) {
+	token := signClaims(t, tokenClaims{RegisteredClaims: registered(time.Now()), Identity: 7, Name: "muchlis",
+		Roles: []string{"ADMIN"}, Type: Refresh, Fresh: true})
+
+	parsed, apiErr := NewJwt().ValidateToken(token)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	claims, apiErr := NewJwt().ReadToken(parsed)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	if claims.Identity != 7 || claims.Name != "muchlis" || claims.Type != Refresh || !claims.Fresh ||
+		len(claims.Roles) != 1 || claims.TokenID != "abc" || claims.IssuedAt == 0 || claims.Exp == 0 {
+		t.Errorf("claims tidak sesuai: %+v", claims)
+	}
+}
+
+func TestGenerateToken_ReturnsEmbeddedExpiry(t *testing.T) {
+	signClaims(t, tokenClaims{})
+	token, expired, apiErr := NewJwt().GenerateToken(CustomClaim{Identity: 1, ExtraMinute: 60})
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	parsed, apiErr := NewJwt().ValidateToken(token)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	claims, apiErr := NewJwt().ReadToken(parsed)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	if claims.Exp != expired || expired-claims.IssuedAt != 60*60 {
+		t.Errorf("expired = %d, exp pada token = %d, iat = %d", expired, claims.Exp, claims.IssuedAt)
+	}
+}
+
+func TestCustomClaim_IsFresh(t *testing.T) {
+	freshLifetime = 15 * time.Minute
+	defer func() { freshLifetime = 0 }()
+
+	now := time.Now()
+	claims := CustomClaim{Fresh: true, IssuedAt: now.Add(-10 * time.Minute).Unix()}
+	if !claims.IsFresh(now) {
+		t.Error("token harusnya masih fresh")
+	}
+	claims.IssuedAt = now.Add(-20 * time.Minute).Unix()
+	if claims.IsFresh(now) {
+		t.Error("token harusnya sudah tidak fresh")
+	}
+	claims.Fresh = false
+	claims.IssuedAt = now.Unix()
+	if claims.IsFresh(now) {
+		t.Error("token hasil refresh tidak pernah fresh")
+	}
+}
+
+func TestGenerateToken_SessionID(t *testing.T) {
+	signClaims(t, tokenClaims{})
+	token, _, apiErr := NewJwt().GenerateToken(CustomClaim{Identity: 1, ExtraMinute: 60, SessionID: "family-1"})
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	parsed, apiErr := NewJwt().ValidateToken(token)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	claims, apiErr := NewJwt().ReadToken(parsed)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	if claims.SessionID != "family-1" {
+		t.Errorf("sid = %q, harusnya family-1", claims.SessionID)
+	}
+}
+
+func TestGenerateToken_Actor(t *testing.T) {
+	signClaims(t, tokenClaims{})
+	token, _, apiErr := NewJwt().GenerateToken(CustomClaim{Identity: 7, ExtraMinute: 15, Fresh: true, ActorID: 1})
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	parsed, apiErr := NewJwt().ValidateToken(token)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	claims, apiErr := NewJwt().ReadToken(parsed)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	if claims.Identity != 7 || claims.ActorID != 1 || !claims.IsImpersonated() {
+		t.Errorf("identity = %d, actor = %d, harusnya 7 dan 1", claims.Identity, claims.ActorID)
+	}
+	if claims.IsFresh(time.Now()) {
+		t.Error("token impersonation tidak pernah fresh")
+	}
+}
+
+func TestGenerateToken_IssuedAfterCutoff(t *testing.T) {
+	leeway = 0
+	defer func() { leeway = 30 * time.Second }()
+
+	signClaims(t, tokenClaims{})
+	cutoff := time.Now().Unix()
+	token, _, apiErr := NewJwt().GenerateToken(CustomClaim{Identity: 1, ExtraMinute: 60, IssuedAt: cutoff + 1})
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	parsed, apiErr := NewJwt().ValidateToken(token)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	claims, apiErr := NewJwt().ReadToken(parsed)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	if claims.IssuedAt <= cutoff {
+		t.Errorf("iat = %d, harusnya setelah batas waktu %d", claims.IssuedAt, cutoff)
+	}
+}
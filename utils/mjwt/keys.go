@@ -0,0 +1,211 @@
+package mjwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"math/big"
+)
+
+// Algoritma yang dapat dipilih melalui BA_JWT_ALGORITHM
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+const kidHeader = "kid"
+
+// JWK public key dalam format JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet daftar public key yang dapat digunakan service lain untuk memverifikasi token
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    crypto.PublicKey
+	jwk    JWK
+}
+
+// keySet berisi key untuk menandatangani token dan seluruh key yang masih diterima saat verifikasi.
+// Untuk RS256 dan EdDSA setiap token membawa header kid sehingga key lama tetap dapat
+// memverifikasi token yang terbit sebelum rotasi.
+type keySet struct {
+	method     jwt.SigningMethod
+	signingKey interface{}
+	signingKID string
+	verifyKeys map[string]verificationKey
+	kids       []string // urutan key pada JWKS, key penandatangan paling awal
+}
+
+// newKeySet membuat keySet dari algoritma dan PEM. Untuk HS256 token ditandatangani dengan secret,
+// selain itu privateKeyPEM wajib diisi. publicKeyPEMs berisi public key lama yang masih diterima.
+func newKeySet(algorithm string, secret []byte, privateKeyPEM []byte, publicKeyPEMs [][]byte) (*keySet, error) {
+	ks := &keySet{
+		verifyKeys: make(map[string]verificationKey),
+	}
+
+	switch algorithm {
+	case "", AlgHS256:
+		if len(secret) == 0 {
+			return nil, errors.New("secret HS256 tidak boleh kosong")
+		}
+		ks.method = jwt.SigningMethodHS256
+		ks.signingKey = secret
+	case AlgRS256, AlgEdDSA:
+		privateKey, err := parsePrivateKey(privateKeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("private key jwt tidak valid: %w", err)
+		}
+		signer, ok := privateKey.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("private key jwt tidak didukung")
+		}
+		vk, err := newVerificationKey(signer.Public())
+		if err != nil {
+			return nil, err
+		}
+		if vk.method.Alg() != algorithm {
+			return nil, fmt.Errorf("private key jwt tidak sesuai dengan algoritma %s", algorithm)
+		}
+		ks.method = vk.method
+		ks.signingKey = privateKey
+		ks.signingKID = vk.jwk.Kid
+		ks.addVerificationKey(vk)
+	default:
+		return nil, fmt.Errorf("algoritma jwt %s tidak didukung", algorithm)
+	}
+
+	for _, publicKeyPEM := range publicKeyPEMs {
+		publicKey, err := parsePublicKey(publicKeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("public key jwt tidak valid: %w", err)
+		}
+		vk, err := newVerificationKey(publicKey)
+		if err != nil {
+			return nil, err
+		}
+		ks.addVerificationKey(vk)
+	}
+
+	return ks, nil
+}
+
+func (k *keySet) addVerificationKey(vk verificationKey) {
+	if _, exist := k.verifyKeys[vk.jwk.Kid]; exist {
+		return
+	}
+	k.verifyKeys[vk.jwk.Kid] = vk
+	k.kids = append(k.kids, vk.jwk.Kid)
+}
+
+func (k *keySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.method, claims)
+	if k.signingKID != "" {
+		token.Header[kidHeader] = k.signingKID
+	}
+	return token.SignedString(k.signingKey)
+}
+
+// keyFunc memilih key verifikasi. Token dengan kid diverifikasi dengan public key terkait dan
+// algoritmanya harus sesuai dengan tipe key, token tanpa kid hanya diterima pada mode HS256.
+func (k *keySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header[kidHeader].(string)
+	if kid == "" {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || k.method != jwt.SigningMethodHS256 {
+			return nil, errors.New("token signing method salah")
+		}
+		return k.signingKey, nil
+	}
+
+	vk, ok := k.verifyKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("kid %s tidak dikenali", kid)
+	}
+	if token.Method.Alg() != vk.method.Alg() {
+		return nil, errors.New("token signing method salah")
+	}
+	return vk.key, nil
+}
+
+func (k *keySet) jwks() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(k.kids))}
+	for _, kid := range k.kids {
+		set.Keys = append(set.Keys, k.verifyKeys[kid].jwk)
+	}
+	return set
+}
+
+// newVerificationKey menentukan algoritma dari tipe public key, kid diisi dengan
+// JWK thumbprint (RFC 7638) sehingga selalu sama untuk key yang sama
+func newVerificationKey(publicKey crypto.PublicKey) (verificationKey, error) {
+	var vk verificationKey
+	var thumbprintInput string
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		n := base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		e := base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+		vk = verificationKey{
+			method: jwt.SigningMethodRS256,
+			key:    key,
+			jwk:    JWK{Kty: "RSA", Use: "sig", Alg: AlgRS256, N: n, E: e},
+		}
+		thumbprintInput = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, e, n)
+	case ed25519.PublicKey:
+		x := base64.RawURLEncoding.EncodeToString(key)
+		vk = verificationKey{
+			method: jwt.SigningMethodEdDSA,
+			key:    key,
+			jwk:    JWK{Kty: "OKP", Use: "sig", Alg: AlgEdDSA, Crv: "Ed25519", X: x},
+		}
+		thumbprintInput = fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":%q}`, x)
+	default:
+		return vk, errors.New("tipe key jwt tidak didukung, gunakan RSA atau Ed25519")
+	}
+
+	thumbprint := sha256.Sum256([]byte(thumbprintInput))
+	vk.jwk.Kid = base64.RawURLEncoding.EncodeToString(thumbprint[:])
+	return vk, nil
+}
+
+func parsePrivateKey(keyPEM []byte) (interface{}, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("key bukan PEM")
+	}
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	return x509.ParsePKCS8PrivateKey(block.Bytes)
+}
+
+func parsePublicKey(keyPEM []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("key bukan PEM")
+	}
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
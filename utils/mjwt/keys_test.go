@@ -0,0 +1,144 @@
+package mjwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func generateRSA(t *testing.T) (privatePEM []byte, publicPEM []byte) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privatePEM = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	publicPEM = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	return privatePEM, publicPEM
+}
+
+func generateEd25519(t *testing.T) (privatePEM []byte, publicPEM []byte) {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privatePEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})
+	publicPEM = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	return privatePEM, publicPEM
+}
+
+func issue(t *testing.T, ks *keySet) string {
+	t.Helper()
+	keys = ks
+	token, _, apiErr := NewJwt().GenerateToken(CustomClaim{Identity: 1, Name: "muchlis", Roles: []string{"ADMIN"}, ExtraMinute: 5})
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	return token
+}
+
+func validate(ks *keySet, token string) error {
+	keys = ks
+	_, apiErr := NewJwt().ValidateToken(token)
+	if apiErr != nil {
+		return apiErr
+	}
+	return nil
+}
+
+func TestKeySet_SignAndVerify(t *testing.T) {
+	rsaPrivate, _ := generateRSA(t)
+	edPrivate, _ := generateEd25519(t)
+
+	for _, tt := range []struct {
+		algorithm  string
+		privatePEM []byte
+	}{
+		{AlgHS256, nil},
+		{AlgRS256, rsaPrivate},
+		{AlgEdDSA, edPrivate},
+	} {
+		ks, err := newKeySet(tt.algorithm, []byte("secret"), tt.privatePEM, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.algorithm, err)
+		}
+		if err := validate(ks, issue(t, ks)); err != nil {
+			t.Errorf("%s: token harusnya valid, %v", tt.algorithm, err)
+		}
+		if tt.algorithm != AlgHS256 && len(ks.jwks().Keys) != 1 {
+			t.Errorf("%s: jwks harusnya berisi 1 key", tt.algorithm)
+		}
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	oldPrivate, oldPublic := generateRSA(t)
+	newPrivate, _ := generateEd25519(t)
+
+	oldKeys, err := newKeySet(AlgRS256, []byte("secret"), oldPrivate, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken := issue(t, oldKeys)
+
+	// key baru tanpa public key lama, token lama ditolak
+	newOnly, err := newKeySet(AlgEdDSA, []byte("secret"), newPrivate, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := validate(newOnly, oldToken); err == nil {
+		t.Error("token dari key yang tidak terdaftar harusnya ditolak")
+	}
+
+	rotated, err := newKeySet(AlgEdDSA, []byte("secret"), newPrivate, [][]byte{oldPublic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := validate(rotated, oldToken); err != nil {
+		t.Errorf("token lama harusnya masih valid selama rotasi, %v", err)
+	}
+
+	jwks := rotated.jwks()
+	if len(jwks.Keys) != 2 || jwks.Keys[0].Alg != AlgEdDSA || jwks.Keys[1].Kid != oldKeys.signingKID {
+		t.Errorf("jwks tidak sesuai: %+v", jwks)
+	}
+}
+
+func TestKeySet_RejectAlgorithmConfusion(t *testing.T) {
+	rsaPrivate, _ := generateRSA(t)
+	hmacKeys, err := newKeySet(AlgHS256, []byte("secret"), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKeys, err := newKeySet(AlgRS256, []byte("secret"), rsaPrivate, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// token HS256 tanpa kid tidak diterima apabila mode bukan HS256
+	if err := validate(rsaKeys, issue(t, hmacKeys)); err == nil {
+		t.Error("token HS256 harusnya ditolak pada mode RS256")
+	}
+
+	// key Ed25519 tidak boleh dipakai untuk algoritma RS256
+	edPrivate, _ := generateEd25519(t)
+	if _, err := newKeySet(AlgRS256, nil, edPrivate, nil); err == nil {
+		t.Error("private key Ed25519 harusnya ditolak untuk RS256")
+	}
+}
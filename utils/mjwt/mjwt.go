@@ -1,23 +1,18 @@
 package mjwt
 
 import (
-	"errors"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/muchlist/berita_acara/configs"
 	"github.com/muchlist/berita_acara/utils/rest_err"
+	"io/ioutil"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 )
 
 const (
-	CLAIMS       = "claims"
-	identityKey  = "identity"
-	nameKey      = "name"
-	rolesKey     = "roles"
-	tokenTypeKey = "type"
-	expKey       = "exp"
-	freshKey     = "fresh"
+	CLAIMS = "claims"
 )
 
 const (
@@ -25,130 +20,165 @@ const (
 )
 
 var (
-	secret []byte
+	keys *keySet
+
+	// issuer dan audience wajib sama pada setiap token, leeway toleransi perbedaan jam antar server
+	issuer   string
+	audience string
+	leeway   time.Duration
+	// freshLifetime lama token fresh dapat digunakan untuk FreshAuth sejak diterbitkan
+	freshLifetime time.Duration
 )
 
 func NewJwt() JWTAssumer {
 	return &jwtUtils{}
 }
 
+// Init memuat key jwt sesuai BA_JWT_ALGORITHM. HS256 (default) menggunakan BA_SECRET_KEY,
+// RS256 dan EdDSA menggunakan BA_JWT_PRIVATE_KEY_FILE serta BA_JWT_PUBLIC_KEY_FILES untuk key lama
 func Init() {
-	secret = []byte(configs.Config.SECRETKEY)
+	cfg := configs.Config
+	secret := []byte(cfg.SECRETKEY)
 	if string(secret) == "" {
 		log.Fatal("Secret key tidak boleh kosong, ENV : SECRET_KEY")
 	}
+
+	var privateKeyPEM []byte
+	if cfg.JWTPRIVATEKEYFILE != "" {
+		var err error
+		privateKeyPEM, err = ioutil.ReadFile(cfg.JWTPRIVATEKEYFILE)
+		if err != nil {
+			log.Fatalf("Gagal membaca private key jwt: %v", err)
+		}
+	}
+	publicKeyPEMs := make([][]byte, 0, len(cfg.JWTPUBLICKEYFILES))
+	for _, file := range cfg.JWTPUBLICKEYFILES {
+		publicKeyPEM, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Fatalf("Gagal membaca public key jwt %s: %v", file, err)
+		}
+		publicKeyPEMs = append(publicKeyPEMs, publicKeyPEM)
+	}
+
+	var err error
+	keys, err = newKeySet(cfg.JWTALGORITHM, secret, privateKeyPEM, publicKeyPEMs)
+	if err != nil {
+		log.Fatalf("Key jwt tidak valid: %v", err)
+	}
+
+	issuer = cfg.JWTISSUER
+	audience = cfg.JWTAUDIENCE
+	leeway = time.Duration(cfg.JWTLEEWAY) * time.Second
+	freshLifetime = time.Duration(cfg.FRESHTOKENMINUTE) * time.Minute
 }
 
 type JWTAssumer interface {
-	GenerateToken(claims CustomClaim) (string, rest_err.APIError)
+	GenerateToken(claims CustomClaim) (string, int64, rest_err.APIError)
 	ValidateToken(tokenString string) (*jwt.Token, rest_err.APIError)
 	ReadToken(token *jwt.Token) (*CustomClaim, rest_err.APIError)
+	JWKS() JWKSet
 }
 
 type jwtUtils struct {
 }
 
 // GenerateToken membuat token jwt untuk login header, untuk menguji nilai payloadnya
-// dapat menggunakan situs jwt.io
-func (j *jwtUtils) GenerateToken(claims CustomClaim) (string, rest_err.APIError) {
-	expired := time.Now().Add(time.Minute * claims.ExtraMinute).Unix()
-
-	jwtClaim := jwt.MapClaims{}
-	jwtClaim[identityKey] = claims.Identity
-	jwtClaim[nameKey] = claims.Name
-	jwtClaim[rolesKey] = claims.Roles
-	jwtClaim[expKey] = expired
-	jwtClaim[tokenTypeKey] = claims.Type
-	jwtClaim[freshKey] = claims.Fresh
+// dapat menggunakan situs jwt.io. Mengembalikan waktu expired (unix) yang tertanam pada token.
+func (j *jwtUtils) GenerateToken(claims CustomClaim) (string, int64, rest_err.APIError) {
+	// presisi claim waktu jwt adalah detik
+	now := time.Now().Truncate(time.Second)
+	expired := now.Add(time.Minute * claims.ExtraMinute)
+	issuedAt := now
+	if claims.IssuedAt > now.Unix() {
+		issuedAt = time.Unix(claims.IssuedAt, 0)
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwtClaim)
+	jwtClaim := tokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   strconv.Itoa(claims.Identity),
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(expired),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ID:        claims.TokenID,
+		},
+		Identity: claims.Identity,
+		Name:     claims.Name,
+		Roles:    claims.Roles,
+		Type:     claims.Type,
+		Fresh:    claims.Fresh,
+		Session:  claims.SessionID,
+	}
+	if claims.ActorID != 0 {
+		jwtClaim.Actor = &actor{Subject: strconv.Itoa(claims.ActorID)}
+	}
 
-	signedToken, err := token.SignedString(secret)
+	signedToken, err := keys.sign(jwtClaim)
 	if err != nil {
-		return "", rest_err.NewInternalServerError("gagal menandatangani token", err)
+		return "", 0, rest_err.NewInternalServerError("gagal menandatangani token", err)
 	}
 
-	return signedToken, nil
+	return signedToken, expired.Unix(), nil
 }
 
 // ReadToken membaca inputan token dan menghasilkan pointer struct CustomClaim
 // struct CustomClaim digunakan untuk nilai passing antar middleware
 func (j *jwtUtils) ReadToken(token *jwt.Token) (*CustomClaim, rest_err.APIError) {
-	claims, ok := token.Claims.(jwt.MapClaims)
+	claims, ok := token.Claims.(*tokenClaims)
 	if !ok || !token.Valid {
-		return nil, rest_err.NewInternalServerError("gagal mapping token", nil)
-	}
-
-	identity, ok := claims[identityKey].(float64)
-	if !ok {
-		return nil, rest_err.NewInternalServerError(mappingError, nil)
-	}
-	name, ok := claims[nameKey].(string)
-	if !ok {
-		return nil, rest_err.NewInternalServerError(mappingError, nil)
-	}
-	exp, ok := claims[expKey].(float64)
-	if !ok {
 		return nil, rest_err.NewInternalServerError(mappingError, nil)
 	}
-	roles, err := iToSliceString(claims[rolesKey])
-	if err != nil {
-		return nil, rest_err.NewInternalServerError(mappingError, err)
+
+	customClaim := CustomClaim{
+		Identity:  claims.Identity,
+		Name:      claims.Name,
+		Roles:     claims.Roles,
+		Type:      claims.Type,
+		Fresh:     claims.Fresh,
+		TokenID:   claims.ID,
+		SessionID: claims.Session,
 	}
-	tokenType, ok := claims[tokenTypeKey].(float64)
-	if !ok {
-		return nil, rest_err.NewInternalServerError(mappingError, nil)
+	if claims.ExpiresAt != nil {
+		customClaim.Exp = claims.ExpiresAt.Unix()
 	}
-	fresh, ok := claims[freshKey].(bool)
-	if !ok {
-		return nil, rest_err.NewInternalServerError(mappingError, nil)
+	if claims.IssuedAt != nil {
+		customClaim.IssuedAt = claims.IssuedAt.Unix()
 	}
-
-	customClaim := CustomClaim{
-		Identity: int(identity),
-		Name:     name,
-		Exp:      int64(exp),
-		Roles:    roles,
-		Type:     int(tokenType),
-		Fresh:    fresh,
+	if claims.Actor != nil {
+		// token impersonation dengan act yang tidak valid ditolak agar tidak terbaca sebagai token biasa
+		actorID, err := strconv.Atoi(claims.Actor.Subject)
+		if err != nil || actorID == 0 {
+			return nil, rest_err.NewAPIError("Token tidak valid", http.StatusUnprocessableEntity, "jwt_error", []interface{}{"act tidak valid"})
+		}
+		customClaim.ActorID = actorID
 	}
 
 	return &customClaim, nil
 }
 
-// ValidateToken memvalidasi apakah token string masukan valid, termasuk memvalidasi apabila field exp nya kadaluarsa
+// ValidateToken memvalidasi signature token beserta claims exp, nbf, iat, iss dan aud.
+// Pemeriksaan waktu menggunakan toleransi leeway.
 func (j *jwtUtils) ValidateToken(tokenString string) (*jwt.Token, rest_err.APIError) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		_, ok := token.Method.(*jwt.SigningMethodHMAC)
-		if !ok {
-			return nil, rest_err.NewAPIError("Token signing method salah", http.StatusUnprocessableEntity, "jwt_error", nil)
-		}
-		return secret, nil
-	})
-
-	// Jika expired akan muncul disini asalkan ada claims exp
+	// validasi claims bawaan library dilewati karena tidak mendukung leeway
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.ParseWithClaims(tokenString, &tokenClaims{}, keys.keyFunc)
 	if err != nil {
-		return nil, rest_err.NewAPIError("Token tidak valid", http.StatusUnprocessableEntity, "jwt_error", []interface{}{err.Error()})
+		return nil, parseError(err)
+	}
+
+	claims, ok := token.Claims.(*tokenClaims)
+	if !ok {
+		return nil, rest_err.NewInternalServerError(mappingError, nil)
+	}
+	if apiErr := claims.validate(time.Now()); apiErr != nil {
+		return nil, apiErr
 	}
 
 	return token, nil
 }
 
-func iToSliceString(assumedSliceInterface interface{}) ([]string, error) {
-	sliceInterface, ok := assumedSliceInterface.([]interface{})
-	if !ok {
-		return nil, errors.New("type bukan slice interface")
-	}
-	sliceString := make([]string, len(sliceInterface))
-	for i, v := range sliceInterface {
-		switch val := v.(type) {
-		case string:
-			sliceString[i] = val
-		case []byte:
-			sliceString[i] = string(val)
-		default:
-			return nil, errors.New("tidak dapat di cast ke int")
-		}
-	}
-	return sliceString, nil
+// JWKS public key yang masih diterima untuk verifikasi, kosong apabila menggunakan HS256
+func (j *jwtUtils) JWKS() JWKSet {
+	return keys.jwks()
 }
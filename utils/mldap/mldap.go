@@ -0,0 +1,254 @@
+package mldap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/muchlist/berita_acara/configs"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Backend autentikasi yang dapat dipilih melalui BA_AUTH_BACKEND
+const (
+	BackendLocal = "local"
+	BackendLDAP  = "ldap"
+)
+
+const (
+	timeout               = 10 * time.Second
+	defaultUserFilter     = "(mail=%s)"
+	defaultGroupAttribute = "memberOf"
+)
+
+// Config konfigurasi koneksi LDAP, UserFilter berisi %s yang diganti username yang sudah di-escape
+type Config struct {
+	URL                string
+	BindDN             string
+	BindPassword       string
+	BaseDN             string
+	UserFilter         string
+	GroupAttribute     string
+	StartTLS           bool
+	InsecureSkipVerify bool
+	CACertFile         string
+	GroupRoles         []GroupRole
+}
+
+// GroupRole user yang menjadi anggota Group mendapatkan Role
+type GroupRole struct {
+	Group string
+	Role  string
+}
+
+// Entry user LDAP yang berhasil bind, Roles berisi role hasil pemetaan grup tanpa duplikasi
+type Entry struct {
+	DN     string
+	Groups []string
+	Roles  []string
+}
+
+type LDAPAssumer interface {
+	Enabled() bool
+	Authenticate(username string, password string) (*Entry, rest_err.APIError)
+}
+
+// NewLDAP membuat client dari BA_LDAP_*, LDAP hanya aktif apabila BA_AUTH_BACKEND bernilai ldap
+func NewLDAP() LDAPAssumer {
+	if configs.Config.AUTHBACKEND != BackendLDAP {
+		return NewClient(Config{})
+	}
+	if configs.Config.LDAPURL == "" || configs.Config.LDAPBASEDN == "" {
+		log.Fatal("URL dan base DN LDAP tidak boleh kosong, ENV : BA_LDAP_URL dan BA_LDAP_BASE_DN")
+	}
+	return NewClient(Config{
+		URL:                configs.Config.LDAPURL,
+		BindDN:             configs.Config.LDAPBINDDN,
+		BindPassword:       configs.Config.LDAPBINDPASSWORD,
+		BaseDN:             configs.Config.LDAPBASEDN,
+		UserFilter:         configs.Config.LDAPUSERFILTER,
+		GroupAttribute:     configs.Config.LDAPGROUPATTRIBUTE,
+		StartTLS:           configs.Config.LDAPSTARTTLS,
+		InsecureSkipVerify: configs.Config.LDAPTLSSKIPVERIFY,
+		CACertFile:         configs.Config.LDAPCACERTFILE,
+		GroupRoles:         ParseGroupRoles(configs.Config.LDAPGROUPROLES),
+	})
+}
+
+func NewClient(config Config) LDAPAssumer {
+	if config.UserFilter == "" {
+		config.UserFilter = defaultUserFilter
+	}
+	if config.GroupAttribute == "" {
+		config.GroupAttribute = defaultGroupAttribute
+	}
+	return &ldapClient{config: config}
+}
+
+type ldapClient struct {
+	config Config
+}
+
+func (l *ldapClient) Enabled() bool {
+	return l.config.URL != ""
+}
+
+// Authenticate mencari user berdasarkan UserFilter menggunakan BindDN lalu bind sebagai user tersebut dengan password.
+// Username yang tidak ditemukan, ditemukan lebih dari satu atau password salah menghasilkan error unauthorized
+func (l *ldapClient) Authenticate(username string, password string) (*Entry, rest_err.APIError) {
+	if !l.Enabled() {
+		return nil, rest_err.NewBadRequestError("Login LDAP tidak diaktifkan")
+	}
+	// password kosong menghasilkan unauthenticated bind yang selalu berhasil
+	if username == "" || password == "" {
+		return nil, rest_err.NewUnauthorizedError("Username atau password tidak valid")
+	}
+
+	conn, apiErr := l.dial()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	defer conn.Close()
+
+	if l.config.BindDN != "" {
+		if err := conn.Bind(l.config.BindDN, l.config.BindPassword); err != nil {
+			return nil, rest_err.NewInternalServerError("gagal bind ke server LDAP", err)
+		}
+	}
+
+	result, err := conn.Search(ldap.NewSearchRequest(
+		l.config.BaseDN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		2,
+		int(timeout.Seconds()),
+		false,
+		fmt.Sprintf(l.config.UserFilter, ldap.EscapeFilter(username)),
+		[]string{l.config.GroupAttribute},
+		nil,
+	))
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+			return nil, rest_err.NewUnauthorizedError("Username atau password tidak valid")
+		}
+		return nil, rest_err.NewInternalServerError("gagal mencari user LDAP", err)
+	}
+	if len(result.Entries) != 1 {
+		return nil, rest_err.NewUnauthorizedError("Username atau password tidak valid")
+	}
+	entry := result.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, rest_err.NewUnauthorizedError("Username atau password tidak valid")
+		}
+		return nil, rest_err.NewInternalServerError("gagal bind ke server LDAP", err)
+	}
+
+	groups := entry.GetAttributeValues(l.config.GroupAttribute)
+	return &Entry{
+		DN:     entry.DN,
+		Groups: groups,
+		Roles:  l.roles(groups),
+	}, nil
+}
+
+func (l *ldapClient) dial() (*ldap.Conn, rest_err.APIError) {
+	tlsConfig, apiErr := l.tlsConfig()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	conn, err := ldap.DialURL(l.config.URL, ldap.DialWithTLSDialer(tlsConfig, &net.Dialer{Timeout: timeout}))
+	if err != nil {
+		return nil, rest_err.NewInternalServerError("server LDAP tidak dapat dihubungi", err)
+	}
+	conn.SetTimeout(timeout)
+
+	if l.config.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, rest_err.NewInternalServerError("gagal StartTLS ke server LDAP", err)
+		}
+	}
+	return conn, nil
+}
+
+// tlsConfig digunakan untuk ldaps:// maupun StartTLS, CACertFile ditambahkan ke root CA selain CA sistem
+func (l *ldapClient) tlsConfig() (*tls.Config, rest_err.APIError) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: l.config.InsecureSkipVerify,
+	}
+	if parsed, err := url.Parse(l.config.URL); err == nil {
+		config.ServerName = parsed.Hostname()
+	}
+
+	if l.config.CACertFile != "" {
+		pem, err := ioutil.ReadFile(l.config.CACertFile)
+		if err != nil {
+			return nil, rest_err.NewInternalServerError("gagal membaca sertifikat CA LDAP", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, rest_err.NewInternalServerError("gagal membaca sertifikat CA LDAP", fmt.Errorf("%s tidak berisi sertifikat PEM", l.config.CACertFile))
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// roles memetakan grup ke role, DN grup dibandingkan tanpa membedakan huruf besar dan kecil
+func (l *ldapClient) roles(groups []string) []string {
+	roles := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, mapping := range l.config.GroupRoles {
+		if seen[mapping.Role] {
+			continue
+		}
+		for _, group := range groups {
+			if equalDN(group, mapping.Group) {
+				roles = append(roles, mapping.Role)
+				seen[mapping.Role] = true
+				break
+			}
+		}
+	}
+	return roles
+}
+
+func equalDN(a string, b string) bool {
+	dnA, errA := ldap.ParseDN(a)
+	dnB, errB := ldap.ParseDN(b)
+	if errA != nil || errB != nil {
+		return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+	}
+	return dnA.EqualFold(dnB)
+}
+
+// ParseGroupRoles membaca pemetaan berformat ROLE:dn grup dipisahkan titik koma, misalnya
+// ADMIN:cn=ba-admin,ou=groups,dc=example,dc=com;NORMAL:cn=staff,ou=groups,dc=example,dc=com
+func ParseGroupRoles(value string) []GroupRole {
+	var groupRoles []GroupRole
+	for _, item := range strings.Split(value, ";") {
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		role, group := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if role == "" || group == "" {
+			continue
+		}
+		groupRoles = append(groupRoles, GroupRole{Group: group, Role: strings.ToUpper(role)})
+	}
+	return groupRoles
+}
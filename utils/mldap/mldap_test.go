@@ -0,0 +1,338 @@
+package mldap
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const (
+	testBaseDN       = "dc=example,dc=com"
+	testBindDN       = "cn=service,dc=example,dc=com"
+	testBindPassword = "service-secret"
+	testAdminGroup   = "cn=ba-admin,ou=groups,dc=example,dc=com"
+	testStaffGroup   = "cn=staff,ou=groups,dc=example,dc=com"
+	startTLSOID      = "1.3.6.1.4.1.1466.20037"
+)
+
+type directoryUser struct {
+	dn       string
+	mail     string
+	password string
+	groups   []string
+}
+
+// ldapStandIn server LDAP minimal yang mendukung bind sederhana, search dengan filter equality dan StartTLS.
+// Search hanya dilayani setelah bind sebagai service account
+type ldapStandIn struct {
+	t         *testing.T
+	listener  net.Listener
+	users     []directoryUser
+	tlsConfig *tls.Config
+}
+
+func newLDAPStandIn(t *testing.T, users []directoryUser) *ldapStandIn {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &ldapStandIn{t: t, listener: listener, users: users}
+	t.Cleanup(func() { _ = listener.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *ldapStandIn) url() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+func (s *ldapStandIn) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *ldapStandIn) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	boundDN := ""
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		if len(packet.Children) < 2 {
+			return
+		}
+		messageID := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			name := op.Children[1].Value.(string)
+			password := op.Children[2].Data.String()
+			code := int64(ldap.LDAPResultInvalidCredentials)
+			if s.checkPassword(name, password) {
+				code, boundDN = ldap.LDAPResultSuccess, name
+			}
+			s.write(conn, messageID, result(ldap.ApplicationBindResponse, code))
+		case ldap.ApplicationSearchRequest:
+			if boundDN != testBindDN {
+				s.write(conn, messageID, result(ldap.ApplicationSearchResultDone, ldap.LDAPResultInsufficientAccessRights))
+				continue
+			}
+			filter, err := ldap.DecompileFilter(op.Children[6])
+			if err != nil {
+				s.write(conn, messageID, result(ldap.ApplicationSearchResultDone, ldap.LDAPResultProtocolError))
+				continue
+			}
+			for _, user := range s.users {
+				if filter == fmt.Sprintf("(mail=%s)", user.mail) {
+					s.write(conn, messageID, searchEntry(user))
+				}
+			}
+			s.write(conn, messageID, result(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess))
+		case ldap.ApplicationExtendedRequest:
+			if s.tlsConfig == nil || op.Children[0].Data.String() != startTLSOID {
+				s.write(conn, messageID, result(ldap.ApplicationExtendedResponse, ldap.LDAPResultProtocolError))
+				continue
+			}
+			s.write(conn, messageID, result(ldap.ApplicationExtendedResponse, ldap.LDAPResultSuccess))
+			conn = tls.Server(conn, s.tlsConfig)
+		case ldap.ApplicationUnbindRequest:
+			return
+		default:
+			return
+		}
+	}
+}
+
+func (s *ldapStandIn) checkPassword(dn string, password string) bool {
+	if password == "" {
+		return false
+	}
+	if dn == testBindDN {
+		return password == testBindPassword
+	}
+	for _, user := range s.users {
+		if user.dn == dn {
+			return user.password == password
+		}
+	}
+	return false
+}
+
+func (s *ldapStandIn) write(w io.Writer, messageID int64, op *ber.Packet) {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "MessageID"))
+	packet.AppendChild(op)
+	if _, err := w.Write(packet.Bytes()); err != nil {
+		s.t.Log(err)
+	}
+}
+
+func result(tag ber.Tag, code int64) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "Result Code"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+	return op
+}
+
+func searchEntry(user directoryUser) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, user.dn, "DN"))
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+	attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, defaultGroupAttribute, "Type"))
+	values := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+	for _, group := range user.groups {
+		values.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, group, "Value"))
+	}
+	attribute.AppendChild(values)
+	attributes.AppendChild(attribute)
+	op.AppendChild(attributes)
+	return op
+}
+
+var testUsers = []directoryUser{
+	{
+		dn:       "uid=budi,ou=people,dc=example,dc=com",
+		mail:     "budi@example.com",
+		password: "rahasia",
+		groups:   []string{"CN=Staff,OU=Groups,DC=example,DC=com", "cn=lain,ou=groups,dc=example,dc=com"},
+	},
+	{
+		dn:       "uid=ani,ou=people,dc=example,dc=com",
+		mail:     "ani@example.com",
+		password: "rahasia-ani",
+		groups:   []string{testAdminGroup, testStaffGroup},
+	},
+}
+
+func testConfig(url string) Config {
+	return Config{
+		URL:          url,
+		BindDN:       testBindDN,
+		BindPassword: testBindPassword,
+		BaseDN:       testBaseDN,
+		GroupRoles: []GroupRole{
+			{Group: testAdminGroup, Role: "ADMIN"},
+			{Group: testStaffGroup, Role: "NORMAL"},
+			{Group: testAdminGroup, Role: "NORMAL"},
+		},
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	server := newLDAPStandIn(t, testUsers)
+	client := NewClient(testConfig(server.url()))
+
+	for _, tt := range []struct {
+		username string
+		password string
+		dn       string
+		roles    []string
+	}{
+		{"budi@example.com", "rahasia", "uid=budi,ou=people,dc=example,dc=com", []string{"NORMAL"}},
+		{"ani@example.com", "rahasia-ani", "uid=ani,ou=people,dc=example,dc=com", []string{"ADMIN", "NORMAL"}},
+	} {
+		entry, apiErr := client.Authenticate(tt.username, tt.password)
+		if apiErr != nil {
+			t.Fatalf("%s: %v", tt.username, apiErr)
+		}
+		if entry.DN != tt.dn {
+			t.Errorf("%s: dn = %s, harusnya %s", tt.username, entry.DN, tt.dn)
+		}
+		if !reflect.DeepEqual(entry.Roles, tt.roles) {
+			t.Errorf("%s: roles = %v, harusnya %v", tt.username, entry.Roles, tt.roles)
+		}
+	}
+}
+
+func TestAuthenticate_Rejected(t *testing.T) {
+	server := newLDAPStandIn(t, testUsers)
+	client := NewClient(testConfig(server.url()))
+
+	for _, tt := range []struct {
+		name     string
+		username string
+		password string
+	}{
+		{"password salah", "budi@example.com", "salah"},
+		{"password kosong", "budi@example.com", ""},
+		{"user tidak terdaftar", "tidakada@example.com", "rahasia"},
+		{"filter injection", "*", "rahasia"},
+	} {
+		if _, apiErr := client.Authenticate(tt.username, tt.password); apiErr == nil {
+			t.Errorf("%s: harusnya ditolak", tt.name)
+		} else if apiErr.Status() != http.StatusUnauthorized {
+			t.Errorf("%s: status %d, harusnya %d", tt.name, apiErr.Status(), http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestAuthenticate_ServiceBindFailed(t *testing.T) {
+	server := newLDAPStandIn(t, testUsers)
+	config := testConfig(server.url())
+	config.BindPassword = "salah"
+
+	_, apiErr := NewClient(config).Authenticate("budi@example.com", "rahasia")
+	if apiErr == nil || apiErr.Status() != http.StatusInternalServerError {
+		t.Errorf("bind service account gagal harusnya menghasilkan internal server error, didapat %v", apiErr)
+	}
+}
+
+func TestAuthenticate_StartTLS(t *testing.T) {
+	server := newLDAPStandIn(t, testUsers)
+	certFile, tlsConfig := generateCertificate(t)
+	server.tlsConfig = tlsConfig
+
+	config := testConfig(server.url())
+	config.StartTLS = true
+	config.CACertFile = certFile
+	if _, apiErr := NewClient(config).Authenticate("budi@example.com", "rahasia"); apiErr != nil {
+		t.Fatal(apiErr)
+	}
+
+	// sertifikat server tidak dipercaya tanpa CACertFile
+	config.CACertFile = ""
+	if _, apiErr := NewClient(config).Authenticate("budi@example.com", "rahasia"); apiErr == nil {
+		t.Error("StartTLS harusnya gagal karena sertifikat tidak dipercaya")
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	client := NewClient(Config{})
+	if client.Enabled() {
+		t.Error("client tanpa url harusnya nonaktif")
+	}
+	if _, apiErr := client.Authenticate("budi@example.com", "rahasia"); apiErr == nil {
+		t.Error("Authenticate harusnya gagal apabila LDAP nonaktif")
+	}
+}
+
+func TestParseGroupRoles(t *testing.T) {
+	got := ParseGroupRoles(" admin:cn=ba-admin,ou=groups,dc=example,dc=com ; NORMAL:cn=staff,ou=groups,dc=example,dc=com;invalid;:cn=x")
+	want := []GroupRole{
+		{Group: testAdminGroup, Role: "ADMIN"},
+		{Group: testStaffGroup, Role: "NORMAL"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseGroupRoles = %v, harusnya %v", got, want)
+	}
+}
+
+// generateCertificate membuat sertifikat self signed untuk 127.0.0.1, mengembalikan lokasi file PEM sertifikat
+func generateCertificate(t *testing.T) (string, *tls.Config) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ldap stand-in"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := ioutil.WriteFile(certFile, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		MinVersion:   tls.VersionTLS12,
+	}
+}
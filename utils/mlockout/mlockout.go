@@ -0,0 +1,47 @@
+package mlockout
+
+import "time"
+
+// maxShift membatasi eksponen backoff agar tidak overflow
+const maxShift = 30
+
+// Policy aturan blokir setelah login gagal berturut-turut. Setiap kegagalan memblokir percobaan berikutnya
+// selama BaseDelay * 2^(failures-1), setelah MaxFailure kali gagal subject dikunci selama LockDuration.
+type Policy struct {
+	MaxFailure   int
+	BaseDelay    time.Duration
+	LockDuration time.Duration
+}
+
+func NewPolicy(maxFailure int, baseDelaySecond int, lockMinute int) Policy {
+	return Policy{
+		MaxFailure:   maxFailure,
+		BaseDelay:    time.Duration(baseDelaySecond) * time.Second,
+		LockDuration: time.Duration(lockMinute) * time.Minute,
+	}
+}
+
+// Delay lama blokir setelah gagal sebanyak failures kali berturut-turut, tidak pernah melebihi LockDuration
+func (p Policy) Delay(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	if p.Locked(failures) {
+		return p.LockDuration
+	}
+
+	shift := failures - 1
+	if shift > maxShift {
+		shift = maxShift
+	}
+	delay := p.BaseDelay << shift
+	if delay > p.LockDuration {
+		return p.LockDuration
+	}
+	return delay
+}
+
+// Locked return true jika jumlah kegagalan sudah mencapai batas penguncian
+func (p Policy) Locked(failures int) bool {
+	return p.MaxFailure > 0 && failures >= p.MaxFailure
+}
@@ -0,0 +1,37 @@
+package mlockout
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPolicy_Delay(t *testing.T) {
+	policy := NewPolicy(5, 1, 15)
+
+	for failures, want := range map[int]time.Duration{
+		0:   0,
+		1:   time.Second,
+		2:   2 * time.Second,
+		4:   8 * time.Second,
+		5:   15 * time.Minute,
+		100: 15 * time.Minute,
+	} {
+		if got := policy.Delay(failures); got != want {
+			t.Errorf("Delay(%d) = %s, harusnya %s", failures, got, want)
+		}
+	}
+}
+
+func TestPolicy_DelayCappedByLockDuration(t *testing.T) {
+	// tanpa batas penguncian, backoff tetap dibatasi LockDuration
+	policy := NewPolicy(0, 60, 10)
+	if got := policy.Delay(5); got != 10*time.Minute {
+		t.Errorf("Delay(5) = %s, harusnya %s", got, 10*time.Minute)
+	}
+	if got := policy.Delay(1000); got != 10*time.Minute {
+		t.Errorf("Delay(1000) = %s, harusnya %s", got, 10*time.Minute)
+	}
+	if policy.Locked(1000) {
+		t.Error("policy tanpa MaxFailure tidak boleh mengunci")
+	}
+}
@@ -0,0 +1,97 @@
+package mnumber
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPattern digunakan apabila unit belum memiliki skema penomoran, contoh hasil : 001/BA/IT/X/2026
+const DefaultPattern = "{seq:3}/BA/{unit}/{month_roman}/{year}"
+
+// tokenPattern mencocokkan token seperti {seq}, {seq:3}, {unit}, {month_roman}
+var tokenPattern = regexp.MustCompile(`\{([a-z_]+)(?::([0-9]+))?\}`)
+
+// unitPattern kode unit yang valid, contoh : IT, KEU, SDM-2
+var unitPattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9_-]*$`)
+
+var romanMonths = [...]string{"I", "II", "III", "IV", "V", "VI", "VII", "VIII", "IX", "X", "XI", "XII"}
+
+// Values nilai yang dapat dipakai pada pattern
+type Values struct {
+	Seq  int
+	Unit string
+	Type string
+	Time time.Time
+}
+
+// IsValidUnit return true jika unit dapat digunakan sebagai kode unit penomoran
+func IsValidUnit(unit string) bool {
+	return unitPattern.MatchString(unit)
+}
+
+// RomanMonth mengembalikan bulan dalam angka romawi, 10 menjadi X
+func RomanMonth(month time.Month) string {
+	return romanMonths[month-1]
+}
+
+// ValidatePattern memastikan pattern hanya berisi token yang dikenal serta memuat {seq}, {unit} dan {year}.
+// Counter nomor urut disimpan per unit dan tahun, tanpa {unit} dan {year} nomor yang sama akan terbit
+// kembali di unit lain atau di tahun berikutnya
+func ValidatePattern(pattern string) error {
+	found := make(map[string]bool)
+	for _, match := range tokenPattern.FindAllStringSubmatch(pattern, -1) {
+		switch match[1] {
+		case "seq":
+			if match[2] != "" {
+				width, _ := strconv.Atoi(match[2])
+				if width < 1 || width > 10 {
+					return errors.New("lebar {seq} harus antara 1 sampai 10")
+				}
+			}
+		case "unit", "type", "month", "month_roman", "year":
+			if match[2] != "" {
+				return fmt.Errorf("token {%s} tidak memiliki lebar", match[1])
+			}
+		default:
+			return fmt.Errorf("token {%s} tidak dikenal", match[1])
+		}
+		found[match[1]] = true
+	}
+	for _, required := range []string{"seq", "unit", "year"} {
+		if !found[required] {
+			return fmt.Errorf("pattern harus memuat {%s}", required)
+		}
+	}
+	return nil
+}
+
+// Format menyusun nomor surat dari pattern, token yang didukung :
+// {seq} atau {seq:N} nomor urut dengan padding nol sebanyak N, {unit}, {type},
+// {month} bulan 2 digit, {month_roman} bulan romawi, {year} tahun 4 digit
+func Format(pattern string, values Values) string {
+	return tokenPattern.ReplaceAllStringFunc(pattern, func(token string) string {
+		match := tokenPattern.FindStringSubmatch(token)
+		switch match[1] {
+		case "seq":
+			if match[2] == "" {
+				return strconv.Itoa(values.Seq)
+			}
+			return fmt.Sprintf("%0"+match[2]+"d", values.Seq)
+		case "unit":
+			return strings.ToUpper(values.Unit)
+		case "type":
+			return strings.ToUpper(values.Type)
+		case "month":
+			return fmt.Sprintf("%02d", int(values.Time.Month()))
+		case "month_roman":
+			return RomanMonth(values.Time.Month())
+		case "year":
+			return strconv.Itoa(values.Time.Year())
+		}
+		return token
+	})
+}
@@ -0,0 +1,72 @@
+package mnumber
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	values := Values{
+		Seq:  1,
+		Unit: "it",
+		Type: "serah_terima",
+		Time: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{DefaultPattern, "001/BA/IT/X/2026"},
+		{"{seq}/{type}/{month}/{year}", "1/SERAH_TERIMA/10/2026"},
+		{"BA-{unit}-{seq:5}", "BA-IT-00001"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.pattern, values); got != tt.want {
+			t.Errorf("Format(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestRomanMonth(t *testing.T) {
+	want := []string{"I", "II", "III", "IV", "V", "VI", "VII", "VIII", "IX", "X", "XI", "XII"}
+	for i, roman := range want {
+		if got := RomanMonth(time.Month(i + 1)); got != roman {
+			t.Errorf("RomanMonth(%d) = %q, want %q", i+1, got, roman)
+		}
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	valid := []string{DefaultPattern, "{seq}/{unit}/{year}", "{seq:10}/{type}/{unit}/{month}/{year}"}
+	for _, pattern := range valid {
+		if err := ValidatePattern(pattern); err != nil {
+			t.Errorf("ValidatePattern(%q) error = %v", pattern, err)
+		}
+	}
+
+	invalid := []string{"BA/{unit}/{year}", "{seq}/{unit}/{year}/{tanggal}", "{seq:0}/{unit}/{year}", "{seq}/{unit}/{year:2}"}
+	for _, pattern := range invalid {
+		if err := ValidatePattern(pattern); err == nil {
+			t.Errorf("ValidatePattern(%q) harusnya error", pattern)
+		}
+	}
+}
+
+func TestValidatePattern_RequiresUnitAndYear(t *testing.T) {
+	// counter direset per unit dan tahun sehingga pattern tanpa keduanya menghasilkan nomor ganda
+	for _, pattern := range []string{"{seq}", "{seq:3}/BA/{unit}", "{seq:3}/BA/{month_roman}/{year}", "{seq}/{type}/{month}"} {
+		if err := ValidatePattern(pattern); err == nil {
+			t.Errorf("ValidatePattern(%q) harusnya error", pattern)
+		}
+	}
+}
+
+func TestIsValidUnit(t *testing.T) {
+	if !IsValidUnit("IT") || !IsValidUnit("SDM-2") {
+		t.Error("unit harusnya valid")
+	}
+	if IsValidUnit("") || IsValidUnit("it") || IsValidUnit("IT/2") {
+		t.Error("unit harusnya tidak valid")
+	}
+}
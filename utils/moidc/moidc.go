@@ -0,0 +1,186 @@
+package moidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/muchlist/berita_acara/configs"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"golang.org/x/oauth2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	httpTimeout = 10 * time.Second
+)
+
+// Config konfigurasi client OIDC, client secret boleh kosong untuk public client karena PKCE selalu digunakan
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Identity identitas user dari ID token yang sudah diverifikasi, Issuer dan Subject bersama-sama unik
+type Identity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type OIDCAssumer interface {
+	Enabled() bool
+	AuthCodeURL(ctx context.Context, state string, nonce string, codeVerifier string) (string, rest_err.APIError)
+	Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*Identity, rest_err.APIError)
+}
+
+// NewOIDC membuat client dari BA_OIDC_*, OIDC nonaktif apabila BA_OIDC_ISSUER kosong
+func NewOIDC() OIDCAssumer {
+	return NewClient(Config{
+		Issuer:       configs.Config.OIDCISSUER,
+		ClientID:     configs.Config.OIDCCLIENTID,
+		ClientSecret: configs.Config.OIDCCLIENTSECRET,
+		RedirectURL:  configs.Config.OIDCREDIRECTURL,
+		Scopes:       configs.Config.OIDCSCOPES,
+	})
+}
+
+// NewClient discovery dilakukan saat pertama kali digunakan sehingga aplikasi tetap dapat berjalan
+// ketika identity provider belum dapat dihubungi
+func NewClient(config Config) OIDCAssumer {
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+	return &oidcClient{
+		config:     config,
+		httpClient: &http.Client{Timeout: httpTimeout},
+	}
+}
+
+type oidcClient struct {
+	config     Config
+	httpClient *http.Client
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+func (o *oidcClient) Enabled() bool {
+	return o.config.Issuer != ""
+}
+
+// AuthCodeURL alamat halaman login identity provider dengan code_challenge S256 dari codeVerifier
+func (o *oidcClient) AuthCodeURL(ctx context.Context, state string, nonce string, codeVerifier string) (string, rest_err.APIError) {
+	oauthConfig, _, apiErr := o.oauthConfig(ctx)
+	if apiErr != nil {
+		return "", apiErr
+	}
+	return oauthConfig.AuthCodeURL(state,
+		oidc.Nonce(nonce),
+		oauth2.SetAuthURLParam("code_challenge", CodeChallenge(codeVerifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	), nil
+}
+
+// Exchange menukar authorization code dengan token lalu memverifikasi signature, issuer, audience,
+// waktu berlaku dan nonce ID token
+func (o *oidcClient) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*Identity, rest_err.APIError) {
+	oauthConfig, provider, apiErr := o.oauthConfig(ctx)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	ctx = oidc.ClientContext(ctx, o.httpClient)
+	token, err := oauthConfig.Exchange(ctx, code, oauth2.SetAuthURLParam("code_verifier", codeVerifier))
+	if err != nil {
+		return nil, rest_err.NewAPIError("Authorization code tidak valid atau sudah kadaluarsa", http.StatusUnauthorized, "oidc_error", []interface{}{err.Error()})
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, rest_err.NewUnauthorizedError("Identity provider tidak mengembalikan id_token")
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: o.config.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, rest_err.NewAPIError("ID token tidak valid", http.StatusUnauthorized, "oidc_error", []interface{}{err.Error()})
+	}
+	if idToken.Nonce != nonce {
+		return nil, rest_err.NewAPIError("ID token tidak valid", http.StatusUnauthorized, "oidc_error", []interface{}{"nonce tidak sesuai"})
+	}
+
+	var claims struct {
+		Email         string      `json:"email"`
+		EmailVerified interface{} `json:"email_verified"`
+		Name          string      `json:"name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, rest_err.NewAPIError("ID token tidak valid", http.StatusUnauthorized, "oidc_error", []interface{}{err.Error()})
+	}
+
+	return &Identity{
+		Issuer:        idToken.Issuer,
+		Subject:       idToken.Subject,
+		Email:         strings.TrimSpace(claims.Email),
+		EmailVerified: isTrue(claims.EmailVerified),
+		Name:          strings.TrimSpace(claims.Name),
+	}, nil
+}
+
+func (o *oidcClient) oauthConfig(ctx context.Context) (*oauth2.Config, *oidc.Provider, rest_err.APIError) {
+	provider, apiErr := o.getProvider(ctx)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+	return &oauth2.Config{
+		ClientID:     o.config.ClientID,
+		ClientSecret: o.config.ClientSecret,
+		RedirectURL:  o.config.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       o.config.Scopes,
+	}, provider, nil
+}
+
+// getProvider melakukan discovery sekali, discovery yang gagal diulang pada pemanggilan berikutnya
+func (o *oidcClient) getProvider(ctx context.Context) (*oidc.Provider, rest_err.APIError) {
+	if !o.Enabled() {
+		return nil, rest_err.NewBadRequestError("Login OIDC tidak diaktifkan")
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.provider != nil {
+		return o.provider, nil
+	}
+
+	provider, err := oidc.NewProvider(oidc.ClientContext(ctx, o.httpClient), o.config.Issuer)
+	if err != nil {
+		return nil, rest_err.NewInternalServerError("Identity provider tidak dapat dihubungi", err)
+	}
+	o.provider = provider
+	return provider, nil
+}
+
+// CodeChallenge code_challenge PKCE metode S256 (RFC 7636)
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// isTrue beberapa identity provider mengirim email_verified dalam bentuk string
+func isTrue(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true")
+	}
+	return false
+}
@@ -0,0 +1,235 @@
+package moidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/golang-jwt/jwt/v4"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const (
+	testClientID = "berita-acara"
+	testCode     = "authorization-code"
+	testKeyID    = "test-key"
+)
+
+// mockIdP identity provider lokal yang menyediakan discovery, jwks dan token endpoint dengan pemeriksaan PKCE
+type mockIdP struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &mockIdP{t: t, key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"issuer":                                idp.server.URL,
+			"authorization_endpoint":                idp.server.URL + "/authorize",
+			"token_endpoint":                        idp.server.URL + "/token",
+			"jwks_uri":                              idp.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": testKeyID,
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", idp.token)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (m *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.PostForm.Get("code") != testCode || CodeChallenge(r.PostForm.Get("code_verifier")) != m.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, m.claims)
+	token.Header["kid"] = testKeyID
+	idToken, err := token.SignedString(m.key)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	writeJSON(w, map[string]interface{}{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+// authorize mensimulasikan halaman login identity provider dengan menyimpan code_challenge dari url
+func (m *mockIdP) authorize(authURL string) url.Values {
+	m.t.Helper()
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	query := parsed.Query()
+	m.challenge = query.Get("code_challenge")
+	m.claims = jwt.MapClaims{
+		"iss":            m.server.URL,
+		"sub":            "user-123",
+		"aud":            testClientID,
+		"exp":            time.Now().Add(5 * time.Minute).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          query.Get("nonce"),
+		"email":          "Budi@Example.com",
+		"email_verified": true,
+		"name":           "Budi",
+	}
+	return query
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func newTestClient(idp *mockIdP) OIDCAssumer {
+	return NewClient(Config{
+		Issuer:      idp.server.URL,
+		ClientID:    testClientID,
+		RedirectURL: "http://localhost:3500/api/v1/oidc/callback",
+	})
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	idp := newMockIdP(t)
+	client := newTestClient(idp)
+
+	authURL, apiErr := client.AuthCodeURL(context.Background(), "state-1", "nonce-1", "verifier-1")
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	query := idp.authorize(authURL)
+	for key, want := range map[string]string{
+		"client_id":             testClientID,
+		"response_type":         "code",
+		"state":                 "state-1",
+		"nonce":                 "nonce-1",
+		"code_challenge":        CodeChallenge("verifier-1"),
+		"code_challenge_method": "S256",
+		"scope":                 "openid email profile",
+	} {
+		if got := query.Get(key); got != want {
+			t.Errorf("%s = %q, harusnya %q", key, got, want)
+		}
+	}
+	if query.Get("code_verifier") != "" {
+		t.Error("code_verifier tidak boleh dikirim melalui url")
+	}
+}
+
+func TestExchange(t *testing.T) {
+	idp := newMockIdP(t)
+	client := newTestClient(idp)
+	ctx := context.Background()
+
+	authURL, apiErr := client.AuthCodeURL(ctx, "state-1", "nonce-1", "verifier-1")
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	idp.authorize(authURL)
+
+	identity, apiErr := client.Exchange(ctx, testCode, "verifier-1", "nonce-1")
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	want := Identity{
+		Issuer:        idp.server.URL,
+		Subject:       "user-123",
+		Email:         "Budi@Example.com",
+		EmailVerified: true,
+		Name:          "Budi",
+	}
+	if *identity != want {
+		t.Errorf("identity = %+v, harusnya %+v", *identity, want)
+	}
+}
+
+func TestExchange_Rejected(t *testing.T) {
+	idp := newMockIdP(t)
+	client := newTestClient(idp)
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		name     string
+		verifier string
+		nonce    string
+		modify   func(claims jwt.MapClaims)
+	}{
+		{"code verifier salah", "verifier-lain", "nonce-1", nil},
+		{"nonce tidak sesuai", "verifier-1", "nonce-lain", nil},
+		{"audience lain", "verifier-1", "nonce-1", func(claims jwt.MapClaims) { claims["aud"] = "aplikasi-lain" }},
+		{"issuer lain", "verifier-1", "nonce-1", func(claims jwt.MapClaims) { claims["iss"] = "https://idp.lain" }},
+		{"kadaluarsa", "verifier-1", "nonce-1", func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() }},
+	} {
+		authURL, apiErr := client.AuthCodeURL(ctx, "state-1", "nonce-1", "verifier-1")
+		if apiErr != nil {
+			t.Fatal(apiErr)
+		}
+		idp.authorize(authURL)
+		if tt.modify != nil {
+			tt.modify(idp.claims)
+		}
+
+		if _, apiErr := client.Exchange(ctx, testCode, tt.verifier, tt.nonce); apiErr == nil {
+			t.Errorf("%s: exchange harusnya ditolak", tt.name)
+		} else if apiErr.Status() != http.StatusUnauthorized {
+			t.Errorf("%s: status %d, harusnya %d", tt.name, apiErr.Status(), http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	client := NewClient(Config{})
+	if client.Enabled() {
+		t.Error("client tanpa issuer harusnya nonaktif")
+	}
+	if _, apiErr := client.AuthCodeURL(context.Background(), "state", "nonce", "verifier"); apiErr == nil {
+		t.Error("AuthCodeURL harusnya gagal apabila OIDC nonaktif")
+	}
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// contoh dari RFC 7636 appendix B
+	if got := CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"); got != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Errorf("code challenge = %s", got)
+	}
+}
@@ -0,0 +1,257 @@
+package mpdf
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/go-pdf/fpdf"
+	"github.com/muchlist/berita_acara/configs"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"strings"
+	"time"
+)
+
+const (
+	ContentType = "application/pdf"
+
+	fontFamily  = "go"
+	pageMargin  = 20.0
+	lineHeight  = 6.0
+	signSpace   = 22.0
+	signerBlock = 5*lineHeight + signSpace
+	qrSize      = 28.0
+	qrPixel     = 256
+	qrImageName = "verify-qr"
+)
+
+// documentZone zona waktu tanggal pada pdf (WITA). Tanggal pada pdf yang ditandatangani tidak boleh
+// bergantung pada zona waktu server agar hash document tetap sama ketika pdf disusun ulang saat verifikasi
+var documentZone = time.FixedZone("WITA", 8*60*60)
+
+var monthNames = [...]string{
+	"Januari", "Februari", "Maret", "April", "Mei", "Juni",
+	"Juli", "Agustus", "September", "Oktober", "November", "Desember",
+}
+
+// Signer penandatangan yang ditampilkan pada blok tanda tangan
+type Signer struct {
+	Name     string
+	Position string
+}
+
+// Letterhead kop surat yang dicetak di bagian atas halaman pertama
+type Letterhead struct {
+	Title   string
+	Address string
+}
+
+// Document data yang dibutuhkan untuk menyusun pdf berita acara
+type Document struct {
+	Number    string
+	Title     string
+	Type      string
+	Body      string
+	CreatedAt time.Time
+	Signers   []Signer
+	// Letterhead kop surat yang disalin saat document mulai ditandatangani, nil berarti mengikuti BA_LETTERHEAD_*
+	Letterhead *Letterhead
+	// VerifyURL alamat halaman verifikasi publik yang dicetak sebagai QR code, kosong berarti tanpa QR
+	VerifyURL string
+}
+
+func NewPdf() PdfAssumer {
+	return &pdfUtils{
+		letterhead: Letterhead{
+			Title:   configs.Config.LETTERHEADTITLE,
+			Address: configs.Config.LETTERHEADADDRESS,
+		},
+	}
+}
+
+type PdfAssumer interface {
+	Render(document Document) ([]byte, rest_err.APIError)
+}
+
+type pdfUtils struct {
+	letterhead Letterhead
+}
+
+// Render menyusun document menjadi pdf A4 berisi kop surat, judul bernomor, paragraf isi dan
+// grid tanda tangan. Font di-embed dari gofont sehingga tidak memerlukan file maupun binary eksternal.
+// Output deterministik untuk input yang sama karena tanggal pembuatan pdf diambil dari document
+// dan selalu ditulis pada documentZone.
+func (p *pdfUtils) Render(document Document) ([]byte, rest_err.APIError) {
+	document.CreatedAt = document.CreatedAt.In(documentZone)
+	letterhead := p.letterhead
+	if document.Letterhead != nil {
+		letterhead = *document.Letterhead
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetAutoPageBreak(true, pageMargin)
+	pdf.SetCreationDate(document.CreatedAt)
+	pdf.SetModificationDate(document.CreatedAt)
+	pdf.SetCatalogSort(true)
+	pdf.SetTitle(document.Title, true)
+	pdf.AddUTF8FontFromBytes(fontFamily, "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", gobold.TTF)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont(fontFamily, "", 8)
+		pdf.CellFormat(0, 10, fmt.Sprintf("Halaman %d dari {nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	writeLetterhead(pdf, letterhead)
+	writeTitle(pdf, document)
+	writeBody(pdf, document.Body)
+	writeSignatures(pdf, document)
+	if err := writeVerifyQR(pdf, document.VerifyURL); err != nil {
+		return nil, rest_err.NewInternalServerError("gagal membuat qr code", err)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, rest_err.NewInternalServerError("gagal membuat pdf", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func writeLetterhead(pdf *fpdf.Fpdf, letterhead Letterhead) {
+	if letterhead.Title == "" && letterhead.Address == "" {
+		return
+	}
+	pageWidth, _ := pdf.GetPageSize()
+
+	pdf.SetFont(fontFamily, "B", 14)
+	pdf.CellFormat(0, 7, strings.ToUpper(letterhead.Title), "", 1, "C", false, 0, "")
+	pdf.SetFont(fontFamily, "", 9)
+	pdf.CellFormat(0, 5, letterhead.Address, "", 1, "C", false, 0, "")
+
+	y := pdf.GetY() + 2
+	pdf.SetLineWidth(0.8)
+	pdf.Line(pageMargin, y, pageWidth-pageMargin, y)
+	pdf.SetLineWidth(0.2)
+	pdf.Line(pageMargin, y+1, pageWidth-pageMargin, y+1)
+	pdf.SetY(y + 8)
+}
+
+func writeTitle(pdf *fpdf.Fpdf, document Document) {
+	number := document.Number
+	if number == "" {
+		number = "DRAFT"
+	}
+
+	pdf.SetFont(fontFamily, "B", 13)
+	pdf.CellFormat(0, 7, "BERITA ACARA", "", 1, "C", false, 0, "")
+	pdf.MultiCell(0, 7, strings.ToUpper(document.Title), "", "C", false)
+	pdf.SetFont(fontFamily, "", 11)
+	pdf.CellFormat(0, lineHeight, "Nomor : "+number, "", 1, "C", false, 0, "")
+	pdf.Ln(lineHeight)
+}
+
+// writeBody setiap baris kosong pada body dianggap sebagai pemisah paragraf
+func writeBody(pdf *fpdf.Fpdf, body string) {
+	pdf.SetFont(fontFamily, "", 11)
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	for _, paragraph := range strings.Split(body, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		pdf.MultiCell(0, lineHeight, paragraph, "", "J", false)
+		pdf.Ln(lineHeight / 2)
+	}
+}
+
+// writeSignatures menyusun penandatangan dalam grid, 2 kolom untuk 2 atau 4 penandatangan
+// dan 3 kolom untuk jumlah lainnya
+func writeSignatures(pdf *fpdf.Fpdf, document Document) {
+	signers := document.Signers
+	if len(signers) == 0 {
+		return
+	}
+
+	columns := 3
+	switch {
+	case len(signers) == 1:
+		columns = 1
+	case len(signers) == 2 || len(signers) == 4:
+		columns = 2
+	}
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	contentWidth := pageWidth - 2*pageMargin
+	columnWidth := contentWidth / float64(columns)
+
+	pdf.Ln(lineHeight)
+	if pdf.GetY()+lineHeight+signerBlock > pageHeight-pageMargin {
+		pdf.AddPage()
+	}
+	pdf.SetFont(fontFamily, "", 11)
+	pdf.CellFormat(0, lineHeight, formatDate(document.CreatedAt), "", 1, "R", false, 0, "")
+	pdf.Ln(lineHeight / 2)
+
+	for start := 0; start < len(signers); start += columns {
+		end := start + columns
+		if end > len(signers) {
+			end = len(signers)
+		}
+		row := signers[start:end]
+
+		if pdf.GetY()+signerBlock > pageHeight-pageMargin {
+			pdf.AddPage()
+		}
+		top := pdf.GetY()
+		// baris yang tidak penuh diletakkan di tengah
+		left := pageMargin + (contentWidth-columnWidth*float64(len(row)))/2
+
+		for i, signer := range row {
+			x := left + columnWidth*float64(i)
+			pdf.SetXY(x, top)
+			pdf.SetFont(fontFamily, "", 10)
+			pdf.MultiCell(columnWidth, 5, signer.Position, "", "C", false)
+			pdf.SetXY(x, top+2*lineHeight+signSpace)
+			pdf.SetFont(fontFamily, "BU", 10)
+			pdf.MultiCell(columnWidth, 5, signer.Name, "", "C", false)
+		}
+		pdf.SetY(top + signerBlock)
+	}
+}
+
+// writeVerifyQR mencetak QR code menuju halaman verifikasi agar salinan cetak dapat diperiksa keasliannya
+func writeVerifyQR(pdf *fpdf.Fpdf, verifyURL string) error {
+	if verifyURL == "" {
+		return nil
+	}
+	png, err := qrcode.Encode(verifyURL, qrcode.Medium, qrPixel)
+	if err != nil {
+		return err
+	}
+
+	_, pageHeight := pdf.GetPageSize()
+	pdf.Ln(lineHeight)
+	if pdf.GetY()+qrSize > pageHeight-pageMargin {
+		pdf.AddPage()
+	}
+	top := pdf.GetY()
+
+	pdf.RegisterImageOptionsReader(qrImageName, fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(png))
+	pdf.ImageOptions(qrImageName, pageMargin, top, qrSize, qrSize, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+
+	pdf.SetXY(pageMargin+qrSize+4, top+qrSize/2-lineHeight)
+	pdf.SetFont(fontFamily, "", 8)
+	pdf.MultiCell(0, 4, "Pindai QR code ini untuk memeriksa keaslian document:\n"+verifyURL, "", "L", false)
+	pdf.SetY(top + qrSize)
+	return pdf.Error()
+}
+
+// formatDate menghasilkan tanggal berbahasa indonesia pada documentZone, contoh : 11 September 2021
+func formatDate(t time.Time) string {
+	t = t.In(documentZone)
+	return fmt.Sprintf("%d %s %d", t.Day(), monthNames[t.Month()-1], t.Year())
+}
@@ -0,0 +1,67 @@
+package mpdf
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	p := &pdfUtils{
+		letterhead: Letterhead{Title: "PT Contoh Indonesia", Address: "Jl. Contoh No. 1"},
+	}
+	document := Document{
+		Number:    "001/BA/IT/X/2026",
+		Title:     "Serah terima laptop",
+		Type:      "SERAH_TERIMA",
+		Body:      "Pada hari ini telah dilakukan serah terima.\n\nDemikian berita acara ini dibuat.",
+		CreatedAt: time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC),
+		Signers: []Signer{
+			{Name: "MUCHLIS", Position: "Pelapor"},
+			{Name: "BUDI", Position: "Kepala Unit IT"},
+			{Name: "SITI", Position: "Atasan"},
+		},
+		VerifyURL: "http://localhost:3500/verify/bq2p0sM4b2kKk8Zr3dRj9nHkO4YwB7xv1Q2nq5mVwzE",
+	}
+
+	first, apiErr := p.Render(document)
+	if apiErr != nil {
+		t.Fatalf("Render() error = %v", apiErr)
+	}
+	if !bytes.HasPrefix(first, []byte("%PDF-")) {
+		t.Fatalf("Render() tidak menghasilkan pdf")
+	}
+
+	// hash pdf digunakan untuk tanda tangan, sehingga output harus selalu sama
+	second, apiErr := p.Render(document)
+	if apiErr != nil {
+		t.Fatalf("Render() error = %v", apiErr)
+	}
+	if !bytes.Equal(first, second) {
+		t.Errorf("Render() tidak deterministik")
+	}
+
+	// perubahan BA_LETTERHEAD_* maupun zona waktu server tidak merubah pdf document yang memiliki salinan kop surat
+	document.Letterhead = &p.letterhead
+	document.CreatedAt = document.CreatedAt.In(time.FixedZone("TEST", -5*60*60))
+	changed := &pdfUtils{letterhead: Letterhead{Title: "PT Lain"}}
+	third, apiErr := changed.Render(document)
+	if apiErr != nil {
+		t.Fatalf("Render() error = %v", apiErr)
+	}
+	if !bytes.Equal(first, third) {
+		t.Errorf("Render() berubah mengikuti kop surat atau zona waktu saat ini")
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	got := formatDate(time.Date(2021, 9, 11, 0, 0, 0, 0, time.UTC))
+	if got != "11 September 2021" {
+		t.Errorf("formatDate() = %q", got)
+	}
+	// 20:00 UTC sudah berganti hari pada WITA
+	got = formatDate(time.Date(2021, 9, 10, 20, 0, 0, 0, time.UTC))
+	if got != "11 September 2021" {
+		t.Errorf("formatDate() = %q", got)
+	}
+}
@@ -0,0 +1,214 @@
+package mpki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/muchlist/berita_acara/configs"
+	"github.com/muchlist/berita_acara/utils/mcrypt"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"go.mozilla.org/pkcs7"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+const (
+	certValidity = 2 * 365 * 24 * time.Hour
+	organization = "Berita Acara"
+)
+
+var (
+	caCert *x509.Certificate
+	caKey  crypto.Signer
+	// keyEncryptionKey digunakan untuk mengenkripsi private key user yang disimpan di database
+	keyEncryptionKey []byte
+)
+
+func NewPki() PkiAssumer {
+	return &pkiUtils{}
+}
+
+// Init membaca sertifikat dan private key CA internal dari file PEM pada config
+func Init() {
+	cfg := configs.Config
+	if cfg.CACERTFILE == "" || cfg.CAKEYFILE == "" {
+		log.Fatal("Sertifikat dan key CA tidak boleh kosong, ENV : BA_CA_CERT_FILE dan BA_CA_KEY_FILE")
+	}
+
+	certPEM, err := ioutil.ReadFile(cfg.CACERTFILE)
+	if err != nil {
+		log.Fatalf("Gagal membaca sertifikat CA: %v", err)
+	}
+	keyPEM, err := ioutil.ReadFile(cfg.CAKEYFILE)
+	if err != nil {
+		log.Fatalf("Gagal membaca key CA: %v", err)
+	}
+
+	caCert, caKey, err = parseCA(certPEM, keyPEM)
+	if err != nil {
+		log.Fatalf("CA tidak valid: %v", err)
+	}
+
+	secret := sha256.Sum256([]byte(cfg.SECRETKEY))
+	keyEncryptionKey = secret[:]
+}
+
+type PkiAssumer interface {
+	IssueUserKey(userID int, name string) (certPEM string, encryptedKey []byte, notAfter time.Time, apiErr rest_err.APIError)
+	Sign(content []byte, certPEM string, encryptedKey []byte) ([]byte, rest_err.APIError)
+	Verify(content []byte, signature []byte, userID int) rest_err.APIError
+}
+
+type pkiUtils struct {
+}
+
+// IssueUserKey membuat key pair ECDSA P-256 untuk user beserta sertifikat X.509 yang ditandatangani CA.
+// Subject serialNumber berisi user id sehingga tanda tangan dapat dipastikan milik user tersebut.
+// Private key dikembalikan dalam bentuk terenkripsi.
+func (p *pkiUtils) IssueUserKey(userID int, name string) (string, []byte, time.Time, rest_err.APIError) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", nil, time.Time{}, rest_err.NewInternalServerError("gagal membuat private key", err)
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", nil, time.Time{}, rest_err.NewInternalServerError("gagal membuat serial sertifikat", err)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName:   name,
+			SerialNumber: strconv.Itoa(userID),
+			Organization: []string{organization},
+		},
+		NotBefore:   now.Add(-5 * time.Minute),
+		NotAfter:    now.Add(certValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection},
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, privateKey.Public(), caKey)
+	if err != nil {
+		return "", nil, time.Time{}, rest_err.NewInternalServerError("gagal membuat sertifikat", err)
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return "", nil, time.Time{}, rest_err.NewInternalServerError("gagal encode private key", err)
+	}
+	encryptedKey, err := mcrypt.Encrypt(keyEncryptionKey, keyDER)
+	if err != nil {
+		return "", nil, time.Time{}, rest_err.NewInternalServerError("gagal mengenkripsi private key", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	return string(certPEM), encryptedKey, template.NotAfter, nil
+}
+
+// Sign membuat detached CMS (PKCS#7) signature dengan digest SHA-256 atas content
+func (p *pkiUtils) Sign(content []byte, certPEM string, encryptedKey []byte) ([]byte, rest_err.APIError) {
+	cert, err := parseCertificate([]byte(certPEM))
+	if err != nil {
+		return nil, rest_err.NewInternalServerError("sertifikat user tidak valid", err)
+	}
+
+	keyDER, err := mcrypt.Decrypt(keyEncryptionKey, encryptedKey)
+	if err != nil {
+		return nil, rest_err.NewInternalServerError("gagal membuka private key user", err)
+	}
+	privateKey, err := x509.ParsePKCS8PrivateKey(keyDER)
+	if err != nil {
+		return nil, rest_err.NewInternalServerError("private key user tidak valid", err)
+	}
+
+	signedData, err := pkcs7.NewSignedData(content)
+	if err != nil {
+		return nil, rest_err.NewInternalServerError("gagal membuat signed data", err)
+	}
+	signedData.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA256)
+	if err := signedData.AddSignerChain(cert, privateKey, []*x509.Certificate{caCert}, pkcs7.SignerInfoConfig{}); err != nil {
+		return nil, rest_err.NewInternalServerError("gagal menandatangani document", err)
+	}
+	signedData.Detach()
+
+	signature, err := signedData.Finish()
+	if err != nil {
+		return nil, rest_err.NewInternalServerError("gagal menyelesaikan signed data", err)
+	}
+	return signature, nil
+}
+
+// Verify memeriksa signature terhadap content dan rantai sertifikat CA,
+// serta memastikan sertifikat penandatangan diterbitkan untuk userID
+func (p *pkiUtils) Verify(content []byte, signature []byte, userID int) rest_err.APIError {
+	p7, err := pkcs7.Parse(signature)
+	if err != nil {
+		return rest_err.NewBadRequestError(fmt.Sprintf("signature tidak dapat dibaca: %s", err.Error()))
+	}
+	p7.Content = content
+
+	truststore := x509.NewCertPool()
+	truststore.AddCert(caCert)
+	if err := p7.VerifyWithChain(truststore); err != nil {
+		return rest_err.NewBadRequestError(fmt.Sprintf("signature tidak valid: %s", err.Error()))
+	}
+
+	signer := p7.GetOnlySigner()
+	if signer == nil || signer.Subject.SerialNumber != strconv.Itoa(userID) {
+		return rest_err.NewBadRequestError("sertifikat penandatangan bukan milik user terkait")
+	}
+	return nil
+}
+
+func parseCA(certPEM []byte, keyPEM []byte) (*x509.Certificate, crypto.Signer, error) {
+	cert, err := parseCertificate(certPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !cert.IsCA {
+		return nil, nil, errors.New("sertifikat bukan CA")
+	}
+
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, nil, errors.New("key CA bukan PEM")
+	}
+	var key interface{}
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, nil, errors.New("tipe key CA tidak didukung")
+	}
+	return cert, signer, nil
+}
+
+func parseCertificate(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("sertifikat bukan PEM")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
@@ -0,0 +1,73 @@
+package mpki
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func setupTestCA(t *testing.T) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caKey = key
+	secret := sha256.Sum256([]byte("secret"))
+	keyEncryptionKey = secret[:]
+}
+
+func TestSignAndVerify(t *testing.T) {
+	setupTestCA(t)
+	p := NewPki()
+
+	certPEM, encryptedKey, _, apiErr := p.IssueUserKey(7, "MUCHLIS")
+	if apiErr != nil {
+		t.Fatalf("IssueUserKey() error = %v", apiErr)
+	}
+
+	content := []byte("%PDF-1.3 isi berita acara")
+	signature, apiErr := p.Sign(content, certPEM, encryptedKey)
+	if apiErr != nil {
+		t.Fatalf("Sign() error = %v", apiErr)
+	}
+
+	if apiErr := p.Verify(content, signature, 7); apiErr != nil {
+		t.Errorf("Verify() error = %v", apiErr)
+	}
+	if apiErr := p.Verify([]byte("%PDF-1.3 isi yang diubah"), signature, 7); apiErr == nil {
+		t.Error("Verify() harusnya gagal untuk content yang berubah")
+	}
+	if apiErr := p.Verify(content, signature, 8); apiErr == nil {
+		t.Error("Verify() harusnya gagal untuk user yang berbeda")
+	}
+
+	// signature dari CA lain tidak boleh lolos
+	setupTestCA(t)
+	if apiErr := p.Verify(content, signature, 7); apiErr == nil {
+		t.Error("Verify() harusnya gagal untuk CA yang berbeda")
+	}
+}
@@ -0,0 +1,44 @@
+package mtemplate
+
+import (
+	"regexp"
+	"strings"
+)
+
+// placeholderPattern mencocokkan placeholder seperti {{nama_pihak_pertama}},
+// spasi di dalam kurung kurawal diperbolehkan {{ nama_pihak_pertama }}
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([a-zA-Z0-9_]+)\s*\}\}`)
+
+// fieldNamePattern nama field yang valid untuk dijadikan placeholder
+var fieldNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// IsValidFieldName return true jika name dapat digunakan sebagai placeholder
+func IsValidFieldName(name string) bool {
+	return fieldNamePattern.MatchString(name)
+}
+
+// Placeholders mengembalikan nama placeholder unik sesuai urutan kemunculan pada text
+// input : "Saya {{nama}} menyerahkan kepada {{ penerima }} ... {{nama}}"
+// output : ["nama", "penerima"]
+func Placeholders(text string) []string {
+	matches := placeholderPattern.FindAllStringSubmatch(text, -1)
+	seen := make(map[string]bool, len(matches))
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if seen[match[1]] {
+			continue
+		}
+		seen[match[1]] = true
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// Render mengganti seluruh placeholder dengan nilai pada values,
+// placeholder yang tidak memiliki nilai diganti dengan string kosong
+func Render(text string, values map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := strings.TrimSpace(strings.Trim(placeholder, "{}"))
+		return values[name]
+	})
+}
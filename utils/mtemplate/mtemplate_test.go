@@ -0,0 +1,43 @@
+package mtemplate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlaceholders(t *testing.T) {
+	text := "Saya {{nama_pihak_pertama}} menyerahkan kepada {{ nama_pihak_kedua }}, ditandatangani {{nama_pihak_pertama}}"
+
+	got := Placeholders(text)
+	want := []string{"nama_pihak_pertama", "nama_pihak_kedua"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Placeholders() = %v, want %v", got, want)
+	}
+
+	if got := Placeholders("tanpa placeholder {tunggal}"); len(got) != 0 {
+		t.Errorf("Placeholders() = %v, want empty", got)
+	}
+}
+
+func TestRender(t *testing.T) {
+	text := "Saya {{nama_pihak_pertama}} menyerahkan {{ barang }} kepada {{nama_pihak_kedua}}."
+	values := map[string]string{
+		"nama_pihak_pertama": "Muchlis",
+		"barang":             "laptop",
+	}
+
+	got := Render(text, values)
+	want := "Saya Muchlis menyerahkan laptop kepada ."
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestIsValidFieldName(t *testing.T) {
+	if !IsValidFieldName("nama_pihak_1") {
+		t.Error("nama_pihak_1 harusnya valid")
+	}
+	if IsValidFieldName("nama pihak") || IsValidFieldName("") {
+		t.Error("nama dengan spasi atau kosong harusnya tidak valid")
+	}
+}
@@ -0,0 +1,167 @@
+package mtotp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/muchlist/berita_acara/configs"
+	"github.com/muchlist/berita_acara/utils/mcrypt"
+	"github.com/muchlist/berita_acara/utils/rest_err"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"github.com/skip2/go-qrcode"
+	"strings"
+	"time"
+)
+
+const (
+	period = 30
+	digits = otp.DigitsSix
+	// skew jumlah step sebelum dan sesudah waktu sekarang yang masih diterima
+	skew = 1
+
+	qrSize              = 256
+	recoveryCodeLength  = 10 // byte acak, ditampilkan sebagai 16 karakter base32
+	recoveryCodeDivider = 4
+)
+
+var (
+	issuer string
+	// secretKey digunakan untuk mengenkripsi secret TOTP yang disimpan di database
+	secretKey []byte
+)
+
+func NewTotp() TotpAssumer {
+	return &totpUtils{}
+}
+
+// Init menyiapkan issuer yang tampil pada aplikasi authenticator dan key enkripsi secret
+func Init() {
+	issuer = configs.Config.MFAISSUER
+	key := sha256.Sum256([]byte("totp:" + configs.Config.SECRETKEY))
+	secretKey = key[:]
+}
+
+type TotpAssumer interface {
+	Generate(accountName string) (secret string, uri string, apiErr rest_err.APIError)
+	Validate(secret string, code string, at time.Time) (step int64, valid bool)
+	QRCode(uri string) ([]byte, rest_err.APIError)
+	EncryptSecret(secret string) ([]byte, rest_err.APIError)
+	DecryptSecret(encrypted []byte) (string, rest_err.APIError)
+}
+
+type totpUtils struct {
+}
+
+// Generate membuat secret TOTP baru beserta otpauth URI untuk aplikasi authenticator
+func (t *totpUtils) Generate(accountName string) (string, string, rest_err.APIError) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: accountName,
+		Period:      period,
+		Digits:      digits,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		return "", "", rest_err.NewInternalServerError("gagal membuat secret totp", err)
+	}
+	return key.Secret(), key.URL(), nil
+}
+
+// Validate mencocokkan code dengan secret pada waktu at dengan toleransi skew step.
+// Step yang cocok dikembalikan agar pemanggil dapat menolak code yang dipakai ulang.
+func (t *totpUtils) Validate(secret string, code string, at time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != digits.Length() {
+		return 0, false
+	}
+
+	current := at.Unix() / period
+	for step := current - skew; step <= current+skew; step++ {
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix(step*period, 0), totp.ValidateOpts{
+			Period:    period,
+			Digits:    digits,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// QRCode membuat gambar PNG dari otpauth URI
+func (t *totpUtils) QRCode(uri string) ([]byte, rest_err.APIError) {
+	png, err := qrcode.Encode(uri, qrcode.Medium, qrSize)
+	if err != nil {
+		return nil, rest_err.NewInternalServerError("gagal membuat qr code", err)
+	}
+	return png, nil
+}
+
+func (t *totpUtils) EncryptSecret(secret string) ([]byte, rest_err.APIError) {
+	encrypted, err := mcrypt.Encrypt(secretKey, []byte(secret))
+	if err != nil {
+		return nil, rest_err.NewInternalServerError("gagal mengenkripsi secret totp", err)
+	}
+	return encrypted, nil
+}
+
+func (t *totpUtils) DecryptSecret(encrypted []byte) (string, rest_err.APIError) {
+	secret, err := mcrypt.Decrypt(secretKey, encrypted)
+	if err != nil {
+		return "", rest_err.NewInternalServerError("gagal membuka secret totp", err)
+	}
+	return string(secret), nil
+}
+
+// GenerateRecoveryCodes membuat n recovery code acak dengan format xxxx-xxxx-xxxx-xxxx
+func GenerateRecoveryCodes(n int) ([]string, rest_err.APIError) {
+	codes := make([]string, n)
+	for i := range codes {
+		b := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(b); err != nil {
+			return nil, rest_err.NewInternalServerError("Crypto error", err)
+		}
+		raw := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))
+		parts := make([]string, 0, len(raw)/recoveryCodeDivider)
+		for j := 0; j < len(raw); j += recoveryCodeDivider {
+			parts = append(parts, raw[j:j+recoveryCodeDivider])
+		}
+		codes[i] = strings.Join(parts, "-")
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode hash recovery code yang disimpan di database. Recovery code cukup acak
+// sehingga sha256 tanpa salt memadai, huruf besar, spasi dan tanda hubung diabaikan.
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsTotpCode return true jika code berbentuk code TOTP (angka sepanjang digit), selain itu dianggap recovery code
+func IsTotpCode(code string) bool {
+	code = strings.TrimSpace(code)
+	if len(code) != digits.Length() {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatQRDataURI mengubah png menjadi data URI agar dapat langsung ditampilkan pada tag img
+func FormatQRDataURI(png []byte) string {
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)
+}
@@ -0,0 +1,107 @@
+package mtotp
+
+import (
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "JBSWY3DPEHPK3PXP"
+
+func codeAt(t *testing.T, at time.Time) string {
+	t.Helper()
+	code, err := totp.GenerateCodeCustom(testSecret, at, totp.ValidateOpts{
+		Period:    period,
+		Digits:    digits,
+		Algorithm: otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	current := now.Unix() / period
+	totpUtils := NewTotp()
+
+	for _, tt := range []struct {
+		name   string
+		offset time.Duration
+		valid  bool
+		step   int64
+	}{
+		{"step sekarang", 0, true, current},
+		{"step sebelumnya", -period * time.Second, true, current - 1},
+		{"step berikutnya", period * time.Second, true, current + 1},
+		{"di luar skew", -2 * period * time.Second, false, 0},
+	} {
+		step, valid := totpUtils.Validate(testSecret, codeAt(t, now.Add(tt.offset)), now)
+		if valid != tt.valid || step != tt.step {
+			t.Errorf("%s: valid %v step %d, harusnya valid %v step %d", tt.name, valid, step, tt.valid, tt.step)
+		}
+	}
+
+	if _, valid := totpUtils.Validate(testSecret, "12345", now); valid {
+		t.Error("code dengan panjang salah harusnya ditolak")
+	}
+}
+
+func TestEncryptSecret(t *testing.T) {
+	secretKey = make([]byte, 32)
+	totpUtils := NewTotp()
+
+	encrypted, apiErr := totpUtils.EncryptSecret(testSecret)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	if strings.Contains(string(encrypted), testSecret) {
+		t.Error("secret tidak boleh tersimpan dalam bentuk asli")
+	}
+	secret, apiErr := totpUtils.DecryptSecret(encrypted)
+	if apiErr != nil || secret != testSecret {
+		t.Errorf("secret hasil decrypt %q, harusnya %q", secret, testSecret)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, apiErr := GenerateRecoveryCodes(10)
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 19 || strings.Count(code, "-") != 3 {
+			t.Errorf("format recovery code salah: %s", code)
+		}
+		if IsTotpCode(code) {
+			t.Errorf("recovery code %s tidak boleh dianggap code TOTP", code)
+		}
+		if seen[code] {
+			t.Errorf("recovery code %s duplikat", code)
+		}
+		seen[code] = true
+	}
+
+	// huruf besar, spasi dan tanda hubung diabaikan
+	if HashRecoveryCode(codes[0]) != HashRecoveryCode(" "+strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))+" ") {
+		t.Error("hash recovery code harusnya sama setelah normalisasi")
+	}
+}
+
+func TestIsTotpCode(t *testing.T) {
+	for code, want := range map[string]bool{
+		"123456":              true,
+		" 123456 ":            true,
+		"12345a":              false,
+		"1234567":             false,
+		"abcd-efgh-ijkl-mnop": false,
+	} {
+		if got := IsTotpCode(code); got != want {
+			t.Errorf("IsTotpCode(%q) = %v, harusnya %v", code, got, want)
+		}
+	}
+}